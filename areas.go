@@ -0,0 +1,193 @@
+package gtfs
+
+import (
+	"github.com/jamespfennell/gtfs/csv"
+	"github.com/jamespfennell/gtfs/warnings"
+)
+
+// Area corresponds to a single row in the areas.txt file.
+type Area struct {
+	Id   string
+	Name string
+	// Stops in the area, as specified in the stop_areas.txt file.
+	Stops []*Stop
+	Extra map[string]string
+}
+
+// Network corresponds to a single row in the networks.txt file.
+type Network struct {
+	Id   string
+	Name string
+	// Routes in the network, as specified in the route_networks.txt file.
+	Routes []*Route
+	Extra  map[string]string
+}
+
+func parseAreas(csv *csv.File) ([]Area, []warnings.StaticWarning) {
+	var w []warnings.StaticWarning
+	idColumn := csv.RequiredColumn("area_id")
+	nameColumn := csv.OptionalColumn("area_name")
+
+	if warnings := checkForMissingColumns(csv); len(warnings) > 0 {
+		return nil, warnings
+	}
+
+	var areas []Area
+	for csv.NextRow() {
+		area := Area{
+			Id:    idColumn.Read(),
+			Name:  nameColumn.Read(),
+			Extra: csv.ExtraColumns(),
+		}
+		if missingKeys := csv.MissingRowKeys(); len(missingKeys) > 0 {
+			w = append(w, warnings.NewStaticWarning(csv, warnings.MissingValues{Columns: missingKeys}))
+			continue
+		}
+		areas = append(areas, area)
+	}
+	return areas, w
+}
+
+func parseStopAreas(csv *csv.File, areas []Area, stops []Stop) []warnings.StaticWarning {
+	var w []warnings.StaticWarning
+	areaIDColumn := csv.RequiredColumn("area_id")
+	stopIDColumn := csv.RequiredColumn("stop_id")
+
+	if warnings := checkForMissingColumns(csv); len(warnings) > 0 {
+		return warnings
+	}
+
+	idToArea := map[string]*Area{}
+	for i := range areas {
+		idToArea[areas[i].Id] = &areas[i]
+	}
+	idToStop := map[string]*Stop{}
+	for i := range stops {
+		idToStop[stops[i].Id] = &stops[i]
+	}
+	for csv.NextRow() {
+		areaID := areaIDColumn.Read()
+		stopID := stopIDColumn.Read()
+		if missingKeys := csv.MissingRowKeys(); len(missingKeys) > 0 {
+			w = append(w, warnings.NewStaticWarning(csv, warnings.MissingValues{Columns: missingKeys}))
+			continue
+		}
+		area, ok := idToArea[areaID]
+		if !ok {
+			w = append(w, warnings.NewStaticWarning(csv, warnings.InvalidAreaReference{AreaID: areaID}))
+			continue
+		}
+		stop, ok := idToStop[stopID]
+		if !ok {
+			w = append(w, warnings.NewStaticWarning(csv, warnings.InvalidStopReference{StopID: stopID}))
+			continue
+		}
+		area.Stops = append(area.Stops, stop)
+		stop.Areas = append(stop.Areas, area)
+	}
+	return w
+}
+
+func parseNetworks(csv *csv.File) ([]Network, []warnings.StaticWarning) {
+	var w []warnings.StaticWarning
+	idColumn := csv.RequiredColumn("network_id")
+	nameColumn := csv.OptionalColumn("network_name")
+
+	if warnings := checkForMissingColumns(csv); len(warnings) > 0 {
+		return nil, warnings
+	}
+
+	var networks []Network
+	for csv.NextRow() {
+		network := Network{
+			Id:    idColumn.Read(),
+			Name:  nameColumn.Read(),
+			Extra: csv.ExtraColumns(),
+		}
+		if missingKeys := csv.MissingRowKeys(); len(missingKeys) > 0 {
+			w = append(w, warnings.NewStaticWarning(csv, warnings.MissingValues{Columns: missingKeys}))
+			continue
+		}
+		networks = append(networks, network)
+	}
+	return networks, w
+}
+
+func parseRouteNetworks(csv *csv.File, networks []Network, routes []Route) []warnings.StaticWarning {
+	var w []warnings.StaticWarning
+	networkIDColumn := csv.RequiredColumn("network_id")
+	routeIDColumn := csv.RequiredColumn("route_id")
+
+	if warnings := checkForMissingColumns(csv); len(warnings) > 0 {
+		return warnings
+	}
+
+	idToNetwork := map[string]*Network{}
+	for i := range networks {
+		idToNetwork[networks[i].Id] = &networks[i]
+	}
+	idToRoute := map[string]*Route{}
+	for i := range routes {
+		idToRoute[routes[i].Id] = &routes[i]
+	}
+	for csv.NextRow() {
+		networkID := networkIDColumn.Read()
+		routeID := routeIDColumn.Read()
+		if missingKeys := csv.MissingRowKeys(); len(missingKeys) > 0 {
+			w = append(w, warnings.NewStaticWarning(csv, warnings.MissingValues{Columns: missingKeys}))
+			continue
+		}
+		network, ok := idToNetwork[networkID]
+		if !ok {
+			w = append(w, warnings.NewStaticWarning(csv, warnings.InvalidNetworkReference{NetworkID: networkID}))
+			continue
+		}
+		route, ok := idToRoute[routeID]
+		if !ok {
+			w = append(w, warnings.NewStaticWarning(csv, warnings.InvalidRouteReference{RouteID: routeID}))
+			continue
+		}
+		if route.Network != nil {
+			w = append(w, warnings.NewStaticWarning(csv, warnings.RouteInMultipleNetworks{RouteID: routeID}))
+			continue
+		}
+		network.Routes = append(network.Routes, route)
+		route.Network = network
+	}
+	return w
+}
+
+// buildNetworksFromRoutes builds the networks implicitly defined by the network_id column
+// of the routes.txt file. Networks appear in the order they are first referenced.
+func buildNetworksFromRoutes(routes []Route, routeIDToNetworkID map[string]string) []Network {
+	var networks []Network
+	networkIDToIndex := map[string]int{}
+	for i := range routes {
+		networkID, ok := routeIDToNetworkID[routes[i].Id]
+		if !ok {
+			continue
+		}
+		if _, ok := networkIDToIndex[networkID]; !ok {
+			networkIDToIndex[networkID] = len(networks)
+			networks = append(networks, Network{Id: networkID})
+		}
+	}
+	for i := range routes {
+		networkID, ok := routeIDToNetworkID[routes[i].Id]
+		if !ok {
+			continue
+		}
+		network := &networks[networkIDToIndex[networkID]]
+		network.Routes = append(network.Routes, &routes[i])
+		routes[i].Network = network
+	}
+	return networks
+}
+
+// forbiddenByRouteNetworkIdColumn returns the warning raised when the networks.txt or
+// route_networks.txt file is provided alongside the network_id column of the routes.txt file.
+func forbiddenByRouteNetworkIdColumn(csv *csv.File) warnings.StaticWarning {
+	return warnings.NewStaticWarning(csv, warnings.ConditionallyForbiddenFile{
+		Reason: "routes.txt has a network_id column",
+	})
+}
@@ -0,0 +1,183 @@
+package gtfs
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jamespfennell/gtfs/warnings"
+)
+
+func TestParseAreas(t *testing.T) {
+	static, err := ParseStatic(newZipBuilderWithDefaults().add(
+		"stops.txt",
+		"stop_id",
+		"stop_1",
+		"stop_2",
+	).add(
+		"areas.txt",
+		"area_id,area_name",
+		"area_1,Area 1",
+		"area_2,Area 2",
+	).add(
+		"stop_areas.txt",
+		"area_id,stop_id",
+		"area_1,stop_1",
+		"area_2,stop_1",
+		"area_2,stop_2",
+		"area_3,stop_2",
+		"area_1,stop_3",
+	).build(), ParseStaticOptions{})
+	if err != nil {
+		t.Fatalf("error when parsing: %s", err)
+	}
+
+	area1, area2 := &static.Areas[0], &static.Areas[1]
+	stop1, stop2 := &static.Stops[0], &static.Stops[1]
+	if len(area1.Stops) != 1 || area1.Stops[0] != stop1 {
+		t.Errorf("area 1 stops: got %+v, want stop 1", area1.Stops)
+	}
+	if len(area2.Stops) != 2 || area2.Stops[0] != stop1 || area2.Stops[1] != stop2 {
+		t.Errorf("area 2 stops: got %+v, want stops 1 and 2", area2.Stops)
+	}
+	if len(stop1.Areas) != 2 || stop1.Areas[0] != area1 || stop1.Areas[1] != area2 {
+		t.Errorf("stop 1 areas: got %+v, want areas 1 and 2", stop1.Areas)
+	}
+	if len(stop2.Areas) != 1 || stop2.Areas[0] != area2 {
+		t.Errorf("stop 2 areas: got %+v, want area 2", stop2.Areas)
+	}
+
+	var gotWarnings []warnings.StaticWarningKind
+	for _, w := range static.Warnings {
+		gotWarnings = append(gotWarnings, w.Kind)
+	}
+	wantWarnings := []warnings.StaticWarningKind{
+		warnings.InvalidAreaReference{AreaID: "area_3"},
+		warnings.InvalidStopReference{StopID: "stop_3"},
+	}
+	if diff := cmp.Diff(wantWarnings, gotWarnings); diff != "" {
+		t.Errorf("warnings not the same: %s", diff)
+	}
+}
+
+func TestParseNetworks(t *testing.T) {
+	for _, tc := range []struct {
+		desc         string
+		content      []byte
+		wantNetworks []string
+		wantRoutes   map[string]string
+		wantWarnings []warnings.StaticWarningKind
+	}{
+		{
+			desc: "route_networks.txt",
+			content: newZipBuilderWithDefaults().add(
+				"routes.txt",
+				"route_id,route_type",
+				"route_1,3",
+				"route_2,3",
+				"route_3,3",
+			).add(
+				"networks.txt",
+				"network_id,network_name",
+				"network_1,Network 1",
+				"network_2,Network 2",
+			).add(
+				"route_networks.txt",
+				"network_id,route_id",
+				"network_1,route_1",
+				"network_2,route_2",
+				"network_2,route_1",
+				"network_3,route_3",
+			).build(),
+			wantNetworks: []string{"network_1", "network_2"},
+			wantRoutes: map[string]string{
+				"route_1": "network_1",
+				"route_2": "network_2",
+			},
+			wantWarnings: []warnings.StaticWarningKind{
+				warnings.InvalidRouteReference{RouteID: "route_id"},
+				warnings.RouteInMultipleNetworks{RouteID: "route_1"},
+				warnings.InvalidNetworkReference{NetworkID: "network_3"},
+			},
+		},
+		{
+			desc: "network_id column in routes.txt",
+			content: newZipBuilderWithDefaults().add(
+				"routes.txt",
+				"route_id,route_type,network_id",
+				"route_1,3,network_2",
+				"route_2,3,network_1",
+				"route_3,3,network_2",
+				"route_4,3,",
+			).build(),
+			wantNetworks: []string{"network_2", "network_1"},
+			wantRoutes: map[string]string{
+				"route_1": "network_2",
+				"route_2": "network_1",
+				"route_3": "network_2",
+			},
+			wantWarnings: []warnings.StaticWarningKind{
+				warnings.InvalidRouteReference{RouteID: "route_id"},
+			},
+		},
+		{
+			desc: "conditionally forbidden files",
+			content: newZipBuilderWithDefaults().add(
+				"routes.txt",
+				"route_id,route_type,network_id",
+				"route_1,3,network_1",
+			).add(
+				"networks.txt",
+				"network_id,network_name",
+				"network_2,Network 2",
+			).add(
+				"route_networks.txt",
+				"network_id,route_id",
+				"network_2,route_1",
+			).build(),
+			wantNetworks: []string{"network_1"},
+			wantRoutes: map[string]string{
+				"route_1": "network_1",
+			},
+			wantWarnings: []warnings.StaticWarningKind{
+				warnings.InvalidRouteReference{RouteID: "route_id"},
+				warnings.ConditionallyForbiddenFile{Reason: "routes.txt has a network_id column"},
+				warnings.ConditionallyForbiddenFile{Reason: "routes.txt has a network_id column"},
+			},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			static, err := ParseStatic(tc.content, ParseStaticOptions{})
+			if err != nil {
+				t.Fatalf("error when parsing: %s", err)
+			}
+			var gotNetworks []string
+			for _, network := range static.Networks {
+				gotNetworks = append(gotNetworks, network.Id)
+				for _, route := range network.Routes {
+					if route.Network == nil || route.Network.Id != network.Id {
+						t.Errorf("route %s is in network %s but has network %+v", route.Id, network.Id, route.Network)
+					}
+				}
+			}
+			if diff := cmp.Diff(tc.wantNetworks, gotNetworks); diff != "" {
+				t.Errorf("networks not the same: %s", diff)
+			}
+			gotRoutes := map[string]string{}
+			for _, route := range static.Routes {
+				if route.Network != nil {
+					gotRoutes[route.Id] = route.Network.Id
+				}
+			}
+			if diff := cmp.Diff(tc.wantRoutes, gotRoutes); diff != "" {
+				t.Errorf("route networks not the same: %s", diff)
+			}
+			var gotWarnings []warnings.StaticWarningKind
+			for _, w := range static.Warnings {
+				gotWarnings = append(gotWarnings, w.Kind)
+			}
+			if diff := cmp.Diff(tc.wantWarnings, gotWarnings); diff != "" {
+				t.Errorf("warnings not the same: %s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package gtfs
+
+import "time"
+
+// IsActiveOn returns whether the service runs on the day of the date.
+//
+// The day of the date is taken in the time zone of the dates of the service, which the parser sets
+// to the time zone of the first agency of the feed. For example, for a feed of an agency in New York,
+// IsActiveOn(time.Now()) returns whether the service runs today in New York wherever the program runs.
+// To check a calendar day, create the date in that time zone, for example with
+// time.Date(2024, time.May, 6, 0, 0, 0, 0, service.StartDate.Location()).
+//
+// Dates in RemovedDates take precedence over dates in AddedDates, which take precedence over the
+// days of the week between CalendarStartDate and CalendarEndDate. StartDate and EndDate are not used
+// as they also cover the dates in calendar_dates.txt.
+func (service *Service) IsActiveOn(date time.Time) bool {
+	return service.isActiveOnDay(service.day(date))
+}
+
+// ActiveDates returns the days between the two dates, inclusive, on which the service runs, in order.
+//
+// The days of the dates are taken in the time zone of the service, like for IsActiveOn, and the
+// returned dates are midnight in that time zone.
+func (service *Service) ActiveDates(from, to time.Time) []time.Time {
+	from, to = service.day(from), service.day(to)
+	// The range of days on which the service can run, which covers the calendar range and the added dates.
+	var start, end time.Time
+	if !service.CalendarStartDate.IsZero() {
+		start, end = civilDate(service.CalendarStartDate), civilDate(service.CalendarEndDate)
+	}
+	for _, addedDate := range service.AddedDates {
+		addedDate := civilDate(addedDate)
+		if start.IsZero() || addedDate.Before(start) {
+			start = addedDate
+		}
+		if end.IsZero() || end.Before(addedDate) {
+			end = addedDate
+		}
+	}
+	if start.IsZero() {
+		return nil
+	}
+	if from.Before(start) {
+		from = start
+	}
+	if end.Before(to) {
+		to = end
+	}
+	var dates []time.Time
+	for date := from; !date.After(to); date = date.AddDate(0, 0, 1) {
+		if service.isActiveOnDay(date) {
+			year, month, day := date.Date()
+			dates = append(dates, time.Date(year, month, day, 0, 0, 0, 0, service.location()))
+		}
+	}
+	return dates
+}
+
+// ServicesOn returns the services that run on the day of the date, in the order of the Services field.
+//
+// The day of the date is taken in the time zone of each service, like for Service.IsActiveOn.
+func (static *Static) ServicesOn(date time.Time) []*Service {
+	var services []*Service
+	for i := range static.Services {
+		if service := &static.Services[i]; service.IsActiveOn(date) {
+			services = append(services, service)
+		}
+	}
+	return services
+}
+
+// isActiveOnDay returns whether the service runs on the day, which is given as midnight UTC.
+func (service *Service) isActiveOnDay(day time.Time) bool {
+	for _, removedDate := range service.RemovedDates {
+		if civilDate(removedDate).Equal(day) {
+			return false
+		}
+	}
+	for _, addedDate := range service.AddedDates {
+		if civilDate(addedDate).Equal(day) {
+			return true
+		}
+	}
+	if service.CalendarStartDate.IsZero() ||
+		day.Before(civilDate(service.CalendarStartDate)) || civilDate(service.CalendarEndDate).Before(day) {
+		return false
+	}
+	switch day.Weekday() {
+	case time.Monday:
+		return service.Monday
+	case time.Tuesday:
+		return service.Tuesday
+	case time.Wednesday:
+		return service.Wednesday
+	case time.Thursday:
+		return service.Thursday
+	case time.Friday:
+		return service.Friday
+	case time.Saturday:
+		return service.Saturday
+	default:
+		return service.Sunday
+	}
+}
+
+// location returns the time zone of the dates of the service.
+func (service *Service) location() *time.Location {
+	return service.StartDate.Location()
+}
+
+// day returns the day of the date in the time zone of the service, as midnight UTC.
+func (service *Service) day(date time.Time) time.Time {
+	return civilDate(date.In(service.location()))
+}
+
+// civilDate returns midnight UTC on the day of the time in its location.
+func civilDate(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
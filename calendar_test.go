@@ -0,0 +1,173 @@
+package gtfs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// newCalendarStatic returns a feed in New York with a weekday service that doesn't run on
+// Memorial Day 2024, a weekend service that also runs on that day, and a weekday service for one
+// week with a removed date a week later.
+func newCalendarStatic(t *testing.T) *Static {
+	t.Helper()
+	return mustParseStatic(t, newZipBuilderWithDefaults().add(
+		"agency.txt",
+		"agency_id,agency_name,agency_url,agency_timezone",
+		"agency,Agency,https://example.com,America/New_York",
+	).add(
+		"calendar.txt",
+		"service_id,monday,tuesday,wednesday,thursday,friday,saturday,sunday,start_date,end_date",
+		"weekday,1,1,1,1,1,0,0,20240501,20240531",
+		"weekend,0,0,0,0,0,1,1,20240501,20240531",
+		"one_week,1,1,1,1,1,0,0,20240520,20240524",
+	).add(
+		"calendar_dates.txt",
+		"service_id,date,exception_type",
+		"weekday,20240527,2",
+		"weekend,20240527,1",
+		"special,20240604,1",
+		"one_week,20240531,2",
+	).add(
+		"trips.txt",
+		"route_id,service_id,trip_id",
+		"route_id,weekday,weekday_trip",
+		"route_id,weekend,weekend_trip",
+		"route_id,special,special_trip",
+		"route_id,one_week,one_week_trip",
+	).build())
+}
+
+func TestService_IsActiveOn(t *testing.T) {
+	static := newCalendarStatic(t)
+	newYork := static.Services[0].StartDate.Location()
+	for _, tc := range []struct {
+		desc    string
+		service string
+		date    time.Time
+		want    bool
+	}{
+		{"weekday", "weekday", time.Date(2024, 5, 6, 0, 0, 0, 0, newYork), true},
+		{"weekend day", "weekday", time.Date(2024, 5, 4, 0, 0, 0, 0, newYork), false},
+		{"before start date", "weekday", time.Date(2024, 4, 30, 0, 0, 0, 0, newYork), false},
+		{"end date", "weekday", time.Date(2024, 5, 31, 0, 0, 0, 0, newYork), true},
+		{"after end date", "weekday", time.Date(2024, 6, 3, 0, 0, 0, 0, newYork), false},
+		{"removed date", "weekday", time.Date(2024, 5, 27, 0, 0, 0, 0, newYork), false},
+		{"added date", "weekend", time.Date(2024, 5, 27, 0, 0, 0, 0, newYork), true},
+		{"added date only", "special", time.Date(2024, 6, 4, 12, 0, 0, 0, newYork), true},
+		{"calendar range", "one_week", time.Date(2024, 5, 24, 0, 0, 0, 0, newYork), true},
+		{"before removed date outside of calendar range", "one_week", time.Date(2024, 5, 28, 0, 0, 0, 0, newYork), false},
+		{"time of day", "weekday", time.Date(2024, 5, 6, 23, 59, 0, 0, newYork), true},
+		// 02:00 UTC on Saturday is still Friday in New York.
+		{"other time zone", "weekday", time.Date(2024, 5, 4, 2, 0, 0, 0, time.UTC), true},
+		{"other time zone, next day", "weekday", time.Date(2024, 5, 4, 5, 0, 0, 0, time.UTC), false},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			service := serviceByID(t, static, tc.service)
+			if got := service.IsActiveOn(tc.date); got != tc.want {
+				t.Errorf("IsActiveOn(%s) = %t, want %t", tc.date, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestService_ActiveDates(t *testing.T) {
+	static := newCalendarStatic(t)
+	newYork := static.Services[0].StartDate.Location()
+	for _, tc := range []struct {
+		desc     string
+		service  string
+		from, to time.Time
+		want     []string
+	}{
+		{
+			desc:    "weekday",
+			service: "weekday",
+			from:    time.Date(2024, 5, 24, 0, 0, 0, 0, newYork),
+			to:      time.Date(2024, 5, 29, 0, 0, 0, 0, newYork),
+			want:    []string{"2024-05-24", "2024-05-28", "2024-05-29"},
+		},
+		{
+			desc:    "weekend",
+			service: "weekend",
+			from:    time.Date(2024, 5, 24, 0, 0, 0, 0, newYork),
+			to:      time.Date(2024, 5, 29, 0, 0, 0, 0, newYork),
+			want:    []string{"2024-05-25", "2024-05-26", "2024-05-27"},
+		},
+		{
+			desc:    "window larger than the service",
+			service: "weekday",
+			from:    time.Date(2024, 5, 29, 0, 0, 0, 0, newYork),
+			to:      time.Date(2025, 1, 1, 0, 0, 0, 0, newYork),
+			want:    []string{"2024-05-29", "2024-05-30", "2024-05-31"},
+		},
+		{
+			desc:    "added date only",
+			service: "special",
+			from:    time.Date(2024, 1, 1, 0, 0, 0, 0, newYork),
+			to:      time.Date(2024, 12, 31, 0, 0, 0, 0, newYork),
+			want:    []string{"2024-06-04"},
+		},
+		{
+			desc:    "removed date outside of calendar range",
+			service: "one_week",
+			from:    time.Date(2024, 5, 1, 0, 0, 0, 0, newYork),
+			to:      time.Date(2024, 5, 31, 0, 0, 0, 0, newYork),
+			want:    []string{"2024-05-20", "2024-05-21", "2024-05-22", "2024-05-23", "2024-05-24"},
+		},
+		{
+			desc:    "empty window",
+			service: "weekday",
+			from:    time.Date(2024, 5, 10, 0, 0, 0, 0, newYork),
+			to:      time.Date(2024, 5, 9, 0, 0, 0, 0, newYork),
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			service := serviceByID(t, static, tc.service)
+			var got []string
+			for _, date := range service.ActiveDates(tc.from, tc.to) {
+				if date.Location() != newYork || date.Hour() != 0 {
+					t.Errorf("date %s is not midnight in New York", date)
+				}
+				got = append(got, date.Format("2006-01-02"))
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("active dates not the same: %s", diff)
+			}
+		})
+	}
+}
+
+func TestStatic_ServicesOn(t *testing.T) {
+	static := newCalendarStatic(t)
+	newYork := static.Services[0].StartDate.Location()
+	for _, tc := range []struct {
+		date time.Time
+		want []string
+	}{
+		{time.Date(2024, 5, 6, 0, 0, 0, 0, newYork), []string{"weekday"}},
+		{time.Date(2024, 5, 27, 0, 0, 0, 0, newYork), []string{"weekend"}},
+		{time.Date(2024, 6, 4, 0, 0, 0, 0, newYork), []string{"special"}},
+		{time.Date(2024, 6, 5, 0, 0, 0, 0, newYork), nil},
+	} {
+		var got []string
+		for _, service := range static.ServicesOn(tc.date) {
+			got = append(got, service.Id)
+		}
+		if diff := cmp.Diff(tc.want, got); diff != "" {
+			t.Errorf("ServicesOn(%s) not the same: %s", tc.date, diff)
+		}
+	}
+}
+
+func serviceByID(t *testing.T, static *Static, id string) *Service {
+	t.Helper()
+	for i := range static.Services {
+		if static.Services[i].Id == id {
+			return &static.Services[i]
+		}
+	}
+	t.Fatalf("service %s not found", id)
+	return nil
+}
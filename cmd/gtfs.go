@@ -1,6 +1,7 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -9,9 +10,12 @@ import (
 
 	"github.com/fatih/color"
 	"github.com/jamespfennell/gtfs"
+	"github.com/jamespfennell/gtfs/diff"
 	"github.com/jamespfennell/gtfs/extensions/nyctalerts"
 	"github.com/jamespfennell/gtfs/extensions/nycttrips"
 	"github.com/jamespfennell/gtfs/journal"
+	"github.com/jamespfennell/gtfs/validate"
+	"github.com/jamespfennell/gtfs/warnings"
 	"github.com/urfave/cli/v2"
 )
 
@@ -48,6 +52,90 @@ func main() {
 					return nil
 				},
 			},
+			{
+				Name:      "validate",
+				Usage:     "validate a GTFS static feed and print a report of the problems found",
+				ArgsUsage: "path",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "json",
+						Usage: "print the report as JSON",
+					},
+					&cli.IntFlag{
+						Name:  "samples",
+						Value: 3,
+						Usage: "number of sample warnings to print for each kind of warning",
+					},
+				},
+				Action: func(ctx *cli.Context) error {
+					args := ctx.Args()
+					if args.Len() == 0 {
+						return fmt.Errorf("a path to the GTFS static feed was not provided")
+					}
+					path := args.First()
+					static, err := parseStaticFeed(path)
+					if err != nil {
+						return fmt.Errorf("failed to parse GTFS static data: %w", err)
+					}
+					ws := append(static.Warnings, validate.Static(static)...)
+					groups := warnings.Aggregate(ws, ctx.Int("samples"))
+					if ctx.Bool("json") {
+						b, err := json.MarshalIndent(groups, "", "  ")
+						if err != nil {
+							return err
+						}
+						fmt.Println(string(b))
+					} else {
+						fmt.Print(formatValidationReport(groups))
+					}
+					var numErrors int
+					for _, group := range groups {
+						if group.Severity == warnings.SeverityError {
+							numErrors += group.Count
+						}
+					}
+					if numErrors > 0 {
+						return fmt.Errorf("the feed has %d errors", numErrors)
+					}
+					return nil
+				},
+			},
+			{
+				Name:      "static-diff",
+				Usage:     "print the changes between two versions of a GTFS static feed",
+				ArgsUsage: "old_path new_path",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "json",
+						Usage: "print the changes as JSON",
+					},
+				},
+				Action: func(ctx *cli.Context) error {
+					args := ctx.Args()
+					if args.Len() != 2 {
+						return fmt.Errorf("paths to the old and new GTFS static feeds were not provided")
+					}
+					before, err := parseStaticFeed(args.Get(0))
+					if err != nil {
+						return fmt.Errorf("failed to parse old GTFS static data: %w", err)
+					}
+					after, err := parseStaticFeed(args.Get(1))
+					if err != nil {
+						return fmt.Errorf("failed to parse new GTFS static data: %w", err)
+					}
+					changes := diff.Static(before, after)
+					if ctx.Bool("json") {
+						b, err := json.MarshalIndent(changes, "", "  ")
+						if err != nil {
+							return err
+						}
+						fmt.Println(string(b))
+					} else {
+						fmt.Print(formatChanges(changes))
+					}
+					return nil
+				},
+			},
 			{
 				Name:      "realtime",
 				Usage:     "parse a GTFS realtime message",
@@ -154,6 +242,97 @@ func main() {
 	}
 }
 
+// parseStaticFeed parses the GTFS static feed in the zip file or directory.
+func parseStaticFeed(path string) (*gtfs.Static, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return gtfs.ParseStaticFS(os.DirFS(path), gtfs.ParseStaticOptions{})
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return gtfs.ParseStatic(b, gtfs.ParseStaticOptions{})
+}
+
+func formatValidationReport(groups []warnings.StaticWarningGroup) string {
+	var b strings.Builder
+	severityToColor := map[warnings.Severity]*color.Color{
+		warnings.SeverityInfo:    color.New(color.FgCyan),
+		warnings.SeverityWarning: color.New(color.FgYellow),
+		warnings.SeverityError:   color.New(color.FgRed),
+	}
+	severityToCount := map[warnings.Severity]int{}
+	for _, group := range groups {
+		severityToCount[group.Severity] += group.Count
+		fmt.Fprintf(&b, "%s %s %s (%d)\n",
+			severityToColor[group.Severity].Sprintf("%-7s", group.Severity),
+			group.File,
+			group.Code,
+			group.Count,
+		)
+		for _, sample := range group.Samples {
+			if sample.RowContent != nil {
+				fmt.Fprintf(&b, "  - row %d: %s\n", sample.RowNumber, sample.Kind)
+			} else {
+				fmt.Fprintf(&b, "  - %s\n", sample.Kind)
+			}
+		}
+	}
+	fmt.Fprintf(&b, "%d errors, %d warnings, %d infos\n",
+		severityToCount[warnings.SeverityError],
+		severityToCount[warnings.SeverityWarning],
+		severityToCount[warnings.SeverityInfo],
+	)
+	return b.String()
+}
+
+func formatChanges(changes *diff.Changes) string {
+	var b strings.Builder
+	kindToPrefix := map[diff.Kind]string{
+		diff.Added:    color.GreenString("+"),
+		diff.Removed:  color.RedString("-"),
+		diff.Modified: color.YellowString("~"),
+	}
+	formatSection := func(name string, changes []diff.Change, distances []*float64) {
+		kindToCount := map[diff.Kind]int{}
+		for _, change := range changes {
+			kindToCount[change.Kind]++
+		}
+		fmt.Fprintf(&b, "%s: %d added, %d removed, %d modified\n",
+			name,
+			kindToCount[diff.Added],
+			kindToCount[diff.Removed],
+			kindToCount[diff.Modified],
+		)
+		for i, change := range changes {
+			if distances != nil && distances[i] != nil {
+				fmt.Fprintf(&b, "  %s %s (moved %.0f meters)\n", kindToPrefix[change.Kind], change.ID, *distances[i])
+			} else {
+				fmt.Fprintf(&b, "  %s %s\n", kindToPrefix[change.Kind], change.ID)
+			}
+			for _, field := range change.Fields {
+				fmt.Fprintf(&b, "      %s: %q -> %q\n", field.Field, field.Old, field.New)
+			}
+		}
+	}
+	formatSection("Routes", changes.Routes, nil)
+	var stops []diff.Change
+	var distances []*float64
+	for _, stop := range changes.Stops {
+		stops = append(stops, stop.Change)
+		distances = append(distances, stop.Distance)
+	}
+	formatSection("Stops", stops, distances)
+	formatSection("Trips", changes.Trips, nil)
+	formatSection("Services", changes.Services, nil)
+	formatSection("Shapes", changes.Shapes, nil)
+	return b.String()
+}
+
 func readGtfsRealtimeExtension(s string, opts *gtfs.ParseRealtimeOptions) error {
 	switch s {
 	case "":
@@ -0,0 +1,265 @@
+package gtfs
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// CompactStopTimes contains the stop times of a trip when the feed is parsed with the
+// CompactStopTimes option.
+//
+// The stop times of all trips are stored in a single table in struct-of-arrays form, which
+// uses a fraction of the memory of a slice of ScheduledStopTime values. Stop times are
+// materialized as ScheduledStopTime values when they are accessed.
+type CompactStopTimes struct {
+	trip  *ScheduledTrip
+	table *stopTimeTable
+	start int
+	end   int
+}
+
+// Len returns the number of stop times.
+func (c *CompactStopTimes) Len() int {
+	return c.end - c.start
+}
+
+// At returns the i-th stop time, in order of stop sequence.
+//
+// The stop time is a copy and modifying it does not modify the compact representation.
+func (c *CompactStopTimes) At(i int) ScheduledStopTime {
+	return c.table.stopTime(c.trip, c.start+i)
+}
+
+// NumStopTimes returns the number of stop times of the trip, whether or not they are stored compactly.
+func (trip *ScheduledTrip) NumStopTimes() int {
+	if trip.CompactStopTimes != nil {
+		return trip.CompactStopTimes.Len()
+	}
+	return len(trip.StopTimes)
+}
+
+// StopTime returns the i-th stop time of the trip, in order of stop sequence, whether or
+// not the stop times are stored compactly.
+func (trip *ScheduledTrip) StopTime(i int) ScheduledStopTime {
+	if trip.CompactStopTimes != nil {
+		return trip.CompactStopTimes.At(i)
+	}
+	return trip.StopTimes[i]
+}
+
+// stopTimeTable stores stop times in struct-of-arrays form. Each column contains one element per row.
+type stopTimeTable struct {
+	stops []Stop
+	// Index of the stop in the stops slice, or -1 for stop times without a stop.
+	stopIndices []int32
+	// Times in seconds.
+	arrivalTimes   []int32
+	departureTimes []int32
+	stopSequences  []int32
+	// Index of the headsign in the headsigns slice, which contains each distinct headsign once.
+	headsignIndices []int32
+	headsigns       []string
+	// The pickup type, drop off type, continuous pickup and continuous drop off policies,
+	// two bits each.
+	policies   []uint8
+	exactTimes []bool
+	// Shape distance traveled, or NaN if not specified. Nil if no row specifies it.
+	shapeDistances []float64
+	// GTFS-Flex fields of the rows that have any, which are rare.
+	flex map[int]*flexStopTime
+	// Extra columns of the rows that have any.
+	extra map[int]map[string]string
+}
+
+type flexStopTime struct {
+	LocationGroup            *LocationGroup
+	Location                 *Location
+	StartPickupDropOffWindow *time.Duration
+	EndPickupDropOffWindow   *time.Duration
+	PickupBookingRule        *BookingRule
+	DropOffBookingRule       *BookingRule
+}
+
+func (t *stopTimeTable) stopTime(trip *ScheduledTrip, row int) ScheduledStopTime {
+	policies := t.policies[row]
+	stopTime := ScheduledStopTime{
+		Trip:              trip,
+		ArrivalTime:       time.Duration(t.arrivalTimes[row]) * time.Second,
+		DepartureTime:     time.Duration(t.departureTimes[row]) * time.Second,
+		StopSequence:      int(t.stopSequences[row]),
+		Headsign:          t.headsigns[t.headsignIndices[row]],
+		PickupType:        PickupDropOffPolicy(policies & 3),
+		DropOffType:       PickupDropOffPolicy(policies >> 2 & 3),
+		ContinuousPickup:  PickupDropOffPolicy(policies >> 4 & 3),
+		ContinuousDropOff: PickupDropOffPolicy(policies >> 6 & 3),
+		ExactTimes:        t.exactTimes[row],
+	}
+	if i := t.stopIndices[row]; i >= 0 {
+		stopTime.Stop = &t.stops[i]
+	}
+	if t.shapeDistances != nil && !math.IsNaN(t.shapeDistances[row]) {
+		d := t.shapeDistances[row]
+		stopTime.ShapeDistanceTraveled = &d
+	}
+	if f, ok := t.flex[row]; ok {
+		stopTime.LocationGroup = f.LocationGroup
+		stopTime.Location = f.Location
+		stopTime.StartPickupDropOffWindow = f.StartPickupDropOffWindow
+		stopTime.EndPickupDropOffWindow = f.EndPickupDropOffWindow
+		stopTime.PickupBookingRule = f.PickupBookingRule
+		stopTime.DropOffBookingRule = f.DropOffBookingRule
+	}
+	stopTime.Extra = t.extra[row]
+	return stopTime
+}
+
+// compactStopTimesBuilder builds the compact stop times of trips from stop times added in any order.
+type compactStopTimesBuilder struct {
+	table           *stopTimeTable
+	trips           []ScheduledTrip
+	tripIndices     []int32
+	tripToIndex     map[*ScheduledTrip]int32
+	stopToIndex     map[*Stop]int32
+	headsignToIndex map[string]int32
+}
+
+func newCompactStopTimesBuilder(stops []Stop, trips []ScheduledTrip) *compactStopTimesBuilder {
+	b := &compactStopTimesBuilder{
+		table: &stopTimeTable{
+			stops:     stops,
+			headsigns: []string{""},
+			flex:      map[int]*flexStopTime{},
+			extra:     map[int]map[string]string{},
+		},
+		trips:           trips,
+		tripToIndex:     map[*ScheduledTrip]int32{},
+		stopToIndex:     map[*Stop]int32{},
+		headsignToIndex: map[string]int32{"": 0},
+	}
+	for i := range trips {
+		b.tripToIndex[&trips[i]] = int32(i)
+	}
+	for i := range stops {
+		b.stopToIndex[&stops[i]] = int32(i)
+	}
+	return b
+}
+
+func (b *compactStopTimesBuilder) add(trip *ScheduledTrip, stopTime ScheduledStopTime) error {
+	t := b.table
+	row := len(t.stopIndices)
+	b.tripIndices = append(b.tripIndices, b.tripToIndex[trip])
+	stopIndex := int32(-1)
+	if stopTime.Stop != nil {
+		stopIndex = b.stopToIndex[stopTime.Stop]
+	}
+	t.stopIndices = append(t.stopIndices, stopIndex)
+	t.arrivalTimes = append(t.arrivalTimes, int32(stopTime.ArrivalTime/time.Second))
+	t.departureTimes = append(t.departureTimes, int32(stopTime.DepartureTime/time.Second))
+	t.stopSequences = append(t.stopSequences, int32(stopTime.StopSequence))
+	headsignIndex, ok := b.headsignToIndex[stopTime.Headsign]
+	if !ok {
+		headsignIndex = int32(len(t.headsigns))
+		t.headsigns = append(t.headsigns, stopTime.Headsign)
+		b.headsignToIndex[stopTime.Headsign] = headsignIndex
+	}
+	t.headsignIndices = append(t.headsignIndices, headsignIndex)
+	t.policies = append(t.policies, uint8(stopTime.PickupType&3)|
+		uint8(stopTime.DropOffType&3)<<2|
+		uint8(stopTime.ContinuousPickup&3)<<4|
+		uint8(stopTime.ContinuousDropOff&3)<<6)
+	t.exactTimes = append(t.exactTimes, stopTime.ExactTimes)
+	if stopTime.ShapeDistanceTraveled != nil && t.shapeDistances == nil {
+		t.shapeDistances = make([]float64, row, cap(t.stopIndices))
+		for i := range t.shapeDistances {
+			t.shapeDistances[i] = math.NaN()
+		}
+	}
+	if t.shapeDistances != nil {
+		d := math.NaN()
+		if stopTime.ShapeDistanceTraveled != nil {
+			d = *stopTime.ShapeDistanceTraveled
+		}
+		t.shapeDistances = append(t.shapeDistances, d)
+	}
+	f := flexStopTime{
+		LocationGroup:            stopTime.LocationGroup,
+		Location:                 stopTime.Location,
+		StartPickupDropOffWindow: stopTime.StartPickupDropOffWindow,
+		EndPickupDropOffWindow:   stopTime.EndPickupDropOffWindow,
+		PickupBookingRule:        stopTime.PickupBookingRule,
+		DropOffBookingRule:       stopTime.DropOffBookingRule,
+	}
+	if f != (flexStopTime{}) {
+		t.flex[row] = &f
+	}
+	if stopTime.Extra != nil {
+		t.extra[row] = stopTime.Extra
+	}
+	return nil
+}
+
+// build sorts the rows of the table by trip and stop sequence and sets the compact stop times of the trips.
+func (b *compactStopTimesBuilder) build() {
+	t := b.table
+	order := make([]int32, len(b.tripIndices))
+	for i := range order {
+		order[i] = int32(i)
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		ti, tj := b.tripIndices[order[i]], b.tripIndices[order[j]]
+		if ti != tj {
+			return ti < tj
+		}
+		return t.stopSequences[order[i]] < t.stopSequences[order[j]]
+	})
+	tripIndices := permute(b.tripIndices, order)
+	b.tripIndices = nil
+	t.stopIndices = permute(t.stopIndices, order)
+	t.arrivalTimes = permute(t.arrivalTimes, order)
+	t.departureTimes = permute(t.departureTimes, order)
+	t.stopSequences = permute(t.stopSequences, order)
+	t.headsignIndices = permute(t.headsignIndices, order)
+	t.policies = permute(t.policies, order)
+	t.exactTimes = permute(t.exactTimes, order)
+	if t.shapeDistances != nil {
+		t.shapeDistances = permute(t.shapeDistances, order)
+	}
+	flex := map[int]*flexStopTime{}
+	extra := map[int]map[string]string{}
+	for row, oldRow := range order {
+		if f, ok := t.flex[int(oldRow)]; ok {
+			flex[row] = f
+		}
+		if e, ok := t.extra[int(oldRow)]; ok {
+			extra[row] = e
+		}
+	}
+	t.flex = flex
+	t.extra = extra
+
+	for start := 0; start < len(tripIndices); {
+		end := start + 1
+		for end < len(tripIndices) && tripIndices[end] == tripIndices[start] {
+			end++
+		}
+		trip := &b.trips[tripIndices[start]]
+		trip.CompactStopTimes = &CompactStopTimes{
+			trip:  trip,
+			table: t,
+			start: start,
+			end:   end,
+		}
+		start = end
+	}
+}
+
+// permute returns the column with its rows in the order given by the row indices.
+func permute[T any](column []T, order []int32) []T {
+	result := make([]T, len(column))
+	for i, j := range order {
+		result[i] = column[j]
+	}
+	return result
+}
@@ -0,0 +1,134 @@
+package gtfs
+
+import (
+	"fmt"
+	"runtime"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func newCompactZipBuilder() *zipBuilder {
+	return newZipBuilderWithDefaults().add(
+		"stops.txt",
+		"stop_id\nstop_1\nstop_2",
+	).add(
+		"trips.txt",
+		"route_id,service_id,trip_id\nroute_id,service_id,trip_1\nroute_id,service_id,trip_2\nroute_id,service_id,trip_3",
+	).add(
+		"stop_times.txt",
+		"trip_id,stop_id,stop_sequence,arrival_time,departure_time,stop_headsign,pickup_type,drop_off_type,continuous_pickup,continuous_drop_off,shape_dist_traveled,timepoint",
+		"trip_1,stop_2,2,08:10:00,08:11:00,Uptown,1,2,3,0,,0",
+		"trip_2,stop_1,1,25:00:00,25:00:00,Downtown,,,,,0.5,",
+		"trip_1,stop_1,1,08:00:00,08:00:00,Uptown,3,0,1,2,1.5,1",
+		"trip_2,stop_2,2,25:10:00,25:10:00,,0,1,2,3,,",
+		"trip_1,stop_1,3,08:20:00,08:20:00,,,,,,,",
+	)
+}
+
+func TestCompactStopTimes(t *testing.T) {
+	for _, tc := range []struct {
+		desc    string
+		content []byte
+	}{
+		{"stop times", newCompactZipBuilder().build()},
+		{"flex stop times", newFlexZipBuilder().build()},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			want, err := ParseStatic(tc.content, ParseStaticOptions{})
+			if err != nil {
+				t.Fatalf("error when parsing: %s", err)
+			}
+			got, err := ParseStatic(tc.content, ParseStaticOptions{CompactStopTimes: true})
+			if err != nil {
+				t.Fatalf("error when parsing with compact stop times: %s", err)
+			}
+			for i := range got.Trips {
+				gotTrip, wantTrip := &got.Trips[i], &want.Trips[i]
+				if gotTrip.StopTimes != nil {
+					t.Errorf("trip %s: stop times are not stored compactly", gotTrip.ID)
+				}
+				if gotTrip.NumStopTimes() != len(wantTrip.StopTimes) {
+					t.Fatalf("trip %s: got %d stop times, want %d", gotTrip.ID, gotTrip.NumStopTimes(), len(wantTrip.StopTimes))
+				}
+				for j := 0; j < gotTrip.NumStopTimes(); j++ {
+					gotStopTime := gotTrip.StopTime(j)
+					if gotStopTime.Trip != gotTrip {
+						t.Errorf("trip %s: stop time %d has trip %v", gotTrip.ID, j, gotStopTime.Trip)
+					}
+					if diff := cmp.Diff(comparableStopTime(wantTrip.StopTime(j)), comparableStopTime(gotStopTime)); diff != "" {
+						t.Errorf("trip %s: stop time %d not the same: %s", gotTrip.ID, j, diff)
+					}
+				}
+			}
+		})
+	}
+}
+
+// comparableStopTime replaces the references of the stop time by IDs, so that stop times from
+// different parses can be compared.
+func comparableStopTime(stopTime ScheduledStopTime) map[string]any {
+	var stopID, locationGroupID, locationID, pickupBookingRuleID, dropOffBookingRuleID string
+	if stopTime.Stop != nil {
+		stopID = stopTime.Stop.Id
+	}
+	if stopTime.LocationGroup != nil {
+		locationGroupID = stopTime.LocationGroup.Id
+	}
+	if stopTime.Location != nil {
+		locationID = stopTime.Location.Id
+	}
+	if stopTime.PickupBookingRule != nil {
+		pickupBookingRuleID = stopTime.PickupBookingRule.Id
+	}
+	if stopTime.DropOffBookingRule != nil {
+		dropOffBookingRuleID = stopTime.DropOffBookingRule.Id
+	}
+	stopTime.Trip = nil
+	stopTime.Stop = nil
+	stopTime.LocationGroup = nil
+	stopTime.Location = nil
+	stopTime.PickupBookingRule = nil
+	stopTime.DropOffBookingRule = nil
+	return map[string]any{
+		"StopTime":             stopTime,
+		"StopID":               stopID,
+		"LocationGroupID":      locationGroupID,
+		"LocationID":           locationID,
+		"PickupBookingRuleID":  pickupBookingRuleID,
+		"DropOffBookingRuleID": dropOffBookingRuleID,
+	}
+}
+
+func BenchmarkCompactStopTimes(b *testing.B) {
+	const numTrips = 2000
+	const stopTimesPerTrip = 50
+	trips := []string{"route_id,service_id,trip_id"}
+	stopTimes := []string{"trip_id,stop_id,stop_sequence,arrival_time,departure_time,stop_headsign"}
+	for i := 0; i < numTrips; i++ {
+		trips = append(trips, fmt.Sprintf("route_id,service_id,trip_%d", i))
+		for j := 0; j < stopTimesPerTrip; j++ {
+			stopTimes = append(stopTimes, fmt.Sprintf("trip_%d,stop_id,%d,08:%02d:00,08:%02d:00,Headsign %d", i, j, j, j, i%10))
+		}
+	}
+	content := newZipBuilderWithDefaults().add("trips.txt", trips...).add("stop_times.txt", stopTimes...).build()
+	for _, compact := range []bool{false, true} {
+		b.Run(fmt.Sprintf("compact=%t", compact), func(b *testing.B) {
+			var retained uint64
+			for n := 0; n < b.N; n++ {
+				var before, after runtime.MemStats
+				runtime.GC()
+				runtime.ReadMemStats(&before)
+				static, err := ParseStatic(content, ParseStaticOptions{CompactStopTimes: compact})
+				if err != nil {
+					b.Fatalf("error when parsing: %s", err)
+				}
+				runtime.GC()
+				runtime.ReadMemStats(&after)
+				retained += after.HeapAlloc - before.HeapAlloc
+				runtime.KeepAlive(static)
+			}
+			b.ReportMetric(float64(retained)/float64(b.N*numTrips*stopTimesPerTrip), "bytes/stoptime")
+		})
+	}
+}
@@ -3,5 +3,35 @@ package constants
 type StaticFile string
 
 const (
-	AgencyFile StaticFile = "agency.txt"
+	AgencyFile             StaticFile = "agency.txt"
+	StopsFile              StaticFile = "stops.txt"
+	RoutesFile             StaticFile = "routes.txt"
+	TripsFile              StaticFile = "trips.txt"
+	StopTimesFile          StaticFile = "stop_times.txt"
+	CalendarFile           StaticFile = "calendar.txt"
+	CalendarDatesFile      StaticFile = "calendar_dates.txt"
+	FareAttributesFile     StaticFile = "fare_attributes.txt"
+	FareRulesFile          StaticFile = "fare_rules.txt"
+	TimeframesFile         StaticFile = "timeframes.txt"
+	FareMediaFile          StaticFile = "fare_media.txt"
+	FareProductsFile       StaticFile = "fare_products.txt"
+	FareLegRulesFile       StaticFile = "fare_leg_rules.txt"
+	FareLegJoinRulesFile   StaticFile = "fare_leg_join_rules.txt"
+	FareTransferRulesFile  StaticFile = "fare_transfer_rules.txt"
+	AreasFile              StaticFile = "areas.txt"
+	StopAreasFile          StaticFile = "stop_areas.txt"
+	NetworksFile           StaticFile = "networks.txt"
+	RouteNetworksFile      StaticFile = "route_networks.txt"
+	ShapesFile             StaticFile = "shapes.txt"
+	FrequenciesFile        StaticFile = "frequencies.txt"
+	TransfersFile          StaticFile = "transfers.txt"
+	PathwaysFile           StaticFile = "pathways.txt"
+	LevelsFile             StaticFile = "levels.txt"
+	LocationGroupsFile     StaticFile = "location_groups.txt"
+	LocationGroupStopsFile StaticFile = "location_group_stops.txt"
+	LocationsFile          StaticFile = "locations.geojson"
+	BookingRulesFile       StaticFile = "booking_rules.txt"
+	TranslationsFile       StaticFile = "translations.txt"
+	FeedInfoFile           StaticFile = "feed_info.txt"
+	AttributionsFile       StaticFile = "attributions.txt"
 )
@@ -7,6 +7,8 @@ import (
 	"encoding/csv"
 	"fmt"
 	"io"
+	"strings"
+	"sync"
 
 	"github.com/jamespfennell/gtfs/constants"
 	"golang.org/x/text/encoding"
@@ -24,6 +26,14 @@ type File struct {
 	currentRow             *row
 	ioErr                  error
 	closer                 func() error
+	// Rows of a chunk of the file, which are read instead of the CSV reader if it is nil.
+	chunkRows [][]string
+	interner  *Interner
+	// Whether the values of extra columns are returned by ExtraColumns.
+	keepExtraColumns bool
+	declaredColumns  map[string]bool
+	// Indices of the columns that are not declared, computed when first needed.
+	extraColumns []int
 }
 
 type row struct {
@@ -65,19 +75,111 @@ func (f *File) HeaderContent() []string {
 	return f.headerContent
 }
 
+// Interner deduplicates strings, so that identical values read from CSV files share memory.
+//
+// The CSV reader allocates one string per row and the values of the row are substrings of it,
+// so interning does not reduce the number of allocations made while reading. It reduces the memory
+// retained by values that are kept after reading, as they no longer retain their entire rows and
+// identical values are stored once.
+//
+// It is safe for concurrent use; calls are serialized with a mutex.
+type Interner struct {
+	mu sync.Mutex
+	m  map[string]string
+}
+
+func NewInterner() *Interner {
+	return &Interner{m: map[string]string{}}
+}
+
+// Intern returns a string equal to s. All calls with equal strings return the same string.
+func (in *Interner) Intern(s string) string {
+	if in == nil || s == "" {
+		return s
+	}
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	if t, ok := in.m[s]; ok {
+		return t
+	}
+	// The value is cloned because the CSV library returns substrings of the entire row, and
+	// retaining the substring would retain the row.
+	s = strings.Clone(s)
+	in.m[s] = s
+	return s
+}
+
+// SetInterner sets the interner used for the values of interned columns. If the interner
+// is nil, which is the default, values are not interned.
+func (f *File) SetInterner(interner *Interner) {
+	f.interner = interner
+}
+
+// SetKeepExtraColumns sets whether the values of extra columns are returned by ExtraColumns.
+func (f *File) SetKeepExtraColumns(keep bool) {
+	f.keepExtraColumns = keep
+}
+
+func (f *File) declareColumn(s string) {
+	if f.declaredColumns == nil {
+		f.declaredColumns = map[string]bool{}
+	}
+	f.declaredColumns[s] = true
+}
+
+// ExtraColumns returns the non-empty values of the current row in the columns that have not been
+// declared as required or optional columns, keyed by column name.
+//
+// It returns nil if there are no such values or if extra columns are not kept.
+// All columns must be declared before it is first called.
+func (f *File) ExtraColumns() map[string]string {
+	if !f.keepExtraColumns || f.currentRow == nil {
+		return nil
+	}
+	if f.extraColumns == nil {
+		f.extraColumns = []int{}
+		for i, column := range f.headerContent {
+			if !f.declaredColumns[column] {
+				f.extraColumns = append(f.extraColumns, i)
+			}
+		}
+	}
+	var m map[string]string
+	for _, i := range f.extraColumns {
+		if i >= len(f.currentRow.cells) || f.currentRow.cells[i] == "" {
+			continue
+		}
+		if m == nil {
+			m = map[string]string{}
+		}
+		m[f.headerContent[i]] = f.interner.Intern(f.currentRow.cells[i])
+	}
+	return m
+}
+
 type RequiredColumn struct {
-	i int
-	s string
-	f *File
+	i        int
+	s        string
+	f        *File
+	interned bool
 }
 
 func (f *File) RequiredColumn(s string) RequiredColumn {
+	f.declareColumn(s)
 	i, b := f.headerMap[s]
 	if !b {
 		f.missingRequiredColumns = append(f.missingRequiredColumns, s)
 		i = -1
 	}
-	return RequiredColumn{i, s, f}
+	return RequiredColumn{i: i, s: s, f: f}
+}
+
+// Interned returns the column with values interned using the interner of the file.
+//
+// This is useful for columns whose values repeat across many rows and are retained after parsing.
+func (c RequiredColumn) Interned() RequiredColumn {
+	c.interned = true
+	return c
 }
 
 func (p *File) MissingRequiredColumns() []string {
@@ -93,15 +195,20 @@ func (c RequiredColumn) Read() string {
 		r.missingKeys = append(r.missingKeys, c.s)
 		return ""
 	}
-	return c.f.currentRow.cells[c.i]
+	if c.interned {
+		return c.f.interner.Intern(r.cells[c.i])
+	}
+	return r.cells[c.i]
 }
 
 type OptionalColumn struct {
-	i int
-	f *File
+	i        int
+	f        *File
+	interned bool
 }
 
 func (f *File) OptionalColumn(s string) OptionalColumn {
+	f.declareColumn(s)
 	i, b := f.headerMap[s]
 	if !b {
 		i = -1
@@ -109,12 +216,28 @@ func (f *File) OptionalColumn(s string) OptionalColumn {
 	return OptionalColumn{i: i, f: f}
 }
 
+// Interned returns the column with values interned using the interner of the file.
+//
+// This is useful for columns whose values repeat across many rows and are retained after parsing.
+func (c OptionalColumn) Interned() OptionalColumn {
+	c.interned = true
+	return c
+}
+
+// Exists returns whether the column appears in the header of the file.
+func (c OptionalColumn) Exists() bool {
+	return c.i >= 0
+}
+
 func (c OptionalColumn) Read() string {
 	if c.i < 0 {
 		return ""
 	}
 	// We copy the string pointer because the CSV library reuses the byte array across rows.
 	s := c.f.currentRow.cells[c.i]
+	if c.interned {
+		return c.f.interner.Intern(s)
+	}
 	return s
 }
 
@@ -122,10 +245,22 @@ func (c OptionalColumn) ReadOr(s string) string {
 	if c.i < 0 {
 		return s
 	}
+	if c.interned {
+		return c.f.interner.Intern(c.f.currentRow.cells[c.i])
+	}
 	return c.f.currentRow.cells[c.i]
 }
 
 func (f *File) NextRow() bool {
+	if f.csvReader == nil {
+		if len(f.chunkRows) == 0 {
+			f.currentRow = nil
+			return false
+		}
+		f.setCurrentRow(f.chunkRows[0])
+		f.chunkRows = f.chunkRows[1:]
+		return true
+	}
 	cells, err := f.csvReader.Read()
 	if err == io.EOF {
 		f.currentRow = nil
@@ -136,13 +271,43 @@ func (f *File) NextRow() bool {
 		f.ioErr = err
 		return false
 	}
+	f.setCurrentRow(cells)
+	return true
+}
+
+func (f *File) setCurrentRow(cells []string) {
 	if f.currentRow == nil {
 		f.currentRow = &row{}
 	}
 	f.rowNumber += 1
 	f.currentRow.cells = cells
 	f.currentRow.missingKeys = nil
-	return true
+}
+
+// NextChunk reads up to n rows and returns a file containing them.
+//
+// The chunk has the same header as the file and its rows are numbered as in the file,
+// so chunks can be parsed independently, for example concurrently.
+// The second return value is false if there are no more rows.
+func (f *File) NextChunk(n int) (*File, bool) {
+	chunk := &File{
+		name:          f.name,
+		headerMap:     f.headerMap,
+		headerContent: f.headerContent,
+		rowNumber:     f.rowNumber,
+		closer:        func() error { return nil },
+		interner:      f.interner,
+
+		keepExtraColumns: f.keepExtraColumns,
+	}
+	for len(chunk.chunkRows) < n && f.NextRow() {
+		// The CSV reader reuses the slice of cells across rows, so the cells are copied.
+		chunk.chunkRows = append(chunk.chunkRows, append([]string(nil), f.currentRow.cells...))
+	}
+	if len(chunk.chunkRows) == 0 {
+		return nil, false
+	}
+	return chunk, true
 }
 
 func (f *File) RowContent() []string {
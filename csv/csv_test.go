@@ -0,0 +1,101 @@
+package csv
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"unsafe"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func newFile(t *testing.T, content string) *File {
+	f, err := New("test.txt", io.NopCloser(strings.NewReader(content)))
+	if err != nil {
+		t.Fatalf("failed to create file: %s", err)
+	}
+	return f
+}
+
+func TestInternedColumn(t *testing.T) {
+	f := newFile(t, "id,headsign\n1,Downtown\n2,Downtown\n3,Uptown\n4,")
+	f.SetInterner(NewInterner())
+	idColumn := f.RequiredColumn("id")
+	headsignColumn := f.OptionalColumn("headsign").Interned()
+	var ids, headsigns []string
+	for f.NextRow() {
+		ids = append(ids, idColumn.Read())
+		headsigns = append(headsigns, headsignColumn.Read())
+	}
+	if diff := cmp.Diff([]string{"1", "2", "3", "4"}, ids); diff != "" {
+		t.Errorf("ids not the same: %s", diff)
+	}
+	if diff := cmp.Diff([]string{"Downtown", "Downtown", "Uptown", ""}, headsigns); diff != "" {
+		t.Errorf("headsigns not the same: %s", diff)
+	}
+	if unsafe.StringData(headsigns[0]) != unsafe.StringData(headsigns[1]) {
+		t.Errorf("identical headsigns do not share memory")
+	}
+}
+
+func TestInternedColumn_NoInterner(t *testing.T) {
+	f := newFile(t, "headsign\nDowntown\nDowntown")
+	headsignColumn := f.RequiredColumn("headsign").Interned()
+	var headsigns []string
+	for f.NextRow() {
+		headsigns = append(headsigns, headsignColumn.Read())
+	}
+	if diff := cmp.Diff([]string{"Downtown", "Downtown"}, headsigns); diff != "" {
+		t.Errorf("headsigns not the same: %s", diff)
+	}
+}
+
+func TestExtraColumns(t *testing.T) {
+	f := newFile(t, "id,name,color,size\n1,a,red,\n2,b,,")
+	f.SetKeepExtraColumns(true)
+	idColumn := f.RequiredColumn("id")
+	nameColumn := f.OptionalColumn("name")
+	var extras []map[string]string
+	for f.NextRow() {
+		idColumn.Read()
+		nameColumn.Read()
+		extras = append(extras, f.ExtraColumns())
+	}
+	if diff := cmp.Diff([]map[string]string{{"color": "red"}, nil}, extras); diff != "" {
+		t.Errorf("extra columns not the same: %s", diff)
+	}
+}
+
+func TestNextChunk(t *testing.T) {
+	f := newFile(t, "id\n1\n2\n3\n4\n5")
+	var chunks [][]string
+	var rowNumbers []int
+	for {
+		chunk, ok := f.NextChunk(2)
+		if !ok {
+			break
+		}
+		idColumn := chunk.RequiredColumn("id")
+		var ids []string
+		for chunk.NextRow() {
+			ids = append(ids, idColumn.Read())
+			rowNumbers = append(rowNumbers, chunk.RowNumber())
+		}
+		chunks = append(chunks, ids)
+	}
+	if diff := cmp.Diff([][]string{{"1", "2"}, {"3", "4"}, {"5"}}, chunks); diff != "" {
+		t.Errorf("chunks not the same: %s", diff)
+	}
+	if diff := cmp.Diff([]int{1, 2, 3, 4, 5}, rowNumbers); diff != "" {
+		t.Errorf("row numbers not the same: %s", diff)
+	}
+}
+
+func BenchmarkInterner(b *testing.B) {
+	values := []string{"Downtown", "Uptown", "Crosstown", "Express"}
+	interner := NewInterner()
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		interner.Intern(values[n%len(values)])
+	}
+}
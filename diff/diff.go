@@ -0,0 +1,370 @@
+// Package diff compares two versions of a GTFS static feed.
+package diff
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jamespfennell/gtfs"
+	"github.com/jamespfennell/gtfs/internal/geo"
+)
+
+// Kind is the kind of a change to an entity.
+type Kind string
+
+const (
+	Added    Kind = "added"
+	Removed  Kind = "removed"
+	Modified Kind = "modified"
+)
+
+// Changes is the set of changes between two versions of a feed.
+//
+// Entities are matched between the versions by their ID. In each list the changes are sorted by ID.
+type Changes struct {
+	Routes   []Change     `json:"routes"`
+	Stops    []StopChange `json:"stops"`
+	Trips    []Change     `json:"trips"`
+	Services []Change     `json:"services"`
+	Shapes   []Change     `json:"shapes"`
+}
+
+// IsEmpty returns whether the two versions of the feed have no differences.
+func (c *Changes) IsEmpty() bool {
+	return len(c.Routes) == 0 && len(c.Stops) == 0 && len(c.Trips) == 0 && len(c.Services) == 0 && len(c.Shapes) == 0
+}
+
+// Change is a change to a single entity.
+type Change struct {
+	Kind Kind   `json:"kind"`
+	ID   string `json:"id"`
+	// Fields that changed, if the entity was modified.
+	Fields []FieldChange `json:"fields,omitempty"`
+}
+
+// FieldChange is a change to a single field of an entity.
+//
+// Values are formatted as strings.
+// For lists like the stop times of a trip or the points of a shape, the values are short summaries.
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// StopChange is a change to a stop.
+type StopChange struct {
+	Change
+	// Distance in meters the stop moved, if it was modified and has coordinates in both versions.
+	Distance *float64 `json:"distance,omitempty"`
+}
+
+// Static returns the changes between the two versions of a feed.
+func Static(before, after *gtfs.Static) *Changes {
+	changes := &Changes{
+		Routes:   compare(before.Routes, after.Routes, func(r *gtfs.Route) string { return r.Id }, routeFields),
+		Stops:    []StopChange{},
+		Trips:    compare(before.Trips, after.Trips, func(t *gtfs.ScheduledTrip) string { return t.ID }, tripFields),
+		Services: compare(before.Services, after.Services, func(s *gtfs.Service) string { return s.Id }, serviceFields),
+		Shapes:   compare(before.Shapes, after.Shapes, func(s *gtfs.Shape) string { return s.ID }, shapeFields),
+	}
+	stopID := func(s *gtfs.Stop) string { return s.Id }
+	beforeStops := byID(before.Stops, stopID)
+	afterStops := byID(after.Stops, stopID)
+	for _, change := range compare(before.Stops, after.Stops, stopID, stopFields) {
+		stopChange := StopChange{Change: change}
+		if change.Kind == Modified {
+			a, b := beforeStops[change.ID], afterStops[change.ID]
+			if a.Latitude != nil && a.Longitude != nil && b.Latitude != nil && b.Longitude != nil &&
+				(*a.Latitude != *b.Latitude || *a.Longitude != *b.Longitude) {
+				d := geo.Distance(*a.Latitude, *a.Longitude, *b.Latitude, *b.Longitude)
+				stopChange.Distance = &d
+			}
+		}
+		changes.Stops = append(changes.Stops, stopChange)
+	}
+	return changes
+}
+
+// field is the value of a field of an entity, formatted as a string.
+type field struct {
+	name  string
+	value string
+	// Short description of the value that is reported instead of the value, if set.
+	summary string
+}
+
+func (f field) String() string {
+	if f.summary != "" {
+		return f.summary
+	}
+	return f.value
+}
+
+// compare returns the changes between two lists of entities, matched by ID.
+//
+// If an ID appears more than once in a list, only the first entity with the ID is compared.
+func compare[T any](before, after []T, id func(*T) string, fields func(*T) []field) []Change {
+	beforeByID := byID(before, id)
+	afterByID := byID(after, id)
+	// The list is never nil so that it is marshalled to an empty JSON list.
+	changes := []Change{}
+	for entityID, a := range beforeByID {
+		b, ok := afterByID[entityID]
+		if !ok {
+			changes = append(changes, Change{Kind: Removed, ID: entityID})
+			continue
+		}
+		var fieldChanges []FieldChange
+		beforeFields, afterFields := fields(a), fields(b)
+		for i := range beforeFields {
+			if beforeFields[i].value == afterFields[i].value {
+				continue
+			}
+			fieldChanges = append(fieldChanges, FieldChange{
+				Field: beforeFields[i].name,
+				Old:   beforeFields[i].String(),
+				New:   afterFields[i].String(),
+			})
+		}
+		if len(fieldChanges) > 0 {
+			changes = append(changes, Change{Kind: Modified, ID: entityID, Fields: fieldChanges})
+		}
+	}
+	for entityID := range afterByID {
+		if _, ok := beforeByID[entityID]; !ok {
+			changes = append(changes, Change{Kind: Added, ID: entityID})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].ID < changes[j].ID
+	})
+	return changes
+}
+
+func byID[T any](entities []T, id func(*T) string) map[string]*T {
+	m := map[string]*T{}
+	for i := range entities {
+		entity := &entities[i]
+		if _, ok := m[id(entity)]; !ok {
+			m[id(entity)] = entity
+		}
+	}
+	return m
+}
+
+func routeFields(route *gtfs.Route) []field {
+	var agencyID, networkID string
+	if route.Agency != nil {
+		agencyID = route.Agency.Id
+	}
+	if route.Network != nil {
+		networkID = route.Network.Id
+	}
+	return []field{
+		{name: "agency_id", value: agencyID},
+		{name: "route_short_name", value: route.ShortName},
+		{name: "route_long_name", value: route.LongName},
+		{name: "route_desc", value: route.Description},
+		{name: "route_type", value: route.Type.String()},
+		{name: "route_url", value: route.Url},
+		{name: "route_color", value: route.Color},
+		{name: "route_text_color", value: route.TextColor},
+		{name: "route_sort_order", value: formatOptionalInt32(route.SortOrder)},
+		{name: "continuous_pickup", value: route.ContinuousPickup.String()},
+		{name: "continuous_drop_off", value: route.ContinuousDropOff.String()},
+		{name: "network_id", value: networkID},
+	}
+}
+
+func stopFields(stop *gtfs.Stop) []field {
+	var parentID, levelID string
+	if stop.Parent != nil {
+		parentID = stop.Parent.Id
+	}
+	if stop.Level != nil {
+		levelID = stop.Level.Id
+	}
+	return []field{
+		{name: "stop_code", value: stop.Code},
+		{name: "stop_name", value: stop.Name},
+		{name: "stop_desc", value: stop.Description},
+		{name: "zone_id", value: stop.ZoneId},
+		{name: "stop_lat", value: formatOptionalFloat64(stop.Latitude)},
+		{name: "stop_lon", value: formatOptionalFloat64(stop.Longitude)},
+		{name: "stop_url", value: stop.Url},
+		{name: "location_type", value: stop.Type.String()},
+		{name: "parent_station", value: parentID},
+		{name: "stop_timezone", value: stop.Timezone},
+		{name: "wheelchair_boarding", value: stop.WheelchairBoarding.String()},
+		{name: "platform_code", value: stop.PlatformCode},
+		{name: "level_id", value: levelID},
+	}
+}
+
+func tripFields(trip *gtfs.ScheduledTrip) []field {
+	var routeID, serviceID, shapeID string
+	if trip.Route != nil {
+		routeID = trip.Route.Id
+	}
+	if trip.Service != nil {
+		serviceID = trip.Service.Id
+	}
+	if trip.Shape != nil {
+		shapeID = trip.Shape.ID
+	}
+	return []field{
+		{name: "route_id", value: routeID},
+		{name: "service_id", value: serviceID},
+		{name: "trip_headsign", value: trip.Headsign},
+		{name: "trip_short_name", value: trip.ShortName},
+		{name: "direction_id", value: trip.DirectionId.String()},
+		{name: "block_id", value: trip.BlockID},
+		{name: "wheelchair_accessible", value: trip.WheelchairAccessible.String()},
+		{name: "bikes_allowed", value: trip.BikesAllowed.String()},
+		{name: "shape_id", value: shapeID},
+		stopTimesField(trip),
+		frequenciesField(trip),
+	}
+}
+
+// stopTimesField returns the stop times of the trip as a single field.
+//
+// The summary contains the number of stop times and the first and last stops, which is
+// usually enough to see how the trip changed.
+func stopTimesField(trip *gtfs.ScheduledTrip) field {
+	n := trip.NumStopTimes()
+	var value []string
+	for i := 0; i < n; i++ {
+		stopTime := trip.StopTime(i)
+		value = append(value, fmt.Sprintf("%d:%s:%s-%s:%s:%s",
+			stopTime.StopSequence,
+			stopIDOf(stopTime.Stop),
+			formatTime(stopTime.ArrivalTime),
+			formatTime(stopTime.DepartureTime),
+			stopTime.PickupType,
+			stopTime.DropOffType,
+		))
+	}
+	summary := "0 stop times"
+	if n > 0 {
+		first, last := trip.StopTime(0), trip.StopTime(n-1)
+		summary = fmt.Sprintf("%d stop times, %s at %s to %s at %s",
+			n,
+			stopIDOf(first.Stop),
+			formatTime(first.DepartureTime),
+			stopIDOf(last.Stop),
+			formatTime(last.ArrivalTime),
+		)
+	}
+	return field{name: "stop_times", value: strings.Join(value, " "), summary: summary}
+}
+
+func frequenciesField(trip *gtfs.ScheduledTrip) field {
+	var value []string
+	for _, frequency := range trip.Frequencies {
+		value = append(value, fmt.Sprintf("%s-%s every %s (%s)",
+			formatTime(frequency.StartTime),
+			formatTime(frequency.EndTime),
+			frequency.Headway,
+			frequency.ExactTimes,
+		))
+	}
+	return field{name: "frequencies", value: strings.Join(value, ", ")}
+}
+
+func serviceFields(service *gtfs.Service) []field {
+	var weekdays []string
+	for _, day := range []struct {
+		name   string
+		active bool
+	}{
+		{"monday", service.Monday},
+		{"tuesday", service.Tuesday},
+		{"wednesday", service.Wednesday},
+		{"thursday", service.Thursday},
+		{"friday", service.Friday},
+		{"saturday", service.Saturday},
+		{"sunday", service.Sunday},
+	} {
+		if day.active {
+			weekdays = append(weekdays, day.name)
+		}
+	}
+	return []field{
+		{name: "weekdays", value: strings.Join(weekdays, ", ")},
+		{name: "start_date", value: formatDate(service.CalendarStartDate)},
+		{name: "end_date", value: formatDate(service.CalendarEndDate)},
+		{name: "added_dates", value: formatDates(service.AddedDates)},
+		{name: "removed_dates", value: formatDates(service.RemovedDates)},
+	}
+}
+
+func shapeFields(shape *gtfs.Shape) []field {
+	var value []string
+	var length float64
+	for i, point := range shape.Points {
+		value = append(value, fmt.Sprintf("%s,%s,%s",
+			strconv.FormatFloat(point.Latitude, 'f', -1, 64),
+			strconv.FormatFloat(point.Longitude, 'f', -1, 64),
+			formatOptionalFloat64(point.Distance),
+		))
+		if i > 0 {
+			previous := shape.Points[i-1]
+			length += geo.Distance(previous.Latitude, previous.Longitude, point.Latitude, point.Longitude)
+		}
+	}
+	return []field{
+		{
+			name:    "points",
+			value:   strings.Join(value, " "),
+			summary: fmt.Sprintf("%d points, %.0f meters", len(shape.Points), length),
+		},
+	}
+}
+
+func stopIDOf(stop *gtfs.Stop) string {
+	if stop == nil {
+		return ""
+	}
+	return stop.Id
+}
+
+func formatOptionalInt32(i *int32) string {
+	if i == nil {
+		return ""
+	}
+	return strconv.FormatInt(int64(*i), 10)
+}
+
+func formatOptionalFloat64(f *float64) string {
+	if f == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*f, 'f', -1, 64)
+}
+
+// formatTime formats a time since the start of the service day in the GTFS HH:MM:SS format.
+func formatTime(d time.Duration) string {
+	s := int64(d / time.Second)
+	return fmt.Sprintf("%02d:%02d:%02d", s/3600, (s/60)%60, s%60)
+}
+
+func formatDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format("2006-01-02")
+}
+
+func formatDates(dates []time.Time) string {
+	var s []string
+	for _, date := range dates {
+		s = append(s, formatDate(date))
+	}
+	sort.Strings(s)
+	return strings.Join(s, ", ")
+}
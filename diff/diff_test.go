@@ -0,0 +1,211 @@
+package diff
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/jamespfennell/gtfs"
+)
+
+// newStatic returns a feed with a single trip that stops at two stops one kilometer apart.
+func newStatic() *gtfs.Static {
+	static := &gtfs.Static{
+		Agencies: []gtfs.Agency{{Id: "agency"}},
+		Stops: []gtfs.Stop{
+			{Id: "stop_1", Name: "Stop 1", Latitude: ptr(40.0), Longitude: ptr(-74.0)},
+			{Id: "stop_2", Name: "Stop 2", Latitude: ptr(40.009), Longitude: ptr(-74.0)},
+		},
+		Services: []gtfs.Service{
+			{
+				Id:        "service",
+				Monday:    true,
+				StartDate: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+				EndDate:   time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		Shapes: []gtfs.Shape{
+			{
+				ID: "shape",
+				Points: []gtfs.ShapePoint{
+					{Latitude: 40.0, Longitude: -74.0},
+					{Latitude: 40.009, Longitude: -74.0},
+				},
+			},
+		},
+	}
+	static.Routes = []gtfs.Route{{Id: "route", Agency: &static.Agencies[0], ShortName: "A", Type: gtfs.RouteType_Bus}}
+	static.Trips = []gtfs.ScheduledTrip{
+		{
+			ID:      "trip",
+			Route:   &static.Routes[0],
+			Service: &static.Services[0],
+			Shape:   &static.Shapes[0],
+		},
+	}
+	trip := &static.Trips[0]
+	trip.StopTimes = []gtfs.ScheduledStopTime{
+		{Trip: trip, Stop: &static.Stops[0], StopSequence: 1, ArrivalTime: 8 * time.Hour, DepartureTime: 8 * time.Hour},
+		{Trip: trip, Stop: &static.Stops[1], StopSequence: 2, ArrivalTime: 8*time.Hour + 2*time.Minute, DepartureTime: 8*time.Hour + 2*time.Minute},
+	}
+	return static
+}
+
+func TestStatic(t *testing.T) {
+	for _, tc := range []struct {
+		desc   string
+		modify func(static *gtfs.Static)
+		want   *Changes
+	}{
+		{
+			desc:   "no changes",
+			modify: func(static *gtfs.Static) {},
+			want:   &Changes{},
+		},
+		{
+			desc: "route added, removed and modified",
+			modify: func(static *gtfs.Static) {
+				static.Routes[0].ShortName = "B"
+				static.Routes[0].Type = gtfs.RouteType_Subway
+				static.Routes = append(static.Routes, gtfs.Route{Id: "route_2"})
+			},
+			want: &Changes{
+				Routes: []Change{
+					{
+						Kind: Modified,
+						ID:   "route",
+						Fields: []FieldChange{
+							{Field: "route_short_name", Old: "A", New: "B"},
+							{Field: "route_type", Old: "BUS", New: "SUBWAY"},
+						},
+					},
+					{Kind: Added, ID: "route_2"},
+				},
+			},
+		},
+		{
+			desc: "stop moved",
+			modify: func(static *gtfs.Static) {
+				static.Stops[1].Latitude = ptr(40.018)
+			},
+			want: &Changes{
+				Stops: []StopChange{
+					{
+						Change: Change{
+							Kind:   Modified,
+							ID:     "stop_2",
+							Fields: []FieldChange{{Field: "stop_lat", Old: "40.009", New: "40.018"}},
+						},
+						Distance: ptr(1000.0),
+					},
+				},
+			},
+		},
+		{
+			desc: "stop renamed",
+			modify: func(static *gtfs.Static) {
+				static.Stops[0].Name = "New stop 1"
+			},
+			want: &Changes{
+				Stops: []StopChange{
+					{
+						Change: Change{
+							Kind:   Modified,
+							ID:     "stop_1",
+							Fields: []FieldChange{{Field: "stop_name", Old: "Stop 1", New: "New stop 1"}},
+						},
+					},
+				},
+			},
+		},
+		{
+			desc: "trip stop times and frequencies",
+			modify: func(static *gtfs.Static) {
+				static.Trips[0].StopTimes[1].ArrivalTime = 8*time.Hour + 3*time.Minute
+				static.Trips[0].Frequencies = []gtfs.Frequency{
+					{StartTime: 6 * time.Hour, EndTime: 10 * time.Hour, Headway: 10 * time.Minute},
+				}
+			},
+			want: &Changes{
+				Trips: []Change{
+					{
+						Kind: Modified,
+						ID:   "trip",
+						Fields: []FieldChange{
+							{
+								Field: "stop_times",
+								Old:   "2 stop times, stop_1 at 08:00:00 to stop_2 at 08:02:00",
+								New:   "2 stop times, stop_1 at 08:00:00 to stop_2 at 08:03:00",
+							},
+							{
+								Field: "frequencies",
+								Old:   "",
+								New:   "06:00:00-10:00:00 every 10m0s (FREQUENCY_BASED)",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			desc: "trip removed",
+			modify: func(static *gtfs.Static) {
+				static.Trips = nil
+			},
+			want: &Changes{
+				Trips: []Change{{Kind: Removed, ID: "trip"}},
+			},
+		},
+		{
+			desc: "service calendar",
+			modify: func(static *gtfs.Static) {
+				static.Services[0].Tuesday = true
+				static.Services[0].RemovedDates = []time.Time{time.Date(2024, 12, 30, 0, 0, 0, 0, time.UTC)}
+			},
+			want: &Changes{
+				Services: []Change{
+					{
+						Kind: Modified,
+						ID:   "service",
+						Fields: []FieldChange{
+							{Field: "weekdays", Old: "monday", New: "monday, tuesday"},
+							{Field: "removed_dates", Old: "", New: "2024-12-30"},
+						},
+					},
+				},
+			},
+		},
+		{
+			desc: "shape points",
+			modify: func(static *gtfs.Static) {
+				static.Shapes[0].Points = append(static.Shapes[0].Points, gtfs.ShapePoint{Latitude: 40.018, Longitude: -74.0})
+			},
+			want: &Changes{
+				Shapes: []Change{
+					{
+						Kind: Modified,
+						ID:   "shape",
+						Fields: []FieldChange{
+							{Field: "points", Old: "2 points, 1001 meters", New: "3 points, 2002 meters"},
+						},
+					},
+				},
+			},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			after := newStatic()
+			tc.modify(after)
+			got := Static(newStatic(), after)
+			// Distances are compared approximately.
+			if diff := cmp.Diff(tc.want, got, cmpopts.EquateApprox(0.01, 0), cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("changes not the same: %s", diff)
+			}
+		})
+	}
+}
+
+func ptr[T any](t T) *T {
+	return &t
+}
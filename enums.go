@@ -37,6 +37,46 @@ func parseBikesAllowed(s string) BikesAllowed {
 	}
 }
 
+// BookingType describes how far in advance a flexible service must be booked.
+//
+// This is a Go representation of the enum described in the `booking_type` field of `booking_rules.txt`.
+type BookingType int32
+
+const (
+	// Real-time booking.
+	BookingType_RealTime BookingType = 0
+	// Up to same-day booking with advance notice.
+	BookingType_SameDay BookingType = 1
+	// Up to prior day(s) booking.
+	BookingType_PriorDays BookingType = 2
+)
+
+func parseBookingType(s string) (BookingType, bool) {
+	switch s {
+	case "0":
+		return BookingType_RealTime, true
+	case "1":
+		return BookingType_SameDay, true
+	case "2":
+		return BookingType_PriorDays, true
+	default:
+		return BookingType_RealTime, false
+	}
+}
+
+func (t BookingType) String() string {
+	switch t {
+	case BookingType_RealTime:
+		return "REAL_TIME"
+	case BookingType_SameDay:
+		return "SAME_DAY"
+	case BookingType_PriorDays:
+		return "PRIOR_DAYS"
+	default:
+		return "UNKNOWN"
+	}
+}
+
 // DirectionID is a mechanism for distinguishing between trips going in the opposite direction.
 type DirectionID uint8
 
@@ -57,6 +97,18 @@ func parseDirectionID_GTFSStatic(s string) DirectionID {
 	}
 }
 
+// formatDirectionID_GTFSStatic is the inverse of parseDirectionID_GTFSStatic.
+func formatDirectionID_GTFSStatic(d DirectionID) string {
+	switch d {
+	case DirectionID_False:
+		return "0"
+	case DirectionID_True:
+		return "1"
+	default:
+		return ""
+	}
+}
+
 func parseDirectionID_GTFSRealtime(raw *uint32) DirectionID {
 	if raw == nil {
 		return DirectionID_Unspecified
@@ -78,6 +130,46 @@ func (d DirectionID) String() string {
 	}
 }
 
+// DurationLimitType describes which events a fare transfer duration limit is measured between.
+//
+// This is a Go representation of the enum described in the `duration_limit_type` field of `fare_transfer_rules.txt`.
+type DurationLimitType int32
+
+const (
+	DurationLimitType_DepartureToArrival   DurationLimitType = 0
+	DurationLimitType_DepartureToDeparture DurationLimitType = 1
+	DurationLimitType_ArrivalToDeparture   DurationLimitType = 2
+	DurationLimitType_ArrivalToArrival     DurationLimitType = 3
+)
+
+func parseDurationLimitType(s string) DurationLimitType {
+	switch s {
+	case "1":
+		return DurationLimitType_DepartureToDeparture
+	case "2":
+		return DurationLimitType_ArrivalToDeparture
+	case "3":
+		return DurationLimitType_ArrivalToArrival
+	default:
+		return DurationLimitType_DepartureToArrival
+	}
+}
+
+func (t DurationLimitType) String() string {
+	switch t {
+	case DurationLimitType_DepartureToArrival:
+		return "DEPARTURE_TO_ARRIVAL"
+	case DurationLimitType_DepartureToDeparture:
+		return "DEPARTURE_TO_DEPARTURE"
+	case DurationLimitType_ArrivalToDeparture:
+		return "ARRIVAL_TO_DEPARTURE"
+	case DurationLimitType_ArrivalToArrival:
+		return "ARRIVAL_TO_ARRIVAL"
+	default:
+		return "UNKNOWN"
+	}
+}
+
 // ExactTimes describes the type of service for a trip.
 //
 // This is a Go representation of the enum described in the `exact_times` field of `frequencies.txt`.
@@ -110,6 +202,178 @@ func (t ExactTimes) String() string {
 	}
 }
 
+// FareMediaType describes the type of a fare media.
+//
+// This is a Go representation of the enum described in the `fare_media_type` field of `fare_media.txt`.
+type FareMediaType int32
+
+const (
+	FareMediaType_None           FareMediaType = 0
+	FareMediaType_PaperTicket    FareMediaType = 1
+	FareMediaType_TransitCard    FareMediaType = 2
+	FareMediaType_ContactlessEMV FareMediaType = 3
+	FareMediaType_MobileApp      FareMediaType = 4
+)
+
+func parseFareMediaType(s string) FareMediaType {
+	switch s {
+	case "1":
+		return FareMediaType_PaperTicket
+	case "2":
+		return FareMediaType_TransitCard
+	case "3":
+		return FareMediaType_ContactlessEMV
+	case "4":
+		return FareMediaType_MobileApp
+	default:
+		return FareMediaType_None
+	}
+}
+
+func (t FareMediaType) String() string {
+	switch t {
+	case FareMediaType_None:
+		return "NONE"
+	case FareMediaType_PaperTicket:
+		return "PAPER_TICKET"
+	case FareMediaType_TransitCard:
+		return "TRANSIT_CARD"
+	case FareMediaType_ContactlessEMV:
+		return "CONTACTLESS_EMV"
+	case FareMediaType_MobileApp:
+		return "MOBILE_APP"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// FareTransferType describes how the cost of a transfer between two fare legs is computed.
+//
+// This is a Go representation of the enum described in the `fare_transfer_type` field of `fare_transfer_rules.txt`.
+type FareTransferType int32
+
+const (
+	// The cost is the cost of the first leg plus the cost of the transfer.
+	FareTransferType_FromLegPlusTransfer FareTransferType = 0
+	// The cost is the cost of both legs plus the cost of the transfer.
+	FareTransferType_FromLegPlusTransferPlusToLeg FareTransferType = 1
+	// The cost is the cost of the transfer only.
+	FareTransferType_Transfer FareTransferType = 2
+)
+
+func parseFareTransferType(s string) FareTransferType {
+	switch s {
+	case "1":
+		return FareTransferType_FromLegPlusTransferPlusToLeg
+	case "2":
+		return FareTransferType_Transfer
+	default:
+		return FareTransferType_FromLegPlusTransfer
+	}
+}
+
+func (t FareTransferType) String() string {
+	switch t {
+	case FareTransferType_FromLegPlusTransfer:
+		return "FROM_LEG_PLUS_TRANSFER"
+	case FareTransferType_FromLegPlusTransferPlusToLeg:
+		return "FROM_LEG_PLUS_TRANSFER_PLUS_TO_LEG"
+	case FareTransferType_Transfer:
+		return "TRANSFER"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// PathwayMode describes the type of a pathway between two locations in a station.
+//
+// This is a Go representation of the enum described in the `pathway_mode` field of `pathways.txt`.
+type PathwayMode int32
+
+const (
+	PathwayMode_Walkway        PathwayMode = 1
+	PathwayMode_Stairs         PathwayMode = 2
+	PathwayMode_MovingSidewalk PathwayMode = 3
+	PathwayMode_Escalator      PathwayMode = 4
+	PathwayMode_Elevator       PathwayMode = 5
+	PathwayMode_FareGate       PathwayMode = 6
+	PathwayMode_ExitGate       PathwayMode = 7
+)
+
+// parsePathwayMode parses a pathway mode. The field is required and has no default,
+// so the second return value is false if the value is not valid.
+func parsePathwayMode(s string) (PathwayMode, bool) {
+	switch s {
+	case "1":
+		return PathwayMode_Walkway, true
+	case "2":
+		return PathwayMode_Stairs, true
+	case "3":
+		return PathwayMode_MovingSidewalk, true
+	case "4":
+		return PathwayMode_Escalator, true
+	case "5":
+		return PathwayMode_Elevator, true
+	case "6":
+		return PathwayMode_FareGate, true
+	case "7":
+		return PathwayMode_ExitGate, true
+	default:
+		return 0, false
+	}
+}
+
+func (m PathwayMode) String() string {
+	switch m {
+	case PathwayMode_Walkway:
+		return "WALKWAY"
+	case PathwayMode_Stairs:
+		return "STAIRS"
+	case PathwayMode_MovingSidewalk:
+		return "MOVING_SIDEWALK"
+	case PathwayMode_Escalator:
+		return "ESCALATOR"
+	case PathwayMode_Elevator:
+		return "ELEVATOR"
+	case PathwayMode_FareGate:
+		return "FARE_GATE"
+	case PathwayMode_ExitGate:
+		return "EXIT_GATE"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// PaymentMethod describes when a fare must be paid.
+//
+// This is a Go representation of the enum described in the `payment_method` field of `fare_attributes.txt`.
+type PaymentMethod int32
+
+const (
+	PaymentMethod_OnBoard        PaymentMethod = 0
+	PaymentMethod_BeforeBoarding PaymentMethod = 1
+)
+
+func parsePaymentMethod(s string) PaymentMethod {
+	switch s {
+	case "1":
+		return PaymentMethod_BeforeBoarding
+	default:
+		return PaymentMethod_OnBoard
+	}
+}
+
+func (m PaymentMethod) String() string {
+	switch m {
+	case PaymentMethod_OnBoard:
+		return "ON_BOARD"
+	case PaymentMethod_BeforeBoarding:
+		return "BEFORE_BOARDING"
+	default:
+		return "UNKNOWN"
+	}
+}
+
 // PickupDropOffPolicy describes the pickup or drop-off policy for a route or scheduled trip.
 //
 // This is a Go representation of the enum described in the `continuous_pickup` field of `routes.txt`,
@@ -127,8 +391,14 @@ const (
 	PickupDropOffPolicy_CoordinateWithDriver PickupDropOffPolicy = 3
 )
 
-func parsePickupDropOffPolicy(s string) PickupDropOffPolicy {
+// parsePickupDropOffPolicy parses a pickup or drop off policy, returning missing if the value is empty.
+//
+// The spec gives different defaults for empty values: regular pickup and drop off for the pickup_type
+// and drop_off_type columns, and no continuous pickup or drop off for the continuous_* columns.
+func parsePickupDropOffPolicy(s string, missing PickupDropOffPolicy) PickupDropOffPolicy {
 	switch s {
+	case "":
+		return missing
 	case "0":
 		return PickupDropOffPolicy_Yes
 	case "2":
@@ -889,6 +1159,17 @@ func parseStopType(s string, hasParentStop bool) StopType {
 	}
 }
 
+// formatStopType is the inverse of parseStopType. Stops and platforms have no location type,
+// and are distinguished by whether they have a parent station.
+func formatStopType(t StopType) string {
+	switch t {
+	case StopType_Stop, StopType_Platform:
+		return ""
+	default:
+		return strconv.FormatInt(int64(t), 10)
+	}
+}
+
 func (t StopType) String() string {
 	switch t {
 	case StopType_Stop:
@@ -918,6 +1199,10 @@ const (
 	TransferType_Timed        TransferType = 1
 	TransferType_RequiresTime TransferType = 2
 	TransferType_NotPossible  TransferType = 3
+	// The rider can stay on board the vehicle from one trip to the next.
+	TransferType_InSeat TransferType = 4
+	// The rider must alight and re-board the vehicle between the two trips.
+	TransferType_InSeatNotAllowed TransferType = 5
 )
 
 func parseTransferType(s string) TransferType {
@@ -928,11 +1213,21 @@ func parseTransferType(s string) TransferType {
 		return TransferType_RequiresTime
 	case "3":
 		return TransferType_NotPossible
+	case "4":
+		return TransferType_InSeat
+	case "5":
+		return TransferType_InSeatNotAllowed
 	default:
 		return TransferType_Recommended
 	}
 }
 
+// IsInSeat returns whether the transfer type describes a transfer between two trips
+// operated by the same vehicle.
+func (t TransferType) IsInSeat() bool {
+	return t == TransferType_InSeat || t == TransferType_InSeatNotAllowed
+}
+
 func (t TransferType) String() string {
 	switch t {
 	case TransferType_Recommended:
@@ -943,6 +1238,10 @@ func (t TransferType) String() string {
 		return "REQUIRES_TIME"
 	case TransferType_NotPossible:
 		return "NOT_POSSIBLE"
+	case TransferType_InSeat:
+		return "IN_SEAT"
+	case TransferType_InSeatNotAllowed:
+		return "IN_SEAT_NOT_ALLOWED"
 	default:
 		return "UNKNOWN"
 	}
@@ -0,0 +1,368 @@
+package gtfs
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/jamespfennell/gtfs/csv"
+	"github.com/jamespfennell/gtfs/warnings"
+)
+
+// FareAttribute corresponds to a single row in the fare_attributes.txt file.
+type FareAttribute struct {
+	Id            string
+	Price         float64
+	CurrencyType  string
+	PaymentMethod PaymentMethod
+	// Number of transfers permitted on this fare, or nil if unlimited transfers are permitted.
+	Transfers *int32
+	Agency    *Agency
+	// Length of time before a transfer expires, or nil if transfers do not expire.
+	TransferDuration *time.Duration
+	Extra            map[string]string
+}
+
+// FareRule corresponds to a single row in the fare_rules.txt file.
+//
+// The zone IDs refer to the ZoneId field of stops in the feed.
+type FareRule struct {
+	Fare              *FareAttribute
+	Route             *Route
+	OriginZoneId      string
+	DestinationZoneId string
+	ContainsZoneId    string
+	Extra             map[string]string
+}
+
+func parseFareAttributes(csv *csv.File, agencies []Agency) ([]FareAttribute, []warnings.StaticWarning) {
+	var w []warnings.StaticWarning
+	idColumn := csv.RequiredColumn("fare_id")
+	priceColumn := csv.RequiredColumn("price")
+	currencyTypeColumn := csv.RequiredColumn("currency_type")
+	paymentMethodColumn := csv.RequiredColumn("payment_method")
+	// The transfers column is required, but an empty value means unlimited transfers.
+	transfersColumn := csv.OptionalColumn("transfers")
+	agencyIDColumn := csv.OptionalColumn("agency_id")
+	transferDurationColumn := csv.OptionalColumn("transfer_duration")
+
+	if warnings := checkForMissingColumns(csv); len(warnings) > 0 {
+		return nil, warnings
+	}
+
+	var fares []FareAttribute
+	for csv.NextRow() {
+		fare := FareAttribute{
+			Id:            idColumn.Read(),
+			CurrencyType:  currencyTypeColumn.Read(),
+			PaymentMethod: parsePaymentMethod(paymentMethodColumn.Read()),
+			Transfers:     parseInt32(transfersColumn.Read()),
+			Extra:         csv.ExtraColumns(),
+		}
+		rawPrice := priceColumn.Read()
+		if missingKeys := csv.MissingRowKeys(); len(missingKeys) > 0 {
+			w = append(w, warnings.NewStaticWarning(csv, warnings.FareAttributeMissingValues{
+				FareID:  fare.Id,
+				Columns: missingKeys,
+			}))
+			continue
+		}
+		price, err := strconv.ParseFloat(rawPrice, 64)
+		if err != nil {
+			w = append(w, warnings.NewStaticWarning(csv, warnings.InvalidValue{
+				Column: "price",
+				Value:  rawPrice,
+			}))
+			continue
+		}
+		fare.Price = price
+		agency, ok := lookupAgency(agencies, agencyIDColumn.Read())
+		if !ok {
+			w = append(w, warnings.NewStaticWarning(csv, warnings.InvalidAgencyReference{
+				AgencyID: agencyIDColumn.Read(),
+			}))
+			continue
+		}
+		fare.Agency = agency
+		if transferDuration := parseInt32(transferDurationColumn.Read()); transferDuration != nil {
+			d := time.Duration(*transferDuration) * time.Second
+			fare.TransferDuration = &d
+		}
+		fares = append(fares, fare)
+	}
+	return fares, w
+}
+
+// lookupAgency returns the agency with the provided ID.
+//
+// If the ID is empty and the feed contains a single agency, that agency is returned.
+func lookupAgency(agencies []Agency, agencyID string) (*Agency, bool) {
+	if agencyID == "" {
+		if len(agencies) == 1 {
+			return &agencies[0], true
+		}
+		return nil, false
+	}
+	for i := range agencies {
+		if agencies[i].Id == agencyID {
+			return &agencies[i], true
+		}
+	}
+	return nil, false
+}
+
+func parseFareRules(csv *csv.File, fares []FareAttribute, routes []Route, stops []Stop) ([]FareRule, []warnings.StaticWarning) {
+	var w []warnings.StaticWarning
+	fareIDColumn := csv.RequiredColumn("fare_id")
+	routeIDColumn := csv.OptionalColumn("route_id")
+	originIDColumn := csv.OptionalColumn("origin_id")
+	destinationIDColumn := csv.OptionalColumn("destination_id")
+	containsIDColumn := csv.OptionalColumn("contains_id")
+
+	if warnings := checkForMissingColumns(csv); len(warnings) > 0 {
+		return nil, warnings
+	}
+
+	idToFare := map[string]*FareAttribute{}
+	for i := range fares {
+		idToFare[fares[i].Id] = &fares[i]
+	}
+	idToRoute := map[string]*Route{}
+	for i := range routes {
+		idToRoute[routes[i].Id] = &routes[i]
+	}
+	zoneIDs := map[string]bool{}
+	for i := range stops {
+		zoneIDs[stops[i].ZoneId] = true
+	}
+	var rules []FareRule
+	for csv.NextRow() {
+		fareID := fareIDColumn.Read()
+		if missingKeys := csv.MissingRowKeys(); len(missingKeys) > 0 {
+			w = append(w, warnings.NewStaticWarning(csv, warnings.MissingValues{
+				Columns: missingKeys,
+			}))
+			continue
+		}
+		fare, ok := idToFare[fareID]
+		if !ok {
+			w = append(w, warnings.NewStaticWarning(csv, warnings.InvalidFareReference{FareID: fareID}))
+			continue
+		}
+		rule := FareRule{
+			Fare:              fare,
+			OriginZoneId:      originIDColumn.Read(),
+			DestinationZoneId: destinationIDColumn.Read(),
+			ContainsZoneId:    containsIDColumn.Read(),
+			Extra:             csv.ExtraColumns(),
+		}
+		if routeID := routeIDColumn.Read(); routeID != "" {
+			rule.Route, ok = idToRoute[routeID]
+			if !ok {
+				// Keeping the rule without the route would make it apply to all routes.
+				w = append(w, warnings.NewStaticWarning(csv, warnings.InvalidRouteReference{RouteID: routeID}))
+				continue
+			}
+		}
+		for _, zoneID := range []string{rule.OriginZoneId, rule.DestinationZoneId, rule.ContainsZoneId} {
+			if zoneID != "" && !zoneIDs[zoneID] {
+				w = append(w, warnings.NewStaticWarning(csv, warnings.InvalidZoneReference{ZoneID: zoneID}))
+			}
+		}
+		rules = append(rules, rule)
+	}
+	return rules, w
+}
+
+// FareLeg is a single ride on a scheduled trip, from the stop time at which the rider boards
+// to the stop time at which the rider alights.
+//
+// Both stop times must belong to the same trip.
+type FareLeg struct {
+	Board  *ScheduledStopTime
+	Alight *ScheduledStopTime
+	// Service date of the trip, as midnight in the time zone of the feed. It is used to check the
+	// services of GTFS fares v2 timeframes; if zero, only the times of day of timeframes are checked.
+	Date time.Time
+}
+
+func (leg *FareLeg) route() *Route {
+	if leg.Board.Trip == nil {
+		return nil
+	}
+	return leg.Board.Trip.Route
+}
+
+// stopTimes returns the stop times of the leg's trip between boarding and alighting, inclusive.
+func (leg *FareLeg) stopTimes() []ScheduledStopTime {
+	if leg.Board.Trip == nil {
+		return []ScheduledStopTime{*leg.Board, *leg.Alight}
+	}
+	var stopTimes []ScheduledStopTime
+	trip := leg.Board.Trip
+	for i := 0; i < trip.NumStopTimes(); i++ {
+		stopTime := trip.StopTime(i)
+		if leg.Board.StopSequence <= stopTime.StopSequence && stopTime.StopSequence <= leg.Alight.StopSequence {
+			stopTimes = append(stopTimes, stopTime)
+		}
+	}
+	return stopTimes
+}
+
+// FareV1 is the cheapest combination of GTFS fares v1 fares that covers a sequence of legs.
+type FareV1 struct {
+	Payments []FareV1Payment
+}
+
+// FareV1Payment is a single fare paid as part of a FareV1.
+type FareV1Payment struct {
+	Fare *FareAttribute
+	// Legs covered by this fare.
+	Legs []FareLeg
+}
+
+// Price returns the sum of the prices of the fares paid.
+func (f *FareV1) Price() float64 {
+	var price float64
+	for _, payment := range f.Payments {
+		price += payment.Fare.Price
+	}
+	return price
+}
+
+// CalculateFareV1 computes the cheapest fare for the provided legs using the fares in
+// the fare_attributes.txt and fare_rules.txt files.
+//
+// The legs are split into consecutive groups each of which is covered by a single fare,
+// taking into account the number of transfers and the transfer duration of each fare.
+// If some leg is not covered by any fare, the second return value is false.
+func (static *Static) CalculateFareV1(legs []FareLeg) (FareV1, bool) {
+	fareToRules := map[*FareAttribute][]*FareRule{}
+	for i := range static.FareRules {
+		rule := &static.FareRules[i]
+		fareToRules[rule.Fare] = append(fareToRules[rule.Fare], rule)
+	}
+	type solution struct {
+		price    float64
+		payments []FareV1Payment
+	}
+	// best[i] is the cheapest solution for the first i legs.
+	best := make([]*solution, len(legs)+1)
+	best[0] = &solution{}
+	for j := 1; j <= len(legs); j++ {
+		for i := 0; i < j; i++ {
+			if best[i] == nil {
+				continue
+			}
+			var cheapest *FareAttribute
+			for k := range static.FareAttributes {
+				fare := &static.FareAttributes[k]
+				if cheapest != nil && cheapest.Price <= fare.Price {
+					continue
+				}
+				if fareV1Matches(fare, fareToRules[fare], legs[i:j]) {
+					cheapest = fare
+				}
+			}
+			if cheapest == nil {
+				continue
+			}
+			price := best[i].price + cheapest.Price
+			if best[j] != nil && best[j].price <= price {
+				continue
+			}
+			payments := make([]FareV1Payment, len(best[i].payments), len(best[i].payments)+1)
+			copy(payments, best[i].payments)
+			best[j] = &solution{
+				price: price,
+				payments: append(payments, FareV1Payment{
+					Fare: cheapest,
+					Legs: legs[i:j],
+				}),
+			}
+		}
+	}
+	if best[len(legs)] == nil {
+		return FareV1{}, false
+	}
+	return FareV1{Payments: best[len(legs)].payments}, true
+}
+
+func fareV1Matches(fare *FareAttribute, rules []*FareRule, legs []FareLeg) bool {
+	if fare.Transfers != nil && len(legs)-1 > int(*fare.Transfers) {
+		return false
+	}
+	if fare.TransferDuration != nil &&
+		legs[len(legs)-1].Board.DepartureTime-legs[0].Board.DepartureTime > *fare.TransferDuration {
+		return false
+	}
+	routes := map[*Route]bool{}
+	for i := range legs {
+		route := legs[i].route()
+		if fare.Agency != nil && (route == nil || route.Agency != fare.Agency) {
+			return false
+		}
+		routes[route] = true
+	}
+	if len(rules) == 0 {
+		return true
+	}
+	var zones []string
+	zoneSet := map[string]bool{}
+	for i := range legs {
+		for _, stopTime := range legs[i].stopTimes() {
+			if stopTime.Stop == nil || stopTime.Stop.ZoneId == "" || zoneSet[stopTime.Stop.ZoneId] {
+				continue
+			}
+			zones = append(zones, stopTime.Stop.ZoneId)
+			zoneSet[stopTime.Stop.ZoneId] = true
+		}
+	}
+	var originZoneID, destinationZoneID string
+	if stop := legs[0].Board.Stop; stop != nil {
+		originZoneID = stop.ZoneId
+	}
+	if stop := legs[len(legs)-1].Alight.Stop; stop != nil {
+		destinationZoneID = stop.ZoneId
+	}
+
+	// Each leg must be covered by some rule, and if the rules list zones that must be passed
+	// through then the zones passed through must be exactly these zones.
+	for i := range legs {
+		route := legs[i].route()
+		matched := false
+		for _, rule := range rules {
+			if rule.Route != nil && rule.Route != route {
+				continue
+			}
+			if rule.OriginZoneId != "" && rule.OriginZoneId != originZoneID {
+				continue
+			}
+			if rule.DestinationZoneId != "" && rule.DestinationZoneId != destinationZoneID {
+				continue
+			}
+			if rule.ContainsZoneId != "" && !zoneSet[rule.ContainsZoneId] {
+				continue
+			}
+			matched = true
+			break
+		}
+		if !matched {
+			return false
+		}
+	}
+	containsZoneIDs := map[string]bool{}
+	for _, rule := range rules {
+		if rule.ContainsZoneId != "" && (rule.Route == nil || routes[rule.Route]) {
+			containsZoneIDs[rule.ContainsZoneId] = true
+		}
+	}
+	if len(containsZoneIDs) == 0 {
+		return true
+	}
+	for _, zone := range zones {
+		if !containsZoneIDs[zone] {
+			return false
+		}
+	}
+	return len(zones) == len(containsZoneIDs)
+}
@@ -0,0 +1,318 @@
+package gtfs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jamespfennell/gtfs/warnings"
+)
+
+func TestParseFaresV1(t *testing.T) {
+	agency := Agency{Id: "a", Name: "b", Url: "c", Timezone: "d"}
+	route := Route{
+		Id:                "route_id",
+		Agency:            &agency,
+		Color:             "FFFFFF",
+		TextColor:         "000000",
+		Type:              RouteType_Bus,
+		ContinuousPickup:  PickupDropOffPolicy_No,
+		ContinuousDropOff: PickupDropOffPolicy_No,
+	}
+	fare := FareAttribute{
+		Id:               "fare_1",
+		Price:            2.75,
+		CurrencyType:     "USD",
+		PaymentMethod:    PaymentMethod_BeforeBoarding,
+		Transfers:        ptr(int32(1)),
+		Agency:           &agency,
+		TransferDuration: ptr(2 * time.Hour),
+	}
+	for _, tc := range []struct {
+		desc         string
+		content      []byte
+		wantFares    []FareAttribute
+		wantRules    []FareRule
+		wantWarnings []warnings.StaticWarningKind
+	}{
+		{
+			desc: "fare attributes and rules",
+			content: newZipBuilderWithDefaults().add(
+				"stops.txt",
+				"stop_id,zone_id",
+				"stop_id,zone_1",
+			).add(
+				"fare_attributes.txt",
+				"fare_id,price,currency_type,payment_method,transfers,transfer_duration",
+				"fare_1,2.75,USD,1,1,7200",
+				"fare_2,1.50,USD,0,,",
+			).add(
+				"fare_rules.txt",
+				"fare_id,route_id,origin_id,destination_id,contains_id",
+				"fare_1,route_id,,,",
+				"fare_2,,zone_1,zone_1,zone_1",
+			).build(),
+			wantFares: []FareAttribute{
+				fare,
+				{
+					Id:            "fare_2",
+					Price:         1.5,
+					CurrencyType:  "USD",
+					PaymentMethod: PaymentMethod_OnBoard,
+					Agency:        &agency,
+				},
+			},
+			wantRules: []FareRule{
+				{
+					Fare:  &fare,
+					Route: &route,
+				},
+				{
+					Fare: &FareAttribute{
+						Id:            "fare_2",
+						Price:         1.5,
+						CurrencyType:  "USD",
+						PaymentMethod: PaymentMethod_OnBoard,
+						Agency:        &agency,
+					},
+					OriginZoneId:      "zone_1",
+					DestinationZoneId: "zone_1",
+					ContainsZoneId:    "zone_1",
+				},
+			},
+		},
+		{
+			desc: "invalid fare attributes",
+			content: newZipBuilderWithDefaults().add(
+				"fare_attributes.txt",
+				"fare_id,price,currency_type,payment_method,transfers,agency_id",
+				"fare_1,,USD,1,1,",
+				"fare_2,abc,USD,1,1,",
+				"fare_3,2.75,USD,1,1,b",
+			).build(),
+			wantWarnings: []warnings.StaticWarningKind{
+				warnings.FareAttributeMissingValues{FareID: "fare_1", Columns: []string{"price"}},
+				warnings.InvalidValue{Column: "price", Value: "abc"},
+				warnings.InvalidAgencyReference{AgencyID: "b"},
+			},
+		},
+		{
+			desc: "dangling fare rule references",
+			content: newZipBuilderWithDefaults().add(
+				"fare_attributes.txt",
+				"fare_id,price,currency_type,payment_method,transfers,transfer_duration",
+				"fare_1,2.75,USD,1,1,7200",
+			).add(
+				"fare_rules.txt",
+				"fare_id,route_id,origin_id",
+				"fare_2,,",
+				"fare_1,route_2,",
+				"fare_1,,zone_2",
+			).build(),
+			wantFares: []FareAttribute{fare},
+			wantRules: []FareRule{
+				{
+					Fare:         &fare,
+					OriginZoneId: "zone_2",
+				},
+			},
+			wantWarnings: []warnings.StaticWarningKind{
+				warnings.InvalidFareReference{FareID: "fare_2"},
+				warnings.InvalidRouteReference{RouteID: "route_2"},
+				warnings.InvalidZoneReference{ZoneID: "zone_2"},
+			},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			actual, err := ParseStatic(tc.content, ParseStaticOptions{})
+			if err != nil {
+				t.Fatalf("error when parsing: %s", err)
+			}
+			if diff := cmp.Diff(tc.wantFares, actual.FareAttributes); diff != "" {
+				t.Errorf("fare attributes not the same: %s", diff)
+			}
+			if diff := cmp.Diff(tc.wantRules, actual.FareRules); diff != "" {
+				t.Errorf("fare rules not the same: %s", diff)
+			}
+			var gotWarnings []warnings.StaticWarningKind
+			for _, w := range actual.Warnings {
+				gotWarnings = append(gotWarnings, w.Kind)
+			}
+			if diff := cmp.Diff(tc.wantWarnings, gotWarnings); diff != "" {
+				t.Errorf("warnings not the same: %s", diff)
+			}
+		})
+	}
+}
+
+func TestParseFaresV1_WarningRowContent(t *testing.T) {
+	actual, err := ParseStatic(newZipBuilderWithDefaults().add(
+		"fare_attributes.txt",
+		"fare_id,price,currency_type,payment_method,transfers",
+		"fare_1,abc,USD,1,1",
+		"fare_2,def,USD,1,1",
+		"fare_3,2.75,USD,1,1",
+	).build(), ParseStaticOptions{})
+	if err != nil {
+		t.Fatalf("error when parsing: %s", err)
+	}
+	var gotRows [][]string
+	for _, w := range actual.Warnings {
+		gotRows = append(gotRows, w.RowContent)
+	}
+	// Each warning keeps the content of its own row, even though later rows are read into the same buffer.
+	wantRows := [][]string{
+		{"fare_1", "abc", "USD", "1", "1"},
+		{"fare_2", "def", "USD", "1", "1"},
+	}
+	if diff := cmp.Diff(wantRows, gotRows); diff != "" {
+		t.Errorf("warning rows not the same: %s", diff)
+	}
+}
+
+func TestCalculateFareV1(t *testing.T) {
+	static, err := ParseStatic(newZipBuilderWithDefaults().add(
+		"routes.txt",
+		"route_id,route_type",
+		"local,3",
+		"express,3",
+	).add(
+		"stops.txt",
+		"stop_id,zone_id",
+		"stop_1,zone_1",
+		"stop_2,zone_2",
+		"stop_3,zone_3",
+	).add(
+		"trips.txt",
+		"route_id,service_id,trip_id",
+		"local,service_id,local_trip",
+		"express,service_id,express_trip",
+	).add(
+		"stop_times.txt",
+		"trip_id,stop_id,stop_sequence,arrival_time,departure_time",
+		"local_trip,stop_1,1,08:00:00,08:00:00",
+		"local_trip,stop_2,2,08:10:00,08:10:00",
+		"express_trip,stop_2,1,08:20:00,08:20:00",
+		"express_trip,stop_3,2,08:40:00,08:40:00",
+		"express_trip,stop_1,3,09:40:00,09:40:00",
+		"express_trip,stop_2,4,10:00:00,10:00:00",
+	).add(
+		"fare_attributes.txt",
+		"fare_id,price,currency_type,payment_method,transfers,transfer_duration",
+		"local_fare,2,USD,0,0,",
+		"express_fare,5,USD,0,0,",
+		"pass,5.5,USD,0,1,3600",
+		"zone_1_to_2,1,USD,0,0,",
+	).add(
+		"fare_rules.txt",
+		"fare_id,route_id,origin_id,destination_id,contains_id",
+		"local_fare,local,,,",
+		"express_fare,express,,,",
+		"pass,local,,,",
+		"pass,express,,,",
+		"zone_1_to_2,local,zone_1,zone_2,",
+		"zone_1_to_2,express,zone_2,zone_3,zone_2",
+		"zone_1_to_2,express,zone_2,zone_3,zone_3",
+	).build(), ParseStaticOptions{})
+	if err != nil {
+		t.Fatalf("error when parsing: %s", err)
+	}
+	tripIDToTrip := map[string]*ScheduledTrip{}
+	for i := range static.Trips {
+		tripIDToTrip[static.Trips[i].ID] = &static.Trips[i]
+	}
+	leg := func(tripID string, board, alight int) FareLeg {
+		trip := tripIDToTrip[tripID]
+		return FareLeg{
+			Board:  &trip.StopTimes[board],
+			Alight: &trip.StopTimes[alight],
+		}
+	}
+	for _, tc := range []struct {
+		desc      string
+		legs      []FareLeg
+		wantFares []string
+		wantPrice float64
+	}{
+		{
+			desc:      "single leg by route",
+			legs:      []FareLeg{leg("express_trip", 1, 2)},
+			wantFares: []string{"express_fare"},
+			wantPrice: 5,
+		},
+		{
+			desc:      "single leg by zones",
+			legs:      []FareLeg{leg("local_trip", 0, 1)},
+			wantFares: []string{"zone_1_to_2"},
+			wantPrice: 1,
+		},
+		{
+			desc:      "transfer within transfer duration",
+			legs:      []FareLeg{leg("local_trip", 0, 1), leg("express_trip", 1, 2)},
+			wantFares: []string{"pass"},
+			wantPrice: 5.5,
+		},
+		{
+			desc:      "transfer after transfer duration",
+			legs:      []FareLeg{leg("local_trip", 0, 1), leg("express_trip", 2, 3)},
+			wantFares: []string{"zone_1_to_2", "express_fare"},
+			wantPrice: 6,
+		},
+		{
+			desc:      "contains all zones",
+			legs:      []FareLeg{leg("express_trip", 0, 1)},
+			wantFares: []string{"zone_1_to_2"},
+			wantPrice: 1,
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			fare, ok := static.CalculateFareV1(tc.legs)
+			if !ok {
+				t.Fatalf("no fare found")
+			}
+			var gotFares []string
+			for _, payment := range fare.Payments {
+				gotFares = append(gotFares, payment.Fare.Id)
+			}
+			if diff := cmp.Diff(tc.wantFares, gotFares); diff != "" {
+				t.Errorf("fares not the same: %s", diff)
+			}
+			if got := fare.Price(); got != tc.wantPrice {
+				t.Errorf("price: got %f, want %f", got, tc.wantPrice)
+			}
+		})
+	}
+}
+
+func TestCalculateFareV1_NoFare(t *testing.T) {
+	static, err := ParseStatic(newZipBuilderWithDefaults().add(
+		"fare_attributes.txt",
+		"fare_id,price,currency_type,payment_method,transfers",
+		"fare_1,2,USD,0,0",
+	).add(
+		"fare_rules.txt",
+		"fare_id,route_id",
+		"fare_1,route_id",
+	).add(
+		"routes.txt",
+		"route_id,route_type",
+		"route_id,3",
+		"other_route_id,3",
+	).add(
+		"trips.txt",
+		"route_id,service_id,trip_id",
+		"other_route_id,service_id,a",
+	).add(
+		"stop_times.txt",
+		"trip_id,stop_id,stop_sequence,arrival_time,departure_time",
+		"a,stop_id,1,08:00:00,08:00:00",
+		"a,stop_id,2,08:10:00,08:10:00",
+	).build(), ParseStaticOptions{})
+	if err != nil {
+		t.Fatalf("error when parsing: %s", err)
+	}
+	trip := &static.Trips[0]
+	if _, ok := static.CalculateFareV1([]FareLeg{{Board: &trip.StopTimes[0], Alight: &trip.StopTimes[1]}}); ok {
+		t.Errorf("expected no fare to be found")
+	}
+}
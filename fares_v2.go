@@ -0,0 +1,797 @@
+package gtfs
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/jamespfennell/gtfs/csv"
+	"github.com/jamespfennell/gtfs/warnings"
+)
+
+// Timeframe corresponds to a single row in the timeframes.txt file.
+type Timeframe struct {
+	GroupId   string
+	StartTime time.Duration
+	EndTime   time.Duration
+	Service   *Service
+	Extra     map[string]string
+}
+
+// FareMedia corresponds to a single row in the fare_media.txt file.
+type FareMedia struct {
+	Id    string
+	Name  string
+	Type  FareMediaType
+	Extra map[string]string
+}
+
+// FareProduct corresponds to a single row in the fare_products.txt file.
+//
+// A fare product that can be purchased using multiple fare media has multiple rows,
+// all with the same ID.
+type FareProduct struct {
+	Id       string
+	Name     string
+	Media    *FareMedia
+	Amount   float64
+	Currency string
+	Extra    map[string]string
+}
+
+// FareLegGroup is the set of fare leg rules that share a leg group ID.
+type FareLegGroup struct {
+	Id    string
+	Rules []*FareLegRule
+}
+
+// FareLegRule corresponds to a single row in the fare_leg_rules.txt file.
+//
+// A nil or empty field means the field was not specified in the rule.
+type FareLegRule struct {
+	LegGroup       *FareLegGroup
+	Network        *Network
+	FromArea       *Area
+	ToArea         *Area
+	FromTimeframes []*Timeframe
+	ToTimeframes   []*Timeframe
+	// All of the rows in fare_products.txt with the fare product ID of the rule.
+	FareProducts []*FareProduct
+	RulePriority *int32
+	Extra        map[string]string
+}
+
+// FareLegJoinRule corresponds to a single row in the fare_leg_join_rules.txt file.
+type FareLegJoinRule struct {
+	FromNetwork *Network
+	ToNetwork   *Network
+	FromStop    *Stop
+	ToStop      *Stop
+	Extra       map[string]string
+}
+
+// FareTransferRule corresponds to a single row in the fare_transfer_rules.txt file.
+type FareTransferRule struct {
+	FromLegGroup *FareLegGroup
+	ToLegGroup   *FareLegGroup
+	// Number of consecutive transfers that the rule applies to, or nil if not specified.
+	// A value of -1 means there is no limit.
+	TransferCount     *int32
+	DurationLimit     *time.Duration
+	DurationLimitType DurationLimitType
+	FareTransferType  FareTransferType
+	// All of the rows in fare_products.txt with the fare product ID of the rule.
+	// If empty, the transfer is free.
+	FareProducts []*FareProduct
+	Extra        map[string]string
+}
+
+func parseTimeframes(csv *csv.File, services []Service) ([]Timeframe, []warnings.StaticWarning) {
+	var w []warnings.StaticWarning
+	groupIDColumn := csv.RequiredColumn("timeframe_group_id")
+	startTimeColumn := csv.OptionalColumn("start_time")
+	endTimeColumn := csv.OptionalColumn("end_time")
+	serviceIDColumn := csv.RequiredColumn("service_id")
+
+	if warnings := checkForMissingColumns(csv); len(warnings) > 0 {
+		return nil, warnings
+	}
+
+	idToService := map[string]*Service{}
+	for i := range services {
+		idToService[services[i].Id] = &services[i]
+	}
+	var timeframes []Timeframe
+rows:
+	for csv.NextRow() {
+		groupID := groupIDColumn.Read()
+		serviceID := serviceIDColumn.Read()
+		if missingKeys := csv.MissingRowKeys(); len(missingKeys) > 0 {
+			w = append(w, warnings.NewStaticWarning(csv, warnings.MissingValues{Columns: missingKeys}))
+			continue
+		}
+		service, ok := idToService[serviceID]
+		if !ok {
+			w = append(w, warnings.NewStaticWarning(csv, warnings.InvalidServiceReference{ServiceID: serviceID}))
+			continue
+		}
+		startTime, endTime := startTimeColumn.Read(), endTimeColumn.Read()
+		// The spec requires both times or neither, which means the whole day.
+		if (startTime == "") != (endTime == "") {
+			missingColumn := "start_time"
+			if endTime == "" {
+				missingColumn = "end_time"
+			}
+			w = append(w, warnings.NewStaticWarning(csv, warnings.MissingValues{Columns: []string{missingColumn}}))
+			continue
+		}
+		timeframe := Timeframe{
+			GroupId:   groupID,
+			StartTime: 0,
+			EndTime:   24 * time.Hour,
+			Service:   service,
+			Extra:     csv.ExtraColumns(),
+		}
+		for _, c := range []struct {
+			column string
+			value  string
+			out    *time.Duration
+		}{
+			{"start_time", startTime, &timeframe.StartTime},
+			{"end_time", endTime, &timeframe.EndTime},
+		} {
+			if c.value == "" {
+				continue
+			}
+			d, ok := parseGtfsTimeToDuration(c.value)
+			if !ok {
+				// Keeping the row with the default times would make the timeframe cover the whole day.
+				w = append(w, warnings.NewStaticWarning(csv, warnings.UnparsableTime{Column: c.column, Value: c.value}))
+				continue rows
+			}
+			*c.out = d
+		}
+		timeframes = append(timeframes, timeframe)
+	}
+	return timeframes, w
+}
+
+func parseFareMedia(csv *csv.File) ([]FareMedia, []warnings.StaticWarning) {
+	var w []warnings.StaticWarning
+	idColumn := csv.RequiredColumn("fare_media_id")
+	nameColumn := csv.OptionalColumn("fare_media_name")
+	typeColumn := csv.RequiredColumn("fare_media_type")
+
+	if warnings := checkForMissingColumns(csv); len(warnings) > 0 {
+		return nil, warnings
+	}
+
+	var media []FareMedia
+	for csv.NextRow() {
+		m := FareMedia{
+			Id:    idColumn.Read(),
+			Name:  nameColumn.Read(),
+			Type:  parseFareMediaType(typeColumn.Read()),
+			Extra: csv.ExtraColumns(),
+		}
+		if missingKeys := csv.MissingRowKeys(); len(missingKeys) > 0 {
+			w = append(w, warnings.NewStaticWarning(csv, warnings.MissingValues{Columns: missingKeys}))
+			continue
+		}
+		media = append(media, m)
+	}
+	return media, w
+}
+
+func parseFareProducts(csv *csv.File, media []FareMedia) ([]FareProduct, []warnings.StaticWarning) {
+	var w []warnings.StaticWarning
+	idColumn := csv.RequiredColumn("fare_product_id")
+	nameColumn := csv.OptionalColumn("fare_product_name")
+	mediaIDColumn := csv.OptionalColumn("fare_media_id")
+	amountColumn := csv.RequiredColumn("amount")
+	currencyColumn := csv.RequiredColumn("currency")
+
+	if warnings := checkForMissingColumns(csv); len(warnings) > 0 {
+		return nil, warnings
+	}
+
+	idToMedia := map[string]*FareMedia{}
+	for i := range media {
+		idToMedia[media[i].Id] = &media[i]
+	}
+	var products []FareProduct
+	for csv.NextRow() {
+		product := FareProduct{
+			Id:       idColumn.Read(),
+			Name:     nameColumn.Read(),
+			Currency: currencyColumn.Read(),
+			Extra:    csv.ExtraColumns(),
+		}
+		rawAmount := amountColumn.Read()
+		if missingKeys := csv.MissingRowKeys(); len(missingKeys) > 0 {
+			w = append(w, warnings.NewStaticWarning(csv, warnings.MissingValues{Columns: missingKeys}))
+			continue
+		}
+		amount, err := strconv.ParseFloat(rawAmount, 64)
+		if err != nil {
+			w = append(w, warnings.NewStaticWarning(csv, warnings.InvalidValue{Column: "amount", Value: rawAmount}))
+			continue
+		}
+		product.Amount = amount
+		if mediaID := mediaIDColumn.Read(); mediaID != "" {
+			m, ok := idToMedia[mediaID]
+			if !ok {
+				w = append(w, warnings.NewStaticWarning(csv, warnings.InvalidFareMediaReference{FareMediaID: mediaID}))
+				continue
+			}
+			product.Media = m
+		}
+		products = append(products, product)
+	}
+	return products, w
+}
+
+// fareV2References contains lookup tables for the entities that the fares v2 rule files refer to.
+type fareV2References struct {
+	idToNetwork    map[string]*Network
+	idToArea       map[string]*Area
+	idToStop       map[string]*Stop
+	groupToFrames  map[string][]*Timeframe
+	idToProducts   map[string][]*FareProduct
+	idToLegGroup   map[string]*FareLegGroup
+	routeToNetwork map[*Route]*Network
+}
+
+func newFareV2References(static *Static) *fareV2References {
+	r := &fareV2References{
+		idToNetwork:    map[string]*Network{},
+		idToArea:       map[string]*Area{},
+		idToStop:       map[string]*Stop{},
+		groupToFrames:  map[string][]*Timeframe{},
+		idToProducts:   map[string][]*FareProduct{},
+		idToLegGroup:   map[string]*FareLegGroup{},
+		routeToNetwork: map[*Route]*Network{},
+	}
+	for i := range static.Networks {
+		network := &static.Networks[i]
+		r.idToNetwork[network.Id] = network
+		for _, route := range network.Routes {
+			r.routeToNetwork[route] = network
+		}
+	}
+	for i := range static.Areas {
+		r.idToArea[static.Areas[i].Id] = &static.Areas[i]
+	}
+	for i := range static.Stops {
+		r.idToStop[static.Stops[i].Id] = &static.Stops[i]
+	}
+	for i := range static.Timeframes {
+		timeframe := &static.Timeframes[i]
+		r.groupToFrames[timeframe.GroupId] = append(r.groupToFrames[timeframe.GroupId], timeframe)
+	}
+	for i := range static.FareProducts {
+		product := &static.FareProducts[i]
+		r.idToProducts[product.Id] = append(r.idToProducts[product.Id], product)
+	}
+	for i := range static.FareLegGroups {
+		r.idToLegGroup[static.FareLegGroups[i].Id] = &static.FareLegGroups[i]
+	}
+	return r
+}
+
+// readReference resolves the ID in the column using the lookup table. If the ID is not empty
+// and does not match any entity, a warning is appended and false is returned.
+func readReference[T any](csv *csv.File, column csv.OptionalColumn, m map[string]T, newWarning func(id string) warnings.StaticWarningKind, w *[]warnings.StaticWarning) (T, bool) {
+	var zero T
+	id := column.Read()
+	if id == "" {
+		return zero, true
+	}
+	v, ok := m[id]
+	if !ok {
+		*w = append(*w, warnings.NewStaticWarning(csv, newWarning(id)))
+		return zero, false
+	}
+	return v, true
+}
+
+func invalidNetworkReference(id string) warnings.StaticWarningKind {
+	return warnings.InvalidNetworkReference{NetworkID: id}
+}
+
+func invalidAreaReference(id string) warnings.StaticWarningKind {
+	return warnings.InvalidAreaReference{AreaID: id}
+}
+
+func invalidRouteReference(id string) warnings.StaticWarningKind {
+	return warnings.InvalidRouteReference{RouteID: id}
+}
+
+func invalidTripReference(id string) warnings.StaticWarningKind {
+	return warnings.InvalidTripReference{TripID: id}
+}
+
+func invalidStopReference(id string) warnings.StaticWarningKind {
+	return warnings.InvalidStopReference{StopID: id}
+}
+
+func invalidTimeframeReference(id string) warnings.StaticWarningKind {
+	return warnings.InvalidTimeframeReference{TimeframeGroupID: id}
+}
+
+func invalidFareProductReference(id string) warnings.StaticWarningKind {
+	return warnings.InvalidFareProductReference{FareProductID: id}
+}
+
+func invalidLegGroupReference(id string) warnings.StaticWarningKind {
+	return warnings.InvalidLegGroupReference{LegGroupID: id}
+}
+
+func parseFareLegRules(csv *csv.File, refs *fareV2References) ([]FareLegRule, []FareLegGroup, []warnings.StaticWarning) {
+	var w []warnings.StaticWarning
+	legGroupIDColumn := csv.OptionalColumn("leg_group_id")
+	networkIDColumn := csv.OptionalColumn("network_id")
+	fromAreaIDColumn := csv.OptionalColumn("from_area_id")
+	toAreaIDColumn := csv.OptionalColumn("to_area_id")
+	fromTimeframeColumn := csv.OptionalColumn("from_timeframe_group_id")
+	toTimeframeColumn := csv.OptionalColumn("to_timeframe_group_id")
+	fareProductIDColumn := csv.RequiredColumn("fare_product_id")
+	rulePriorityColumn := csv.OptionalColumn("rule_priority")
+
+	if warnings := checkForMissingColumns(csv); len(warnings) > 0 {
+		return nil, nil, warnings
+	}
+
+	var rules []FareLegRule
+	var legGroupIDs []string
+	for csv.NextRow() {
+		fareProductID := fareProductIDColumn.Read()
+		if missingKeys := csv.MissingRowKeys(); len(missingKeys) > 0 {
+			w = append(w, warnings.NewStaticWarning(csv, warnings.MissingValues{Columns: missingKeys}))
+			continue
+		}
+		products, ok := refs.idToProducts[fareProductID]
+		if !ok {
+			w = append(w, warnings.NewStaticWarning(csv, warnings.InvalidFareProductReference{FareProductID: fareProductID}))
+			continue
+		}
+		rule := FareLegRule{
+			FareProducts: products,
+			RulePriority: parseInt32(rulePriorityColumn.Read()),
+			Extra:        csv.ExtraColumns(),
+		}
+		var networkOk, fromAreaOk, toAreaOk, fromTimeframeOk, toTimeframeOk bool
+		rule.Network, networkOk = readReference(csv, networkIDColumn, refs.idToNetwork, invalidNetworkReference, &w)
+		rule.FromArea, fromAreaOk = readReference(csv, fromAreaIDColumn, refs.idToArea, invalidAreaReference, &w)
+		rule.ToArea, toAreaOk = readReference(csv, toAreaIDColumn, refs.idToArea, invalidAreaReference, &w)
+		rule.FromTimeframes, fromTimeframeOk = readReference(csv, fromTimeframeColumn, refs.groupToFrames, invalidTimeframeReference, &w)
+		rule.ToTimeframes, toTimeframeOk = readReference(csv, toTimeframeColumn, refs.groupToFrames, invalidTimeframeReference, &w)
+		// Dropping a reference would make the rule apply more broadly than intended, so we skip the rule.
+		if !networkOk || !fromAreaOk || !toAreaOk || !fromTimeframeOk || !toTimeframeOk {
+			continue
+		}
+		rules = append(rules, rule)
+		legGroupIDs = append(legGroupIDs, legGroupIDColumn.Read())
+	}
+
+	var groups []FareLegGroup
+	groupIDToIndex := map[string]int{}
+	for _, legGroupID := range legGroupIDs {
+		if _, ok := groupIDToIndex[legGroupID]; ok || legGroupID == "" {
+			continue
+		}
+		groupIDToIndex[legGroupID] = len(groups)
+		groups = append(groups, FareLegGroup{Id: legGroupID})
+	}
+	for i, legGroupID := range legGroupIDs {
+		j, ok := groupIDToIndex[legGroupID]
+		if !ok {
+			continue
+		}
+		rules[i].LegGroup = &groups[j]
+		groups[j].Rules = append(groups[j].Rules, &rules[i])
+	}
+	return rules, groups, w
+}
+
+func parseFareLegJoinRules(csv *csv.File, refs *fareV2References) ([]FareLegJoinRule, []warnings.StaticWarning) {
+	var w []warnings.StaticWarning
+	fromNetworkIDColumn := csv.RequiredColumn("from_network_id")
+	toNetworkIDColumn := csv.RequiredColumn("to_network_id")
+	fromStopIDColumn := csv.OptionalColumn("from_stop_id")
+	toStopIDColumn := csv.OptionalColumn("to_stop_id")
+
+	if warnings := checkForMissingColumns(csv); len(warnings) > 0 {
+		return nil, warnings
+	}
+
+	var rules []FareLegJoinRule
+	for csv.NextRow() {
+		fromNetworkID := fromNetworkIDColumn.Read()
+		toNetworkID := toNetworkIDColumn.Read()
+		if missingKeys := csv.MissingRowKeys(); len(missingKeys) > 0 {
+			w = append(w, warnings.NewStaticWarning(csv, warnings.MissingValues{Columns: missingKeys}))
+			continue
+		}
+		fromNetwork, fromNetworkOk := refs.idToNetwork[fromNetworkID]
+		if !fromNetworkOk {
+			w = append(w, warnings.NewStaticWarning(csv, warnings.InvalidNetworkReference{NetworkID: fromNetworkID}))
+		}
+		toNetwork, toNetworkOk := refs.idToNetwork[toNetworkID]
+		if !toNetworkOk {
+			w = append(w, warnings.NewStaticWarning(csv, warnings.InvalidNetworkReference{NetworkID: toNetworkID}))
+		}
+		fromStop, fromStopOk := readReference(csv, fromStopIDColumn, refs.idToStop, invalidStopReference, &w)
+		toStop, toStopOk := readReference(csv, toStopIDColumn, refs.idToStop, invalidStopReference, &w)
+		if !fromNetworkOk || !toNetworkOk || !fromStopOk || !toStopOk {
+			continue
+		}
+		rules = append(rules, FareLegJoinRule{
+			FromNetwork: fromNetwork,
+			ToNetwork:   toNetwork,
+			FromStop:    fromStop,
+			ToStop:      toStop,
+			Extra:       csv.ExtraColumns(),
+		})
+	}
+	return rules, w
+}
+
+func parseFareTransferRules(csv *csv.File, refs *fareV2References) ([]FareTransferRule, []warnings.StaticWarning) {
+	var w []warnings.StaticWarning
+	fromLegGroupIDColumn := csv.OptionalColumn("from_leg_group_id")
+	toLegGroupIDColumn := csv.OptionalColumn("to_leg_group_id")
+	transferCountColumn := csv.OptionalColumn("transfer_count")
+	durationLimitColumn := csv.OptionalColumn("duration_limit")
+	durationLimitTypeColumn := csv.OptionalColumn("duration_limit_type")
+	fareTransferTypeColumn := csv.RequiredColumn("fare_transfer_type")
+	fareProductIDColumn := csv.OptionalColumn("fare_product_id")
+
+	if warnings := checkForMissingColumns(csv); len(warnings) > 0 {
+		return nil, warnings
+	}
+
+	var rules []FareTransferRule
+	for csv.NextRow() {
+		rule := FareTransferRule{
+			TransferCount:     parseInt32(transferCountColumn.Read()),
+			DurationLimitType: parseDurationLimitType(durationLimitTypeColumn.Read()),
+			FareTransferType:  parseFareTransferType(fareTransferTypeColumn.Read()),
+			Extra:             csv.ExtraColumns(),
+		}
+		if missingKeys := csv.MissingRowKeys(); len(missingKeys) > 0 {
+			w = append(w, warnings.NewStaticWarning(csv, warnings.MissingValues{Columns: missingKeys}))
+			continue
+		}
+		if durationLimit := parseInt32(durationLimitColumn.Read()); durationLimit != nil {
+			d := time.Duration(*durationLimit) * time.Second
+			rule.DurationLimit = &d
+		}
+		var fromOk, toOk, productOk bool
+		rule.FromLegGroup, fromOk = readReference(csv, fromLegGroupIDColumn, refs.idToLegGroup, invalidLegGroupReference, &w)
+		rule.ToLegGroup, toOk = readReference(csv, toLegGroupIDColumn, refs.idToLegGroup, invalidLegGroupReference, &w)
+		rule.FareProducts, productOk = readReference(csv, fareProductIDColumn, refs.idToProducts, invalidFareProductReference, &w)
+		if !fromOk || !toOk || !productOk {
+			continue
+		}
+		rules = append(rules, rule)
+	}
+	return rules, w
+}
+
+// FareV2 is the result of applying the GTFS fares v2 rules to an itinerary.
+type FareV2 struct {
+	// Effective fare legs of the itinerary.
+	Legs []FareV2Leg
+	// Transfers between consecutive effective fare legs that match a fare transfer rule.
+	Transfers []FareV2Transfer
+}
+
+// FareV2Leg is an effective fare leg: either a single leg of the itinerary, or multiple
+// consecutive legs that are joined by a fare leg join rule.
+type FareV2Leg struct {
+	Legs []FareLeg
+	// Fare leg rules that match the effective fare leg.
+	Rules []*FareLegRule
+	// Fare products of the matching rules. The rider needs one of these products for the leg.
+	FareProducts []*FareProduct
+}
+
+// FareV2Transfer is a transfer between two consecutive effective fare legs.
+type FareV2Transfer struct {
+	// Index of the effective fare leg before the transfer.
+	FromLeg int
+	// Index of the effective fare leg after the transfer.
+	ToLeg int
+	Rule  *FareTransferRule
+	// Cost of the transfer, which is the amount of the cheapest fare product of the rule, or zero if
+	// the rule has no fare product. The fare transfer type of the rule says whether the fares of the
+	// legs are charged in addition to this cost.
+	Amount   float64
+	Currency string
+}
+
+// CalculateFareV2 applies the GTFS fares v2 rules to the itinerary described by the legs.
+//
+// Legs are first joined into effective fare legs using the fare leg join rules. Each effective
+// fare leg is matched against the fare leg rules, and each transfer between consecutive effective
+// fare legs is matched against the fare transfer rules.
+//
+// Timeframes match legs whose times of day are in the timeframe and, if the date of the leg is
+// set, whose days are active days of the service of the timeframe. Times after 24:00:00 are on the
+// day after the date of the leg.
+func (static *Static) CalculateFareV2(legs []FareLeg) FareV2 {
+	refs := newFareV2References(static)
+	var result FareV2
+	for _, joinedLegs := range static.joinFareLegs(legs, refs) {
+		rules := static.matchFareLegRules(joinedLegs, refs)
+		fareLeg := FareV2Leg{
+			Legs:  joinedLegs,
+			Rules: rules,
+		}
+		seen := map[*FareProduct]bool{}
+		for _, rule := range rules {
+			for _, product := range rule.FareProducts {
+				if !seen[product] {
+					fareLeg.FareProducts = append(fareLeg.FareProducts, product)
+					seen[product] = true
+				}
+			}
+		}
+		result.Legs = append(result.Legs, fareLeg)
+	}
+
+	// A rule may apply to a sequence of consecutive transfers, in which case its transfer count is
+	// counted from the first leg in the sequence. Its duration limit applies to each transfer.
+	var chainRule *FareTransferRule
+	var chainLength int
+	for i := 1; i < len(result.Legs); i++ {
+		from := &result.Legs[i-1]
+		to := &result.Legs[i]
+		var rule *FareTransferRule
+		if chainRule != nil && fareTransferRuleApplies(chainRule, from, to, chainLength) {
+			rule = chainRule
+		} else {
+			// A leg that ends a sequence of transfers cannot start a new sequence under the same rule.
+			rule = static.matchFareTransferRule(from, to, chainRule)
+			chainLength = 0
+		}
+		chainRule = rule
+		if rule == nil {
+			continue
+		}
+		chainLength++
+		transfer := FareV2Transfer{
+			FromLeg: i - 1,
+			ToLeg:   i,
+			Rule:    rule,
+		}
+		for j, product := range rule.FareProducts {
+			if j == 0 || product.Amount < transfer.Amount {
+				transfer.Amount = product.Amount
+				transfer.Currency = product.Currency
+			}
+		}
+		result.Transfers = append(result.Transfers, transfer)
+	}
+	return result
+}
+
+func (static *Static) joinFareLegs(legs []FareLeg, refs *fareV2References) [][]FareLeg {
+	var joined [][]FareLeg
+	for i, leg := range legs {
+		if i > 0 && static.fareLegsJoin(&legs[i-1], &legs[i], refs) {
+			joined[len(joined)-1] = append(joined[len(joined)-1], leg)
+			continue
+		}
+		joined = append(joined, []FareLeg{leg})
+	}
+	return joined
+}
+
+func (static *Static) fareLegsJoin(from, to *FareLeg, refs *fareV2References) bool {
+	fromNetwork := refs.routeToNetwork[from.route()]
+	toNetwork := refs.routeToNetwork[to.route()]
+	for i := range static.FareLegJoinRules {
+		rule := &static.FareLegJoinRules[i]
+		if rule.FromNetwork != fromNetwork || rule.ToNetwork != toNetwork {
+			continue
+		}
+		if rule.FromStop != nil && !stopMatches(rule.FromStop, from.Alight.Stop) {
+			continue
+		}
+		if rule.ToStop != nil && !stopMatches(rule.ToStop, to.Board.Stop) {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// stopMatches returns whether the stop is the target stop, or is a child of the target station.
+func stopMatches(target, stop *Stop) bool {
+	for ; stop != nil; stop = stop.Parent {
+		if stop == target {
+			return true
+		}
+	}
+	return false
+}
+
+// areaContains returns whether the stop or one of its ancestors is in the area.
+func areaContains(area *Area, stop *Stop) bool {
+	for _, areaStop := range area.Stops {
+		if stopMatches(areaStop, stop) {
+			return true
+		}
+	}
+	return false
+}
+
+// timeframesContain returns whether the time, measured from the date, is in one of the timeframes.
+// If the date is zero, the services of the timeframes are not checked.
+func timeframesContain(timeframes []*Timeframe, date time.Time, t time.Duration) bool {
+	if !date.IsZero() {
+		date = date.AddDate(0, 0, int(t/(24*time.Hour)))
+	}
+	t = t % (24 * time.Hour)
+	for _, timeframe := range timeframes {
+		if timeframe.StartTime > t || t >= timeframe.EndTime {
+			continue
+		}
+		if date.IsZero() || timeframe.Service == nil || timeframe.Service.IsActiveOn(date) {
+			return true
+		}
+	}
+	return false
+}
+
+func (static *Static) matchFareLegRules(legs []FareLeg, refs *fareV2References) []*FareLegRule {
+	first := &legs[0]
+	last := &legs[len(legs)-1]
+	network := refs.routeToNetwork[first.route()]
+	for i := range legs {
+		if refs.routeToNetwork[legs[i].route()] != network {
+			network = nil
+		}
+	}
+	// Each matcher returns whether the field is set in the rule, and if so whether it matches the leg.
+	matchers := []func(rule *FareLegRule) (bool, bool){
+		func(rule *FareLegRule) (bool, bool) {
+			return rule.Network != nil, rule.Network != nil && rule.Network == network
+		},
+		func(rule *FareLegRule) (bool, bool) {
+			return rule.FromArea != nil, rule.FromArea != nil && areaContains(rule.FromArea, first.Board.Stop)
+		},
+		func(rule *FareLegRule) (bool, bool) {
+			return rule.ToArea != nil, rule.ToArea != nil && areaContains(rule.ToArea, last.Alight.Stop)
+		},
+		func(rule *FareLegRule) (bool, bool) {
+			return len(rule.FromTimeframes) > 0, timeframesContain(rule.FromTimeframes, first.Date, first.Board.DepartureTime)
+		},
+		func(rule *FareLegRule) (bool, bool) {
+			return len(rule.ToTimeframes) > 0, timeframesContain(rule.ToTimeframes, last.Date, last.Alight.ArrivalTime)
+		},
+	}
+	var candidates []*FareLegRule
+	usesPriority := false
+	for i := range static.FareLegRules {
+		candidates = append(candidates, &static.FareLegRules[i])
+		usesPriority = usesPriority || static.FareLegRules[i].RulePriority != nil
+	}
+	if usesPriority {
+		// When rule priorities are used, empty fields match everything and only the matching
+		// rules with the highest priority apply.
+		var matches []*FareLegRule
+		var maxPriority int32
+		for _, rule := range candidates {
+			ok := true
+			for _, matcher := range matchers {
+				if set, matches := matcher(rule); set && !matches {
+					ok = false
+					break
+				}
+			}
+			if !ok {
+				continue
+			}
+			var priority int32
+			if rule.RulePriority != nil {
+				priority = *rule.RulePriority
+			}
+			if len(matches) == 0 || priority > maxPriority {
+				matches = nil
+				maxPriority = priority
+			}
+			if priority == maxPriority {
+				matches = append(matches, rule)
+			}
+		}
+		return matches
+	}
+	// Otherwise, for each field the rules that explicitly match the leg are used if there are
+	// any, and the rules in which the field is empty are used if not.
+	for _, matcher := range matchers {
+		var exact, empty []*FareLegRule
+		for _, rule := range candidates {
+			set, matches := matcher(rule)
+			if !set {
+				empty = append(empty, rule)
+			} else if matches {
+				exact = append(exact, rule)
+			}
+		}
+		if len(exact) > 0 {
+			candidates = exact
+		} else {
+			candidates = empty
+		}
+	}
+	return candidates
+}
+
+// matchFareTransferRule returns the transfer rule that applies to the transfer between the two
+// effective fare legs, or nil if no rule applies. The excluded rule, if not nil, is not considered.
+func (static *Static) matchFareTransferRule(from, to *FareV2Leg, excluded *FareTransferRule) *FareTransferRule {
+	var exact, empty []*FareTransferRule
+	for i := range static.FareTransferRules {
+		rule := &static.FareTransferRules[i]
+		if rule == excluded || !fareTransferRuleApplies(rule, from, to, 0) {
+			continue
+		}
+		if rule.FromLegGroup != nil && rule.ToLegGroup != nil {
+			exact = append(exact, rule)
+		} else {
+			empty = append(empty, rule)
+		}
+	}
+	if len(exact) > 0 {
+		return exact[0]
+	}
+	if len(empty) > 0 {
+		return empty[0]
+	}
+	return nil
+}
+
+// fareTransferRuleApplies returns whether the rule applies to the transfer between the two effective
+// fare legs, given that the transfer is preceded by the provided number of transfers under the same rule.
+//
+// As in the GTFS specification, the duration limit is measured from the departure or arrival of the
+// current leg, which is the leg before the transfer, to the departure or arrival of the next leg.
+func fareTransferRuleApplies(rule *FareTransferRule, from, to *FareV2Leg, transfers int) bool {
+	if rule.FromLegGroup != nil && !inLegGroup(from, rule.FromLegGroup) {
+		return false
+	}
+	if rule.ToLegGroup != nil && !inLegGroup(to, rule.ToLegGroup) {
+		return false
+	}
+	if rule.TransferCount != nil && *rule.TransferCount >= 0 && transfers >= int(*rule.TransferCount) {
+		return false
+	}
+	if rule.DurationLimit == nil {
+		return true
+	}
+	var begin, end time.Duration
+	switch rule.DurationLimitType {
+	case DurationLimitType_DepartureToArrival, DurationLimitType_DepartureToDeparture:
+		begin = from.Legs[0].Board.DepartureTime
+	default:
+		begin = from.Legs[len(from.Legs)-1].Alight.ArrivalTime
+	}
+	switch rule.DurationLimitType {
+	case DurationLimitType_DepartureToArrival, DurationLimitType_ArrivalToArrival:
+		end = to.Legs[len(to.Legs)-1].Alight.ArrivalTime
+	default:
+		end = to.Legs[0].Board.DepartureTime
+	}
+	return end-begin <= *rule.DurationLimit
+}
+
+func inLegGroup(leg *FareV2Leg, group *FareLegGroup) bool {
+	for _, rule := range leg.Rules {
+		if rule.LegGroup == group {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,399 @@
+package gtfs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jamespfennell/gtfs/warnings"
+)
+
+func newFaresV2ZipBuilder() *zipBuilder {
+	return newZipBuilderWithDefaults().add(
+		"calendar.txt",
+		"service_id,monday,tuesday,wednesday,thursday,friday,saturday,sunday,start_date,end_date",
+		"service_id,1,1,1,1,1,0,0,20220502,20220531",
+	).add(
+		"routes.txt",
+		"route_id,route_type",
+		"subway,1",
+		"bus,3",
+	).add(
+		"stops.txt",
+		"stop_id",
+		"stop_1",
+		"stop_2",
+		"stop_3",
+		"stop_4",
+	).add(
+		"trips.txt",
+		"route_id,service_id,trip_id",
+		"subway,service_id,subway_peak",
+		"subway,service_id,subway_off_peak",
+		"subway,service_id,subway_off_peak_2",
+		"bus,service_id,bus",
+		"bus,service_id,bus_2",
+	).add(
+		"stop_times.txt",
+		"trip_id,stop_id,stop_sequence,arrival_time,departure_time",
+		"subway_peak,stop_1,1,08:00:00,08:00:00",
+		"subway_peak,stop_2,2,08:10:00,08:10:00",
+		"subway_off_peak,stop_1,1,12:00:00,12:00:00",
+		"subway_off_peak,stop_2,2,12:10:00,12:10:00",
+		"subway_off_peak,stop_3,3,12:20:00,12:20:00",
+		"subway_off_peak_2,stop_2,1,12:15:00,12:15:00",
+		"subway_off_peak_2,stop_3,2,12:25:00,12:25:00",
+		"bus,stop_3,1,12:30:00,12:30:00",
+		"bus,stop_4,2,12:50:00,12:50:00",
+		"bus_2,stop_4,1,13:00:00,13:00:00",
+		"bus_2,stop_1,2,13:20:00,13:20:00",
+	).add(
+		"networks.txt",
+		"network_id,network_name",
+		"subway,Subway",
+		"bus,Bus",
+	).add(
+		"route_networks.txt",
+		"network_id,route_id",
+		"subway,subway",
+		"bus,bus",
+	).add(
+		"areas.txt",
+		"area_id,area_name",
+		"area_1,Area 1",
+	).add(
+		"stop_areas.txt",
+		"area_id,stop_id",
+		"area_1,stop_1",
+	).add(
+		"timeframes.txt",
+		"timeframe_group_id,start_time,end_time,service_id",
+		"peak,07:00:00,09:00:00,service_id",
+	).add(
+		"fare_media.txt",
+		"fare_media_id,fare_media_name,fare_media_type",
+		"cash,Cash,0",
+		"card,Card,2",
+	).add(
+		"fare_products.txt",
+		"fare_product_id,fare_product_name,fare_media_id,amount,currency",
+		"single_ride,Single ride,cash,2.90,USD",
+		"single_ride,Single ride,card,2.90,USD",
+		"peak_ride,Peak ride,card,3.50,USD",
+	).add(
+		"fare_leg_rules.txt",
+		"leg_group_id,network_id,from_area_id,to_area_id,from_timeframe_group_id,to_timeframe_group_id,fare_product_id",
+		"core,subway,,,,,single_ride",
+		"core,bus,,,,,single_ride",
+		"peak,subway,,,peak,,peak_ride",
+	).add(
+		"fare_leg_join_rules.txt",
+		"from_network_id,to_network_id,from_stop_id,to_stop_id",
+		"subway,subway,stop_2,stop_2",
+	).add(
+		"fare_transfer_rules.txt",
+		"from_leg_group_id,to_leg_group_id,transfer_count,duration_limit,duration_limit_type,fare_transfer_type,fare_product_id",
+		"core,core,1,7200,1,0,",
+	)
+}
+
+func TestParseFaresV2(t *testing.T) {
+	static, err := ParseStatic(newFaresV2ZipBuilder().build(), ParseStaticOptions{})
+	if err != nil {
+		t.Fatalf("error when parsing: %s", err)
+	}
+	if len(static.Warnings) != 0 {
+		t.Errorf("unexpected warnings: %v", static.Warnings)
+	}
+
+	if got, want := len(static.FareLegGroups), 2; got != want {
+		t.Fatalf("number of leg groups: got %d, want %d", got, want)
+	}
+	core := &static.FareLegGroups[0]
+	if core.Id != "core" || len(core.Rules) != 2 || core.Rules[0] != &static.FareLegRules[0] {
+		t.Errorf("unexpected core leg group: %+v", core)
+	}
+
+	peakRule := static.FareLegRules[2]
+	if peakRule.Network != &static.Networks[0] {
+		t.Errorf("peak rule network: got %+v, want the subway network", peakRule.Network)
+	}
+	if diff := cmp.Diff([]*Timeframe{&static.Timeframes[0]}, peakRule.FromTimeframes); diff != "" {
+		t.Errorf("peak rule timeframes not the same: %s", diff)
+	}
+	if peakRule.FromTimeframes[0].Service != &static.Services[0] {
+		t.Errorf("timeframe service not resolved")
+	}
+	if diff := cmp.Diff([]*FareProduct{&static.FareProducts[2]}, peakRule.FareProducts); diff != "" {
+		t.Errorf("peak rule fare products not the same: %s", diff)
+	}
+	if got := static.FareLegRules[0].FareProducts; len(got) != 2 || got[0].Media.Id != "cash" || got[1].Media.Id != "card" {
+		t.Errorf("single ride fare products: got %+v", got)
+	}
+
+	wantTransferRule := FareTransferRule{
+		FromLegGroup:      core,
+		ToLegGroup:        core,
+		TransferCount:     ptr(int32(1)),
+		DurationLimit:     ptr(2 * time.Hour),
+		DurationLimitType: DurationLimitType_DepartureToDeparture,
+		FareTransferType:  FareTransferType_FromLegPlusTransfer,
+	}
+	if diff := cmp.Diff([]FareTransferRule{wantTransferRule}, static.FareTransferRules); diff != "" {
+		t.Errorf("fare transfer rules not the same: %s", diff)
+	}
+
+	wantJoinRule := FareLegJoinRule{
+		FromNetwork: &static.Networks[0],
+		ToNetwork:   &static.Networks[0],
+		FromStop:    &static.Stops[1],
+		ToStop:      &static.Stops[1],
+	}
+	if diff := cmp.Diff([]FareLegJoinRule{wantJoinRule}, static.FareLegJoinRules); diff != "" {
+		t.Errorf("fare leg join rules not the same: %s", diff)
+	}
+}
+
+func TestParseFaresV2_InvalidReferences(t *testing.T) {
+	static, err := ParseStatic(newFaresV2ZipBuilder().add(
+		"fare_leg_rules.txt",
+		"leg_group_id,network_id,from_area_id,from_timeframe_group_id,fare_product_id",
+		"core,subway,,,single_ride",
+		"core,ferry,,,single_ride",
+		"core,,area_2,,single_ride",
+		"core,,,off_peak,single_ride",
+		"core,,,,monthly_pass",
+	).add(
+		"fare_transfer_rules.txt",
+		"from_leg_group_id,to_leg_group_id,fare_transfer_type",
+		"core,express,0",
+	).add(
+		"fare_products.txt",
+		"fare_product_id,fare_media_id,amount,currency",
+		"single_ride,cash,2.90,USD",
+		"single_ride,token,2.90,USD",
+	).build(), ParseStaticOptions{})
+	if err != nil {
+		t.Fatalf("error when parsing: %s", err)
+	}
+	var gotWarnings []warnings.StaticWarningKind
+	for _, w := range static.Warnings {
+		gotWarnings = append(gotWarnings, w.Kind)
+	}
+	wantWarnings := []warnings.StaticWarningKind{
+		warnings.InvalidFareMediaReference{FareMediaID: "token"},
+		warnings.InvalidNetworkReference{NetworkID: "ferry"},
+		warnings.InvalidAreaReference{AreaID: "area_2"},
+		warnings.InvalidTimeframeReference{TimeframeGroupID: "off_peak"},
+		warnings.InvalidFareProductReference{FareProductID: "monthly_pass"},
+		warnings.InvalidLegGroupReference{LegGroupID: "express"},
+	}
+	if diff := cmp.Diff(wantWarnings, gotWarnings); diff != "" {
+		t.Errorf("warnings not the same: %s", diff)
+	}
+	if got := len(static.FareLegRules); got != 1 {
+		t.Errorf("number of fare leg rules: got %d, want 1", got)
+	}
+}
+
+func TestParseFaresV2_InvalidTimeframes(t *testing.T) {
+	static, err := ParseStatic(newFaresV2ZipBuilder().add(
+		"timeframes.txt",
+		"timeframe_group_id,start_time,end_time,service_id",
+		"peak,07:00:00,09:00:00,service_id",
+		"peak,7am,10:00:00,service_id",
+		"peak,16:00:00,,service_id",
+		"off_peak,,10:00:00,service_id",
+	).build(), ParseStaticOptions{})
+	if err != nil {
+		t.Fatalf("error when parsing: %s", err)
+	}
+	var gotWarnings []warnings.StaticWarningKind
+	for _, w := range static.Warnings {
+		gotWarnings = append(gotWarnings, w.Kind)
+	}
+	wantWarnings := []warnings.StaticWarningKind{
+		warnings.UnparsableTime{Column: "start_time", Value: "7am"},
+		warnings.MissingValues{Columns: []string{"end_time"}},
+		warnings.MissingValues{Columns: []string{"start_time"}},
+	}
+	if diff := cmp.Diff(wantWarnings, gotWarnings); diff != "" {
+		t.Errorf("warnings not the same: %s", diff)
+	}
+	wantTimeframes := []Timeframe{
+		{
+			GroupId:   "peak",
+			StartTime: 7 * time.Hour,
+			EndTime:   9 * time.Hour,
+			Service:   &static.Services[0],
+		},
+	}
+	if diff := cmp.Diff(wantTimeframes, static.Timeframes); diff != "" {
+		t.Errorf("timeframes not the same: %s", diff)
+	}
+}
+
+func TestCalculateFareV2(t *testing.T) {
+	static, err := ParseStatic(newFaresV2ZipBuilder().build(), ParseStaticOptions{})
+	if err != nil {
+		t.Fatalf("error when parsing: %s", err)
+	}
+	tripIDToTrip := map[string]*ScheduledTrip{}
+	for i := range static.Trips {
+		tripIDToTrip[static.Trips[i].ID] = &static.Trips[i]
+	}
+	leg := func(tripID string, board, alight int) FareLeg {
+		trip := tripIDToTrip[tripID]
+		return FareLeg{
+			Board:  &trip.StopTimes[board],
+			Alight: &trip.StopTimes[alight],
+		}
+	}
+	datedLeg := func(tripID string, board, alight int, date time.Time) FareLeg {
+		fareLeg := leg(tripID, board, alight)
+		fareLeg.Date = date
+		return fareLeg
+	}
+	type transfer struct {
+		from, to int
+	}
+	for _, tc := range []struct {
+		desc          string
+		legs          []FareLeg
+		wantLegs      [][]string
+		wantTransfers []transfer
+	}{
+		{
+			desc:     "off peak",
+			legs:     []FareLeg{leg("subway_off_peak", 0, 1)},
+			wantLegs: [][]string{{"single_ride/cash", "single_ride/card"}},
+		},
+		{
+			desc:     "peak timeframe takes precedence",
+			legs:     []FareLeg{leg("subway_peak", 0, 1)},
+			wantLegs: [][]string{{"peak_ride/card"}},
+		},
+		{
+			// 2022-05-04 is a Wednesday, when the service of the timeframe runs.
+			desc:     "peak timeframe on a service day",
+			legs:     []FareLeg{datedLeg("subway_peak", 0, 1, may4)},
+			wantLegs: [][]string{{"peak_ride/card"}},
+		},
+		{
+			// 2022-05-07 is a Saturday, when the service of the timeframe doesn't run.
+			desc:     "peak timeframe on another day",
+			legs:     []FareLeg{datedLeg("subway_peak", 0, 1, may7)},
+			wantLegs: [][]string{{"single_ride/cash", "single_ride/card"}},
+		},
+		{
+			desc: "transfer",
+			legs: []FareLeg{leg("subway_off_peak", 0, 2), leg("bus", 0, 1)},
+			wantLegs: [][]string{
+				{"single_ride/cash", "single_ride/card"},
+				{"single_ride/cash", "single_ride/card"},
+			},
+			wantTransfers: []transfer{{0, 1}},
+		},
+		{
+			desc: "transfer count exceeded",
+			legs: []FareLeg{leg("subway_off_peak", 0, 2), leg("bus", 0, 1), leg("bus_2", 0, 1)},
+			wantLegs: [][]string{
+				{"single_ride/cash", "single_ride/card"},
+				{"single_ride/cash", "single_ride/card"},
+				{"single_ride/cash", "single_ride/card"},
+			},
+			wantTransfers: []transfer{{0, 1}},
+		},
+		{
+			desc:     "joined legs",
+			legs:     []FareLeg{leg("subway_off_peak", 0, 1), leg("subway_off_peak_2", 0, 1)},
+			wantLegs: [][]string{{"single_ride/cash", "single_ride/card"}},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			fare := static.CalculateFareV2(tc.legs)
+			var gotLegs [][]string
+			for _, leg := range fare.Legs {
+				var products []string
+				for _, product := range leg.FareProducts {
+					products = append(products, product.Id+"/"+product.Media.Id)
+				}
+				gotLegs = append(gotLegs, products)
+			}
+			if diff := cmp.Diff(tc.wantLegs, gotLegs); diff != "" {
+				t.Errorf("legs not the same: %s", diff)
+			}
+			var gotTransfers []transfer
+			for _, fareTransfer := range fare.Transfers {
+				gotTransfers = append(gotTransfers, transfer{fareTransfer.FromLeg, fareTransfer.ToLeg})
+			}
+			if diff := cmp.Diff(tc.wantTransfers, gotTransfers, cmp.AllowUnexported(transfer{})); diff != "" {
+				t.Errorf("transfers not the same: %s", diff)
+			}
+		})
+	}
+}
+
+func TestCalculateFareV2_TransferRules(t *testing.T) {
+	// Legs at 08:00-08:20, 08:40-09:00 and 09:20-09:40.
+	var legs []FareLeg
+	for i := 0; i < 3; i++ {
+		start := 8*time.Hour + time.Duration(40*i)*time.Minute
+		legs = append(legs, FareLeg{
+			Board:  &ScheduledStopTime{DepartureTime: start, ArrivalTime: start},
+			Alight: &ScheduledStopTime{DepartureTime: start + 20*time.Minute, ArrivalTime: start + 20*time.Minute},
+		})
+	}
+	for _, tc := range []struct {
+		desc              string
+		durationLimitType DurationLimitType
+		durationLimit     time.Duration
+		legs              int
+		wantTransfers     int
+	}{
+		{"departure to arrival", DurationLimitType_DepartureToArrival, 60 * time.Minute, 2, 1},
+		{"departure to arrival exceeded", DurationLimitType_DepartureToArrival, 59 * time.Minute, 2, 0},
+		{"departure to departure", DurationLimitType_DepartureToDeparture, 40 * time.Minute, 2, 1},
+		{"departure to departure exceeded", DurationLimitType_DepartureToDeparture, 39 * time.Minute, 2, 0},
+		{"arrival to departure", DurationLimitType_ArrivalToDeparture, 20 * time.Minute, 2, 1},
+		{"arrival to departure exceeded", DurationLimitType_ArrivalToDeparture, 19 * time.Minute, 2, 0},
+		{"arrival to arrival", DurationLimitType_ArrivalToArrival, 40 * time.Minute, 2, 1},
+		{"arrival to arrival exceeded", DurationLimitType_ArrivalToArrival, 39 * time.Minute, 2, 0},
+		// The limit is measured from the current leg, not from the first leg of the sequence.
+		{"departure to departure, consecutive transfers", DurationLimitType_DepartureToDeparture, 40 * time.Minute, 3, 2},
+		{"arrival to arrival, consecutive transfers", DurationLimitType_ArrivalToArrival, 40 * time.Minute, 3, 2},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			static := &Static{
+				FareLegGroups: []FareLegGroup{{Id: "all"}},
+				FareProducts: []FareProduct{
+					{Id: "transfer", Amount: 1.00, Currency: "USD"},
+					{Id: "transfer", Amount: 0.75, Currency: "USD"},
+				},
+			}
+			static.FareLegRules = []FareLegRule{{LegGroup: &static.FareLegGroups[0]}}
+			durationLimit := tc.durationLimit
+			static.FareTransferRules = []FareTransferRule{
+				{
+					FromLegGroup:      &static.FareLegGroups[0],
+					ToLegGroup:        &static.FareLegGroups[0],
+					DurationLimit:     &durationLimit,
+					DurationLimitType: tc.durationLimitType,
+					FareTransferType:  FareTransferType_FromLegPlusTransfer,
+					FareProducts:      []*FareProduct{&static.FareProducts[0], &static.FareProducts[1]},
+				},
+			}
+
+			fare := static.CalculateFareV2(legs[:tc.legs])
+
+			if got := len(fare.Transfers); got != tc.wantTransfers {
+				t.Fatalf("got %d transfers, want %d", got, tc.wantTransfers)
+			}
+			for _, transfer := range fare.Transfers {
+				if transfer.Amount != 0.75 || transfer.Currency != "USD" {
+					t.Errorf("got transfer cost %v %s, want the cheapest product 0.75 USD", transfer.Amount, transfer.Currency)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package gtfs
+
+import (
+	"time"
+
+	"github.com/jamespfennell/gtfs/csv"
+	"github.com/jamespfennell/gtfs/warnings"
+)
+
+// FeedInfo corresponds to the single row in the feed_info.txt file.
+type FeedInfo struct {
+	PublisherName   string
+	PublisherUrl    string
+	Language        string
+	DefaultLanguage string
+	// First day of the service period covered by the feed, or the zero time if not specified.
+	StartDate time.Time
+	// Last day of the service period covered by the feed, or the zero time if not specified.
+	EndDate      time.Time
+	Version      string
+	ContactEmail string
+	ContactUrl   string
+	Extra        map[string]string
+}
+
+// IsValidOn returns whether the day containing t is within the service period covered by the feed.
+//
+// If the feed does not specify a start or end date, the period is unbounded on that side.
+func (feedInfo *FeedInfo) IsValidOn(t time.Time) bool {
+	if !feedInfo.StartDate.IsZero() && t.Before(feedInfo.StartDate) {
+		return false
+	}
+	if !feedInfo.EndDate.IsZero() && !t.Before(feedInfo.EndDate.AddDate(0, 0, 1)) {
+		return false
+	}
+	return true
+}
+
+// Attribution corresponds to a single row in the attributions.txt file.
+//
+// At most one of Agency, Route and Trip is set. If none is set the attribution applies
+// to the entire feed.
+type Attribution struct {
+	Id               string
+	Agency           *Agency
+	Route            *Route
+	Trip             *ScheduledTrip
+	OrganizationName string
+	IsProducer       bool
+	IsOperator       bool
+	IsAuthority      bool
+	Url              string
+	Email            string
+	Phone            string
+	Extra            map[string]string
+}
+
+func parseFeedInfo(csv *csv.File, timezone *time.Location) (*FeedInfo, []warnings.StaticWarning) {
+	var w []warnings.StaticWarning
+	publisherNameColumn := csv.RequiredColumn("feed_publisher_name")
+	publisherUrlColumn := csv.RequiredColumn("feed_publisher_url")
+	languageColumn := csv.RequiredColumn("feed_lang")
+	defaultLanguageColumn := csv.OptionalColumn("default_lang")
+	startDateColumn := csv.OptionalColumn("feed_start_date")
+	endDateColumn := csv.OptionalColumn("feed_end_date")
+	versionColumn := csv.OptionalColumn("feed_version")
+	contactEmailColumn := csv.OptionalColumn("feed_contact_email")
+	contactUrlColumn := csv.OptionalColumn("feed_contact_url")
+
+	if warnings := checkForMissingColumns(csv); len(warnings) > 0 {
+		return nil, warnings
+	}
+
+	var feedInfo *FeedInfo
+	for csv.NextRow() {
+		row := FeedInfo{
+			PublisherName:   publisherNameColumn.Read(),
+			PublisherUrl:    publisherUrlColumn.Read(),
+			Language:        languageColumn.Read(),
+			DefaultLanguage: defaultLanguageColumn.Read(),
+			Version:         versionColumn.Read(),
+			ContactEmail:    contactEmailColumn.Read(),
+			ContactUrl:      contactUrlColumn.Read(),
+			Extra:           csv.ExtraColumns(),
+		}
+		if missingKeys := csv.MissingRowKeys(); len(missingKeys) > 0 {
+			w = append(w, warnings.NewStaticWarning(csv, warnings.MissingValues{Columns: missingKeys}))
+			continue
+		}
+		if feedInfo != nil {
+			w = append(w, warnings.NewStaticWarning(csv, warnings.MultipleFeedInfoRows{}))
+			continue
+		}
+		for _, c := range []struct {
+			column string
+			value  string
+			out    *time.Time
+		}{
+			{"feed_start_date", startDateColumn.Read(), &row.StartDate},
+			{"feed_end_date", endDateColumn.Read(), &row.EndDate},
+		} {
+			if c.value == "" {
+				continue
+			}
+			date, err := parseTime(c.value, timezone)
+			if err != nil {
+				w = append(w, warnings.NewStaticWarning(csv, warnings.InvalidValue{Column: c.column, Value: c.value}))
+				continue
+			}
+			*c.out = date
+		}
+		feedInfo = &row
+	}
+	return feedInfo, w
+}
+
+func parseAttributions(csv *csv.File, agencies []Agency, routes []Route, trips []ScheduledTrip) ([]Attribution, []warnings.StaticWarning) {
+	var w []warnings.StaticWarning
+	idColumn := csv.OptionalColumn("attribution_id")
+	agencyIDColumn := csv.OptionalColumn("agency_id")
+	routeIDColumn := csv.OptionalColumn("route_id")
+	tripIDColumn := csv.OptionalColumn("trip_id")
+	organizationNameColumn := csv.RequiredColumn("organization_name")
+	isProducerColumn := csv.OptionalColumn("is_producer")
+	isOperatorColumn := csv.OptionalColumn("is_operator")
+	isAuthorityColumn := csv.OptionalColumn("is_authority")
+	urlColumn := csv.OptionalColumn("attribution_url")
+	emailColumn := csv.OptionalColumn("attribution_email")
+	phoneColumn := csv.OptionalColumn("attribution_phone")
+
+	if warnings := checkForMissingColumns(csv); len(warnings) > 0 {
+		return nil, warnings
+	}
+
+	idToRoute := map[string]*Route{}
+	for i := range routes {
+		idToRoute[routes[i].Id] = &routes[i]
+	}
+	idToTrip := map[string]*ScheduledTrip{}
+	for i := range trips {
+		idToTrip[trips[i].ID] = &trips[i]
+	}
+	var attributions []Attribution
+	for csv.NextRow() {
+		attribution := Attribution{
+			Id:               idColumn.Read(),
+			OrganizationName: organizationNameColumn.Read(),
+			IsProducer:       isProducerColumn.Read() == "1",
+			IsOperator:       isOperatorColumn.Read() == "1",
+			IsAuthority:      isAuthorityColumn.Read() == "1",
+			Url:              urlColumn.Read(),
+			Email:            emailColumn.Read(),
+			Phone:            phoneColumn.Read(),
+			Extra:            csv.ExtraColumns(),
+		}
+		if missingKeys := csv.MissingRowKeys(); len(missingKeys) > 0 {
+			w = append(w, warnings.NewStaticWarning(csv, warnings.MissingValues{Columns: missingKeys}))
+			continue
+		}
+		agencyID := agencyIDColumn.Read()
+		routeID := routeIDColumn.Read()
+		tripID := tripIDColumn.Read()
+		var numReferences int
+		for _, id := range []string{agencyID, routeID, tripID} {
+			if id != "" {
+				numReferences++
+			}
+		}
+		if numReferences > 1 {
+			w = append(w, warnings.NewStaticWarning(csv, warnings.MutuallyExclusiveColumns{
+				Columns: []string{"agency_id", "route_id", "trip_id"},
+			}))
+			continue
+		}
+		var ok bool
+		switch {
+		case agencyID != "":
+			if attribution.Agency, ok = lookupAgency(agencies, agencyID); !ok {
+				w = append(w, warnings.NewStaticWarning(csv, warnings.InvalidAgencyReference{AgencyID: agencyID}))
+				continue
+			}
+		case routeID != "":
+			if attribution.Route, ok = idToRoute[routeID]; !ok {
+				w = append(w, warnings.NewStaticWarning(csv, warnings.InvalidRouteReference{RouteID: routeID}))
+				continue
+			}
+		case tripID != "":
+			if attribution.Trip, ok = idToTrip[tripID]; !ok {
+				w = append(w, warnings.NewStaticWarning(csv, warnings.InvalidTripReference{TripID: tripID}))
+				continue
+			}
+		}
+		if !attribution.IsProducer && !attribution.IsOperator && !attribution.IsAuthority {
+			w = append(w, warnings.NewStaticWarning(csv, warnings.MissingValues{
+				Columns: []string{"is_producer", "is_operator", "is_authority"},
+			}))
+			continue
+		}
+		attributions = append(attributions, attribution)
+	}
+	return attributions, w
+}
@@ -0,0 +1,161 @@
+package gtfs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jamespfennell/gtfs/constants"
+	"github.com/jamespfennell/gtfs/warnings"
+)
+
+func TestParseFeedInfo(t *testing.T) {
+	for _, tc := range []struct {
+		desc         string
+		content      []byte
+		wantFeedInfo *FeedInfo
+		wantWarnings []warnings.StaticWarning
+	}{
+		{
+			desc: "all fields",
+			content: newZipBuilderWithDefaults().add(
+				"feed_info.txt",
+				"feed_publisher_name,feed_publisher_url,feed_lang,default_lang,feed_start_date,feed_end_date,feed_version,feed_contact_email,feed_contact_url",
+				"Publisher,https://example.com,en,fr,20240101,20241231,v1,a@example.com,https://example.com/contact",
+			).build(),
+			wantFeedInfo: &FeedInfo{
+				PublisherName:   "Publisher",
+				PublisherUrl:    "https://example.com",
+				Language:        "en",
+				DefaultLanguage: "fr",
+				StartDate:       time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+				EndDate:         time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC),
+				Version:         "v1",
+				ContactEmail:    "a@example.com",
+				ContactUrl:      "https://example.com/contact",
+			},
+		},
+		{
+			desc: "multiple rows",
+			content: newZipBuilderWithDefaults().add(
+				"feed_info.txt",
+				"feed_publisher_name,feed_publisher_url,feed_lang,feed_start_date",
+				"Publisher,https://example.com,en,2024",
+				"Other publisher,https://example.com,en,",
+			).build(),
+			wantFeedInfo: &FeedInfo{
+				PublisherName: "Publisher",
+				PublisherUrl:  "https://example.com",
+				Language:      "en",
+			},
+			wantWarnings: []warnings.StaticWarning{
+				{
+					Kind:          warnings.InvalidValue{Column: "feed_start_date", Value: "2024"},
+					File:          "feed_info.txt",
+					RowNumber:     1,
+					RowContent:    []string{"Publisher", "https://example.com", "en", "2024"},
+					HeaderContent: []string{"feed_publisher_name", "feed_publisher_url", "feed_lang", "feed_start_date"},
+				},
+				{
+					Kind:          warnings.MultipleFeedInfoRows{},
+					File:          "feed_info.txt",
+					RowNumber:     2,
+					RowContent:    []string{"Other publisher", "https://example.com", "en", ""},
+					HeaderContent: []string{"feed_publisher_name", "feed_publisher_url", "feed_lang", "feed_start_date"},
+				},
+			},
+		},
+		{
+			desc: "missing but required",
+			content: newZipBuilderWithDefaults().add(
+				"translations.txt",
+				"table_name,field_name,language,translation,record_id",
+				"stops,stop_name,fr,Arrêt,stop_id",
+			).build(),
+			wantWarnings: []warnings.StaticWarning{
+				{
+					Kind: warnings.MissingConditionallyRequiredFile{Reason: "translations.txt is provided"},
+					File: constants.StaticFile("feed_info.txt"),
+				},
+			},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			static, err := ParseStatic(tc.content, ParseStaticOptions{})
+			if err != nil {
+				t.Fatalf("error when parsing: %s", err)
+			}
+			if diff := cmp.Diff(tc.wantFeedInfo, static.FeedInfo); diff != "" {
+				t.Errorf("feed info not the same: %s", diff)
+			}
+			if diff := cmp.Diff(tc.wantWarnings, static.Warnings); diff != "" {
+				t.Errorf("warnings not the same: %s", diff)
+			}
+		})
+	}
+}
+
+func TestFeedInfoIsValidOn(t *testing.T) {
+	feedInfo := FeedInfo{
+		StartDate: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:   time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC),
+	}
+	for _, tc := range []struct {
+		t    time.Time
+		want bool
+	}{
+		{time.Date(2023, 12, 31, 23, 59, 0, 0, time.UTC), false},
+		{time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), true},
+		{time.Date(2024, 1, 31, 23, 59, 0, 0, time.UTC), true},
+		{time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), false},
+	} {
+		if got := feedInfo.IsValidOn(tc.t); got != tc.want {
+			t.Errorf("IsValidOn(%s): got %t, want %t", tc.t, got, tc.want)
+		}
+	}
+	if !(&FeedInfo{}).IsValidOn(time.Now()) {
+		t.Errorf("feed with no dates should always be valid")
+	}
+}
+
+func TestParseAttributions(t *testing.T) {
+	static, err := ParseStatic(newZipBuilderWithDefaults().add(
+		"trips.txt",
+		"route_id,service_id,trip_id",
+		"route_id,service_id,trip_id",
+	).add(
+		"attributions.txt",
+		"attribution_id,agency_id,route_id,trip_id,organization_name,is_producer,is_operator,is_authority,attribution_url",
+		"1,,,,Producer Inc,1,0,0,https://example.com",
+		"2,a,,,Operator Inc,0,1,0,",
+		"3,,route_id,,Route Inc,0,0,1,",
+		"4,,,trip_id,Trip Inc,1,1,1,",
+		"5,a,route_id,,Both Inc,1,0,0,",
+		"6,,,other_trip,Other Inc,1,0,0,",
+		"7,,,,Nobody Inc,0,0,0,",
+	).build(), ParseStaticOptions{})
+	if err != nil {
+		t.Fatalf("error when parsing: %s", err)
+	}
+	wantAttributions := []Attribution{
+		{Id: "1", OrganizationName: "Producer Inc", IsProducer: true, Url: "https://example.com"},
+		{Id: "2", Agency: &static.Agencies[0], OrganizationName: "Operator Inc", IsOperator: true},
+		{Id: "3", Route: &static.Routes[0], OrganizationName: "Route Inc", IsAuthority: true},
+		{Id: "4", Trip: &static.Trips[0], OrganizationName: "Trip Inc", IsProducer: true, IsOperator: true, IsAuthority: true},
+	}
+	if diff := cmp.Diff(wantAttributions, static.Attributions); diff != "" {
+		t.Errorf("attributions not the same: %s", diff)
+	}
+	var gotWarnings []warnings.StaticWarningKind
+	for _, w := range static.Warnings {
+		gotWarnings = append(gotWarnings, w.Kind)
+	}
+	wantWarnings := []warnings.StaticWarningKind{
+		warnings.MutuallyExclusiveColumns{Columns: []string{"agency_id", "route_id", "trip_id"}},
+		warnings.InvalidTripReference{TripID: "other_trip"},
+		warnings.MissingValues{Columns: []string{"is_producer", "is_operator", "is_authority"}},
+	}
+	if diff := cmp.Diff(wantWarnings, gotWarnings); diff != "" {
+		t.Errorf("warnings not the same: %s", diff)
+	}
+}
@@ -0,0 +1,374 @@
+package gtfs
+
+import "time"
+
+type FilterOptions struct {
+	// IDs of the agencies whose routes are kept. If empty, the routes of all agencies are kept.
+	AgencyIDs []string
+
+	// IDs of the routes that are kept. If empty, all routes are kept.
+	RouteIDs []string
+
+	// If set, only trips that stop at least once in the bounding box are kept. The trips keep
+	// all of their stops, including the stops outside of the bounding box.
+	BoundingBox *BoundingBox
+
+	// If set, only trips whose service is active on at least one day between the dates, inclusive,
+	// are kept. A zero start or end date leaves the window open on that side. The days of the dates
+	// are taken in the time zone of the feed, like for Service.IsActiveOn.
+	StartDate time.Time
+	EndDate   time.Time
+}
+
+// BoundingBox is a geographic area between two latitudes and two longitudes.
+type BoundingBox struct {
+	MinLatitude  float64
+	MinLongitude float64
+	MaxLatitude  float64
+	MaxLongitude float64
+}
+
+// containsStop returns whether the stop has coordinates in the bounding box.
+func (b *BoundingBox) containsStop(stop *Stop) bool {
+	if stop == nil || stop.Latitude == nil || stop.Longitude == nil {
+		return false
+	}
+	return b.MinLatitude <= *stop.Latitude && *stop.Latitude <= b.MaxLatitude &&
+		b.MinLongitude <= *stop.Longitude && *stop.Longitude <= b.MaxLongitude
+}
+
+// Filter returns a smaller feed with only the trips selected by the options and the entities they use.
+//
+// The result only contains the routes, services, shapes and stops of the trips that are kept,
+// and the agencies of the routes. Stops are kept with their station and the other stops of the
+// station, like entrances, and transfers, pathways, fares and translations are kept if all of the
+// entities they reference are kept. Fare products, fare media, leg groups and timeframes are only
+// kept if kept fare rules reference them. Entities that are not used by any trip are removed even if
+// no filter is set.
+//
+// Like Merge, stop times are always stored in the ScheduledTrip.StopTimes field and the warnings of
+// the feed are not copied.
+func (static *Static) Filter(opts FilterOptions) *Static {
+	agencyIDs := stringSet(opts.AgencyIDs)
+	routeIDs := stringSet(opts.RouteIDs)
+	selectRoute := func(route *Route) bool {
+		if len(routeIDs) > 0 && (route == nil || !routeIDs[route.Id]) {
+			return false
+		}
+		if len(agencyIDs) > 0 && (route == nil || route.Agency == nil || !agencyIDs[route.Agency.Id]) {
+			return false
+		}
+		return true
+	}
+	filterDates := !opts.StartDate.IsZero() || !opts.EndDate.IsZero()
+	selectService := func(service *Service) bool {
+		if !filterDates {
+			return true
+		}
+		return service != nil && serviceIsActiveBetween(service, opts.StartDate, opts.EndDate)
+	}
+	selectTrip := func(trip *ScheduledTrip) bool {
+		if !selectRoute(trip.Route) || !selectService(trip.Service) {
+			return false
+		}
+		if opts.BoundingBox == nil {
+			return true
+		}
+		for i := 0; i < trip.NumStopTimes(); i++ {
+			if opts.BoundingBox.containsStop(trip.StopTime(i).Stop) {
+				return true
+			}
+		}
+		return false
+	}
+
+	trips := map[*ScheduledTrip]bool{}
+	routes := map[*Route]bool{}
+	services := map[*Service]bool{}
+	shapes := map[*Shape]bool{}
+	usedStops := map[*Stop]bool{}
+	locationGroups := map[*LocationGroup]bool{}
+	locations := map[*Location]bool{}
+	bookingRules := map[*BookingRule]bool{}
+	for i := range static.Trips {
+		trip := &static.Trips[i]
+		if !selectTrip(trip) {
+			continue
+		}
+		trips[trip] = true
+		routes[trip.Route] = true
+		services[trip.Service] = true
+		shapes[trip.Shape] = true
+		for j := 0; j < trip.NumStopTimes(); j++ {
+			stopTime := trip.StopTime(j)
+			usedStops[stopTime.Stop] = true
+			locationGroups[stopTime.LocationGroup] = true
+			locations[stopTime.Location] = true
+			bookingRules[stopTime.PickupBookingRule] = true
+			bookingRules[stopTime.DropOffBookingRule] = true
+		}
+	}
+	for i := range static.LocationGroups {
+		locationGroup := &static.LocationGroups[i]
+		if locationGroups[locationGroup] {
+			for _, stop := range locationGroup.Stops {
+				usedStops[stop] = true
+			}
+		}
+	}
+	for i := range static.BookingRules {
+		bookingRule := &static.BookingRules[i]
+		if bookingRules[bookingRule] {
+			services[bookingRule.PriorNoticeService] = true
+		}
+	}
+	stations := map[*Stop]bool{}
+	for stop := range usedStops {
+		if stop != nil {
+			stations[stop.Root()] = true
+		}
+	}
+	stops := map[*Stop]bool{}
+	levels := map[*Level]bool{}
+	for i := range static.Stops {
+		stop := &static.Stops[i]
+		if stations[stop.Root()] {
+			stops[stop] = true
+			levels[stop.Level] = true
+		}
+	}
+	agencies := map[*Agency]bool{}
+	networks := map[*Network]bool{}
+	for route := range routes {
+		if route != nil {
+			agencies[route.Agency] = true
+			networks[route.Network] = true
+		}
+	}
+	for i := range static.Networks {
+		network := &static.Networks[i]
+		for _, route := range network.Routes {
+			if routes[route] {
+				networks[network] = true
+			}
+		}
+	}
+	areas := map[*Area]bool{}
+	for i := range static.Areas {
+		area := &static.Areas[i]
+		for _, stop := range area.Stops {
+			if stops[stop] {
+				areas[area] = true
+			}
+		}
+	}
+	selectedTimeframes := map[*Timeframe]bool{}
+	for i := range static.Timeframes {
+		timeframe := &static.Timeframes[i]
+		if timeframe.Service == nil || selectService(timeframe.Service) {
+			selectedTimeframes[timeframe] = true
+		}
+	}
+	fareAttributes := map[*FareAttribute]bool{}
+	for i := range static.FareAttributes {
+		fareAttribute := &static.FareAttributes[i]
+		if keptOrNil(agencies, fareAttribute.Agency) {
+			fareAttributes[fareAttribute] = true
+		}
+	}
+	// Fare products, fare media, leg groups and timeframes are kept if the kept leg and transfer
+	// rules reference them.
+	fareLegRules := map[*FareLegRule]bool{}
+	fareLegGroups := map[*FareLegGroup]bool{}
+	fareProducts := map[*FareProduct]bool{}
+	timeframes := map[*Timeframe]bool{}
+	for i := range static.FareLegRules {
+		fareLegRule := &static.FareLegRules[i]
+		if !keptOrNil(networks, fareLegRule.Network) ||
+			!keptOrNil(areas, fareLegRule.FromArea) ||
+			!keptOrNil(areas, fareLegRule.ToArea) ||
+			!anyKept(selectedTimeframes, fareLegRule.FromTimeframes) ||
+			!anyKept(selectedTimeframes, fareLegRule.ToTimeframes) {
+			continue
+		}
+		fareLegRules[fareLegRule] = true
+		fareLegGroups[fareLegRule.LegGroup] = true
+		for _, fareProduct := range fareLegRule.FareProducts {
+			fareProducts[fareProduct] = true
+		}
+		for _, timeframe := range append(append([]*Timeframe{}, fareLegRule.FromTimeframes...), fareLegRule.ToTimeframes...) {
+			if selectedTimeframes[timeframe] {
+				timeframes[timeframe] = true
+				services[timeframe.Service] = true
+			}
+		}
+	}
+	fareTransferRules := map[*FareTransferRule]bool{}
+	for i := range static.FareTransferRules {
+		fareTransferRule := &static.FareTransferRules[i]
+		if keptOrNil(fareLegGroups, fareTransferRule.FromLegGroup) && keptOrNil(fareLegGroups, fareTransferRule.ToLegGroup) {
+			fareTransferRules[fareTransferRule] = true
+			for _, fareProduct := range fareTransferRule.FareProducts {
+				fareProducts[fareProduct] = true
+			}
+		}
+	}
+	fareMedia := map[*FareMedia]bool{}
+	for fareProduct := range fareProducts {
+		if fareProduct != nil {
+			fareMedia[fareProduct.Media] = true
+		}
+	}
+
+	pathways := map[*Pathway]bool{}
+	for i := range static.Pathways {
+		pathway := &static.Pathways[i]
+		if stops[pathway.From] && stops[pathway.To] {
+			pathways[pathway] = true
+		}
+	}
+	attributions := map[*Attribution]bool{}
+	for i := range static.Attributions {
+		attribution := &static.Attributions[i]
+		if keptOrNil(agencies, attribution.Agency) && keptOrNil(routes, attribution.Route) && keptOrNil(trips, attribution.Trip) {
+			attributions[attribution] = true
+		}
+	}
+	// Translations of entities that are not kept are removed.
+	tableNameToIDs := map[string]map[string]bool{
+		"agency":       keptIDs(static.Agencies, agencies, func(a *Agency) string { return a.Id }),
+		"stops":        keptIDs(static.Stops, stops, func(s *Stop) string { return s.Id }),
+		"routes":       keptIDs(static.Routes, routes, func(r *Route) string { return r.Id }),
+		"trips":        keptIDs(static.Trips, trips, func(t *ScheduledTrip) string { return t.ID }),
+		"stop_times":   keptIDs(static.Trips, trips, func(t *ScheduledTrip) string { return t.ID }),
+		"pathways":     keptIDs(static.Pathways, pathways, func(p *Pathway) string { return p.Id }),
+		"levels":       keptIDs(static.Levels, levels, func(l *Level) string { return l.Id }),
+		"attributions": keptIDs(static.Attributions, attributions, func(a *Attribution) string { return a.Id }),
+	}
+
+	t := &staticTables{
+		agencies: filterTable(static.Agencies, func(a *Agency) bool { return agencies[a] },
+			func(a *Agency) *string { return &a.Id }),
+		levels: filterTable(static.Levels, func(l *Level) bool { return levels[l] },
+			func(l *Level) *string { return &l.Id }),
+		stops: filterTable(static.Stops, func(s *Stop) bool { return stops[s] },
+			func(s *Stop) *string { return &s.Id }),
+		areas: filterTable(static.Areas, func(a *Area) bool { return areas[a] },
+			func(a *Area) *string { return &a.Id }),
+		networks: filterTable(static.Networks, func(n *Network) bool { return networks[n] },
+			func(n *Network) *string { return &n.Id }),
+		routes: filterTable(static.Routes, func(r *Route) bool { return routes[r] },
+			func(r *Route) *string { return &r.Id }),
+		services: filterTable(static.Services, func(s *Service) bool { return services[s] },
+			func(s *Service) *string { return &s.Id }),
+		shapes: filterTable(static.Shapes, func(s *Shape) bool { return shapes[s] },
+			func(s *Shape) *string { return &s.ID }),
+		trips: filterTable(static.Trips, func(t *ScheduledTrip) bool { return trips[t] },
+			func(t *ScheduledTrip) *string { return &t.ID }),
+		transfers: filterTable(static.Transfers, func(t *Transfer) bool {
+			return keptOrNil(stops, t.From) && keptOrNil(stops, t.To) &&
+				keptOrNil(routes, t.FromRoute) && keptOrNil(routes, t.ToRoute) &&
+				keptOrNil(trips, t.FromTrip) && keptOrNil(trips, t.ToTrip)
+		}, nil),
+		pathways: filterTable(static.Pathways, func(p *Pathway) bool { return pathways[p] },
+			func(p *Pathway) *string { return &p.Id }),
+		locations: filterTable(static.Locations, func(l *Location) bool { return locations[l] },
+			func(l *Location) *string { return &l.Id }),
+		locationGroups: filterTable(static.LocationGroups, func(g *LocationGroup) bool { return locationGroups[g] },
+			func(g *LocationGroup) *string { return &g.Id }),
+		bookingRules: filterTable(static.BookingRules, func(r *BookingRule) bool { return bookingRules[r] },
+			func(r *BookingRule) *string { return &r.Id }),
+		fareAttributes: filterTable(static.FareAttributes, func(a *FareAttribute) bool { return fareAttributes[a] },
+			func(a *FareAttribute) *string { return &a.Id }),
+		fareRules: filterTable(static.FareRules, func(r *FareRule) bool {
+			return fareAttributes[r.Fare] && keptOrNil(routes, r.Route)
+		}, nil),
+		timeframes: filterTable(static.Timeframes, func(t *Timeframe) bool { return timeframes[t] },
+			func(t *Timeframe) *string { return &t.GroupId }),
+		fareMedia: filterTable(static.FareMedia, func(f *FareMedia) bool { return fareMedia[f] },
+			func(f *FareMedia) *string { return &f.Id }),
+		fareProducts: filterTable(static.FareProducts, func(p *FareProduct) bool { return fareProducts[p] },
+			func(p *FareProduct) *string { return &p.Id }),
+		fareLegGroups: filterTable(static.FareLegGroups, func(g *FareLegGroup) bool { return fareLegGroups[g] },
+			func(g *FareLegGroup) *string { return &g.Id }),
+		fareLegRules: filterTable(static.FareLegRules, func(r *FareLegRule) bool { return fareLegRules[r] }, nil),
+		fareLegJoinRules: filterTable(static.FareLegJoinRules, func(r *FareLegJoinRule) bool {
+			return keptOrNil(networks, r.FromNetwork) && keptOrNil(networks, r.ToNetwork) &&
+				keptOrNil(stops, r.FromStop) && keptOrNil(stops, r.ToStop)
+		}, nil),
+		fareTransferRules: filterTable(static.FareTransferRules, func(r *FareTransferRule) bool { return fareTransferRules[r] }, nil),
+		attributions: filterTable(static.Attributions, func(a *Attribution) bool { return attributions[a] },
+			func(a *Attribution) *string { return &a.Id }),
+		translations: filterTable(static.Translations, func(t *Translation) bool {
+			ids, ok := tableNameToIDs[t.TableName]
+			return !ok || t.RecordId == "" || ids[t.RecordId]
+		}, nil),
+	}
+	return t.build(&merger{}, []*Static{static})
+}
+
+// filterTable returns the entities of the table that are kept.
+func filterTable[T any](table []T, keep func(*T) bool, id func(*T) *string) *mergedTable[T] {
+	t := &mergedTable[T]{
+		id:         id,
+		entityMaps: []map[*T]*T{{}},
+		ids:        []map[string]string{{}},
+	}
+	var kept []int
+	for i := range table {
+		if keep(&table[i]) {
+			kept = append(kept, i)
+		}
+	}
+	t.entities = make([]T, len(kept))
+	t.feeds = make([]int, len(kept))
+	for j, i := range kept {
+		t.entities[j] = table[i]
+		t.entityMaps[0][&table[i]] = &t.entities[j]
+		if id != nil {
+			t.ids[0][*id(&table[i])] = *id(&table[i])
+		}
+	}
+	return t
+}
+
+// serviceIsActiveBetween returns whether the service runs on at least one day between the dates,
+// inclusive. A zero start or end date leaves the window open on that side.
+func serviceIsActiveBetween(service *Service, start, end time.Time) bool {
+	if end.IsZero() {
+		end = time.Date(9999, time.December, 31, 0, 0, 0, 0, service.location())
+	}
+	return len(service.ActiveDates(start, end)) > 0
+}
+
+func stringSet(values []string) map[string]bool {
+	set := map[string]bool{}
+	for _, value := range values {
+		set[value] = true
+	}
+	return set
+}
+
+func keptOrNil[T any](kept map[*T]bool, entity *T) bool {
+	return entity == nil || kept[entity]
+}
+
+// anyKept returns whether at least one of the entities is kept, or there are no entities.
+func anyKept[T any](kept map[*T]bool, entities []*T) bool {
+	for _, entity := range entities {
+		if kept[entity] {
+			return true
+		}
+	}
+	return len(entities) == 0
+}
+
+func keptIDs[T any](entities []T, kept map[*T]bool, id func(*T) string) map[string]bool {
+	ids := map[string]bool{}
+	for i := range entities {
+		if kept[&entities[i]] {
+			ids[id(&entities[i])] = true
+		}
+	}
+	return ids
+}
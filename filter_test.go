@@ -0,0 +1,341 @@
+package gtfs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFilter(t *testing.T) {
+	static := mustParseStatic(t, newZipBuilder().add(
+		"agency.txt",
+		"agency_id,agency_name,agency_url,agency_timezone",
+		"subway,Subway,https://subway.example.com,America/New_York",
+		"bus,Bus,https://bus.example.com,America/New_York",
+	).add(
+		"routes.txt",
+		"route_id,agency_id,route_type",
+		"A,subway,1",
+		"B,subway,1",
+		"M1,bus,3",
+	).add(
+		"stops.txt",
+		"stop_id,location_type,parent_station,stop_lat,stop_lon",
+		"station,1,,40.75,-73.99",
+		"platform,0,station,40.75,-73.99",
+		"entrance,2,station,40.751,-73.99",
+		"uptown,0,,40.80,-73.95",
+		"downtown,0,,40.70,-74.01",
+		"unused,0,,40.71,-74.00",
+	).add(
+		"calendar.txt",
+		"service_id,monday,tuesday,wednesday,thursday,friday,saturday,sunday,start_date,end_date",
+		"weekday,1,1,1,1,1,0,0,20240101,20241231",
+		"weekend,0,0,0,0,0,1,1,20240101,20241231",
+		"summer,1,1,1,1,1,1,1,20240701,20240831",
+	).add(
+		"shapes.txt",
+		"shape_id,shape_pt_lat,shape_pt_lon,shape_pt_sequence",
+		"A_shape,40.75,-73.99,1",
+		"A_shape,40.80,-73.95,2",
+		"B_shape,40.75,-73.99,1",
+		"B_shape,40.70,-74.01,2",
+	).add(
+		"trips.txt",
+		"route_id,service_id,trip_id,shape_id",
+		"A,weekday,A_weekday,A_shape",
+		"A,weekend,A_weekend,A_shape",
+		"B,summer,B_summer,B_shape",
+		"M1,weekday,M1_weekday,",
+	).add(
+		"stop_times.txt",
+		"trip_id,stop_id,stop_sequence,arrival_time,departure_time",
+		"A_weekday,platform,1,08:00:00,08:00:00",
+		"A_weekday,uptown,2,08:10:00,08:10:00",
+		"A_weekend,platform,1,09:00:00,09:00:00",
+		"A_weekend,uptown,2,09:10:00,09:10:00",
+		"B_summer,platform,1,10:00:00,10:00:00",
+		"B_summer,downtown,2,10:10:00,10:10:00",
+		"M1_weekday,downtown,1,11:00:00,11:00:00",
+	).add(
+		"transfers.txt",
+		"from_stop_id,to_stop_id,transfer_type",
+		"platform,downtown,2",
+		"platform,uptown,2",
+	).add(
+		"pathways.txt",
+		"pathway_id,from_stop_id,to_stop_id,pathway_mode,is_bidirectional",
+		"pathway,entrance,platform,1,1",
+	).build())
+	// Dates are taken in the time zone of the feed.
+	newYork := static.Services[0].StartDate.Location()
+
+	for _, tc := range []struct {
+		desc          string
+		opts          FilterOptions
+		wantAgencies  []string
+		wantRoutes    []string
+		wantTrips     []string
+		wantStops     []string
+		wantServices  []string
+		wantShapes    []string
+		wantTransfers int
+		wantPathways  int
+	}{
+		{
+			desc:          "no filter",
+			wantAgencies:  []string{"subway", "bus"},
+			wantRoutes:    []string{"A", "B", "M1"},
+			wantTrips:     []string{"A_weekday", "A_weekend", "B_summer", "M1_weekday"},
+			wantStops:     []string{"station", "platform", "entrance", "uptown", "downtown"},
+			wantServices:  []string{"summer", "weekday", "weekend"},
+			wantShapes:    []string{"A_shape", "B_shape"},
+			wantTransfers: 2,
+			wantPathways:  1,
+		},
+		{
+			desc:          "agency",
+			opts:          FilterOptions{AgencyIDs: []string{"bus"}},
+			wantAgencies:  []string{"bus"},
+			wantRoutes:    []string{"M1"},
+			wantTrips:     []string{"M1_weekday"},
+			wantStops:     []string{"downtown"},
+			wantServices:  []string{"weekday"},
+			wantTransfers: 0,
+			wantPathways:  0,
+		},
+		{
+			desc:          "route",
+			opts:          FilterOptions{RouteIDs: []string{"A"}},
+			wantAgencies:  []string{"subway"},
+			wantRoutes:    []string{"A"},
+			wantTrips:     []string{"A_weekday", "A_weekend"},
+			wantStops:     []string{"station", "platform", "entrance", "uptown"},
+			wantServices:  []string{"weekday", "weekend"},
+			wantShapes:    []string{"A_shape"},
+			wantTransfers: 1,
+			wantPathways:  1,
+		},
+		{
+			desc: "bounding box",
+			opts: FilterOptions{BoundingBox: &BoundingBox{
+				MinLatitude:  40.69,
+				MinLongitude: -74.02,
+				MaxLatitude:  40.71,
+				MaxLongitude: -74.00,
+			}},
+			wantAgencies:  []string{"subway", "bus"},
+			wantRoutes:    []string{"B", "M1"},
+			wantTrips:     []string{"B_summer", "M1_weekday"},
+			wantStops:     []string{"station", "platform", "entrance", "downtown"},
+			wantServices:  []string{"summer", "weekday"},
+			wantShapes:    []string{"B_shape"},
+			wantTransfers: 1,
+			wantPathways:  1,
+		},
+		{
+			desc: "date window",
+			// A weekend in June.
+			opts: FilterOptions{
+				StartDate: time.Date(2024, 6, 1, 0, 0, 0, 0, newYork),
+				EndDate:   time.Date(2024, 6, 2, 0, 0, 0, 0, newYork),
+			},
+			wantAgencies:  []string{"subway"},
+			wantRoutes:    []string{"A"},
+			wantTrips:     []string{"A_weekend"},
+			wantStops:     []string{"station", "platform", "entrance", "uptown"},
+			wantServices:  []string{"weekend"},
+			wantShapes:    []string{"A_shape"},
+			wantTransfers: 1,
+			wantPathways:  1,
+		},
+		{
+			desc: "open date window",
+			opts: FilterOptions{
+				StartDate: time.Date(2024, 8, 1, 0, 0, 0, 0, newYork),
+				AgencyIDs: []string{"subway"},
+				RouteIDs:  []string{"B"},
+			},
+			wantAgencies:  []string{"subway"},
+			wantRoutes:    []string{"B"},
+			wantTrips:     []string{"B_summer"},
+			wantStops:     []string{"station", "platform", "entrance", "downtown"},
+			wantServices:  []string{"summer"},
+			wantShapes:    []string{"B_shape"},
+			wantTransfers: 1,
+			wantPathways:  1,
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			filtered := static.Filter(tc.opts)
+
+			for _, check := range []struct {
+				name string
+				got  []string
+				want []string
+			}{
+				{"agencies", ids(filtered.Agencies, func(a *Agency) string { return a.Id }), tc.wantAgencies},
+				{"routes", ids(filtered.Routes, func(r *Route) string { return r.Id }), tc.wantRoutes},
+				{"trips", ids(filtered.Trips, func(t *ScheduledTrip) string { return t.ID }), tc.wantTrips},
+				{"stops", ids(filtered.Stops, func(s *Stop) string { return s.Id }), tc.wantStops},
+				{"services", ids(filtered.Services, func(s *Service) string { return s.Id }), tc.wantServices},
+				{"shapes", ids(filtered.Shapes, func(s *Shape) string { return s.ID }), tc.wantShapes},
+			} {
+				if !cmp.Equal(check.got, check.want) {
+					t.Errorf("%s: got %v, want %v", check.name, check.got, check.want)
+				}
+			}
+			if got := len(filtered.Transfers); got != tc.wantTransfers {
+				t.Errorf("got %d transfers, want %d", got, tc.wantTransfers)
+			}
+			if got := len(filtered.Pathways); got != tc.wantPathways {
+				t.Errorf("got %d pathways, want %d", got, tc.wantPathways)
+			}
+
+			for i := range filtered.Trips {
+				trip := &filtered.Trips[i]
+				if !containsEntity(filtered.Routes, trip.Route) {
+					t.Errorf("trip %s: route not in filtered feed", trip.ID)
+				}
+				if !containsEntity(filtered.Services, trip.Service) {
+					t.Errorf("trip %s: service not in filtered feed", trip.ID)
+				}
+				for _, stopTime := range trip.StopTimes {
+					if stopTime.Trip != trip || !containsEntity(filtered.Stops, stopTime.Stop) {
+						t.Errorf("trip %s: stop time not linked to filtered feed", trip.ID)
+					}
+				}
+			}
+			for i := range filtered.Stops {
+				if parent := filtered.Stops[i].Parent; parent != nil && !containsEntity(filtered.Stops, parent) {
+					t.Errorf("stop %s: parent not in filtered feed", filtered.Stops[i].Id)
+				}
+			}
+		})
+	}
+}
+
+func TestServiceIsActiveBetween(t *testing.T) {
+	date := func(day int) time.Time {
+		return time.Date(2024, 5, day, 0, 0, 0, 0, time.UTC)
+	}
+	// 2024-05-06 is a Monday. The removed date on 2024-05-31 is outside of the calendar range.
+	service := &Service{
+		Monday:            true,
+		StartDate:         date(1),
+		EndDate:           date(31),
+		AddedDates:        []time.Time{date(1)},
+		RemovedDates:      []time.Time{date(13), date(31)},
+		CalendarStartDate: date(1),
+		CalendarEndDate:   date(20),
+	}
+	for _, tc := range []struct {
+		start, end time.Time
+		want       bool
+	}{
+		{date(1), date(1), true},
+		{date(2), date(5), false},
+		{date(6), date(6), true},
+		{date(13), date(13), false},
+		{date(12), date(14), false},
+		{date(14), date(20), true},
+		{date(21), date(31), false},
+		{time.Time{}, date(1), true},
+		{date(28), time.Time{}, false},
+		{time.Time{}, time.Time{}, true},
+	} {
+		if got := serviceIsActiveBetween(service, tc.start, tc.end); got != tc.want {
+			t.Errorf("serviceIsActiveBetween(%s, %s) = %t, want %t", tc.start, tc.end, got, tc.want)
+		}
+	}
+}
+
+func TestFilter_FaresV2(t *testing.T) {
+	static := mustParseStatic(t, newZipBuilder().add(
+		"agency.txt",
+		"agency_id,agency_name,agency_url,agency_timezone",
+		"agency,Agency,https://example.com,America/New_York",
+	).add(
+		"routes.txt",
+		"route_id,agency_id,route_type,network_id",
+		"A,agency,1,subway",
+		"M1,agency,3,bus",
+	).add(
+		"stops.txt",
+		"stop_id",
+		"stop",
+	).add(
+		"calendar.txt",
+		"service_id,monday,tuesday,wednesday,thursday,friday,saturday,sunday,start_date,end_date",
+		"weekday,1,1,1,1,1,0,0,20240101,20241231",
+	).add(
+		"trips.txt",
+		"route_id,service_id,trip_id",
+		"A,weekday,A_trip",
+		"M1,weekday,M1_trip",
+	).add(
+		"stop_times.txt",
+		"trip_id,stop_id,stop_sequence,arrival_time,departure_time",
+		"A_trip,stop,1,08:00:00,08:00:00",
+		"M1_trip,stop,1,09:00:00,09:00:00",
+	).add(
+		"timeframes.txt",
+		"timeframe_group_id,start_time,end_time,service_id",
+		"peak,07:00:00,09:00:00,weekday",
+	).add(
+		"fare_media.txt",
+		"fare_media_id,fare_media_name,fare_media_type",
+		"card,Card,2",
+		"paper,Paper,1",
+	).add(
+		"fare_products.txt",
+		"fare_product_id,fare_product_name,fare_media_id,amount,currency",
+		"subway_ride,Subway ride,card,2.90,USD",
+		"bus_ride,Bus ride,paper,2.50,USD",
+		"transfer,Transfer,card,1.00,USD",
+		"unused,Unused,card,5.00,USD",
+	).add(
+		"fare_leg_rules.txt",
+		"leg_group_id,network_id,from_timeframe_group_id,fare_product_id",
+		"subway,subway,,subway_ride",
+		"bus,bus,peak,bus_ride",
+	).add(
+		"fare_transfer_rules.txt",
+		"from_leg_group_id,to_leg_group_id,fare_transfer_type,fare_product_id",
+		"subway,subway,0,",
+		"subway,bus,0,transfer",
+	).build())
+
+	filtered := static.Filter(FilterOptions{RouteIDs: []string{"A"}})
+
+	for _, check := range []struct {
+		name string
+		got  []string
+		want []string
+	}{
+		{"fare media", ids(filtered.FareMedia, func(m *FareMedia) string { return m.Id }), []string{"card"}},
+		{"fare products", ids(filtered.FareProducts, func(p *FareProduct) string { return p.Id }), []string{"subway_ride"}},
+		{"fare leg groups", ids(filtered.FareLegGroups, func(g *FareLegGroup) string { return g.Id }), []string{"subway"}},
+		{"fare transfer rules", ids(filtered.FareTransferRules, func(r *FareTransferRule) string {
+			return r.FromLegGroup.Id + "->" + r.ToLegGroup.Id
+		}), []string{"subway->subway"}},
+		{"timeframes", ids(filtered.Timeframes, func(t *Timeframe) string { return t.GroupId }), nil},
+	} {
+		if !cmp.Equal(check.got, check.want) {
+			t.Errorf("%s: got %v, want %v", check.name, check.got, check.want)
+		}
+	}
+	if got := len(filtered.FareLegRules); got != 1 {
+		t.Errorf("got %d fare leg rules, want 1", got)
+	}
+}
+
+func containsEntity[T any](entities []T, entity *T) bool {
+	for i := range entities {
+		if &entities[i] == entity {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,390 @@
+package gtfs
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/jamespfennell/gtfs/constants"
+	"github.com/jamespfennell/gtfs/csv"
+	"github.com/jamespfennell/gtfs/warnings"
+)
+
+// Location corresponds to a single feature in the locations.geojson file.
+//
+// A location is a zone in which riders can request pickup or drop off by a
+// demand-responsive service.
+type Location struct {
+	Id          string
+	Name        string
+	Description string
+	// Polygons making up the zone. A GeoJSON Polygon results in a single polygon
+	// and a GeoJSON MultiPolygon in one polygon for each of its members.
+	Polygons []Polygon
+}
+
+// Polygon is a GeoJSON polygon. The first ring is the exterior boundary of the polygon
+// and any subsequent rings are holes in the polygon.
+type Polygon [][]Point
+
+// Point is a position in WGS84 coordinates.
+type Point struct {
+	Latitude  float64
+	Longitude float64
+}
+
+// Contains returns whether the point is inside the location.
+func (location *Location) Contains(point Point) bool {
+	for _, polygon := range location.Polygons {
+		if polygon.Contains(point) {
+			return true
+		}
+	}
+	return false
+}
+
+// Contains returns whether the point is inside the polygon.
+func (polygon Polygon) Contains(point Point) bool {
+	if len(polygon) == 0 || !ringContains(polygon[0], point) {
+		return false
+	}
+	for _, hole := range polygon[1:] {
+		if ringContains(hole, point) {
+			return false
+		}
+	}
+	return true
+}
+
+// ringContains implements the ray casting algorithm, treating coordinates as planar.
+func ringContains(ring []Point, point Point) bool {
+	inside := false
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		a, b := ring[i], ring[j]
+		if (a.Latitude > point.Latitude) == (b.Latitude > point.Latitude) {
+			continue
+		}
+		crossing := (b.Longitude-a.Longitude)*(point.Latitude-a.Latitude)/(b.Latitude-a.Latitude) + a.Longitude
+		if point.Longitude < crossing {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// LocationGroup corresponds to a single row in the location_groups.txt file.
+type LocationGroup struct {
+	Id   string
+	Name string
+	// Stops in the group, as specified in the location_group_stops.txt file.
+	Stops []*Stop
+	Extra map[string]string
+}
+
+// BookingRule corresponds to a single row in the booking_rules.txt file.
+type BookingRule struct {
+	Id   string
+	Type BookingType
+	// Minimum and maximum time between the booking and the pickup. Only set for same-day booking.
+	PriorNoticeDurationMin *time.Duration
+	PriorNoticeDurationMax *time.Duration
+	// Number of days before the travel day by which the booking must be made, and the time
+	// on that day. Only set for prior-day booking.
+	PriorNoticeLastDay  *int32
+	PriorNoticeLastTime *time.Duration
+	// Earliest day and time on that day that a booking can be made.
+	PriorNoticeStartDay  *int32
+	PriorNoticeStartTime *time.Duration
+	// Service whose active days are used to count the prior notice days. If nil, calendar days are used.
+	PriorNoticeService *Service
+	Message            string
+	PickupMessage      string
+	DropOffMessage     string
+	PhoneNumber        string
+	InfoUrl            string
+	BookingUrl         string
+	Extra              map[string]string
+}
+
+type geoJSONFeatureCollection struct {
+	Type     string           `json:"type"`
+	Features []geoJSONFeature `json:"features"`
+}
+
+type geoJSONFeature struct {
+	Id         string `json:"id"`
+	Properties struct {
+		StopName string `json:"stop_name"`
+		StopDesc string `json:"stop_desc"`
+	} `json:"properties"`
+	Geometry struct {
+		Type        string          `json:"type"`
+		Coordinates json.RawMessage `json:"coordinates"`
+	} `json:"geometry"`
+}
+
+func parseLocations(file constants.StaticFile, content io.Reader) ([]Location, []warnings.StaticWarning) {
+	newWarning := func(format string, a ...any) warnings.StaticWarning {
+		return warnings.StaticWarning{
+			Kind: warnings.InvalidGeoJSON{Reason: fmt.Sprintf(format, a...)},
+			File: file,
+		}
+	}
+	var collection geoJSONFeatureCollection
+	if err := json.NewDecoder(content).Decode(&collection); err != nil {
+		return nil, []warnings.StaticWarning{newWarning("%s", err)}
+	}
+	if collection.Type != "FeatureCollection" {
+		return nil, []warnings.StaticWarning{newWarning("expected a FeatureCollection, got %q", collection.Type)}
+	}
+	var w []warnings.StaticWarning
+	var locations []Location
+	for _, feature := range collection.Features {
+		if feature.Id == "" {
+			w = append(w, newWarning("feature has no ID"))
+			continue
+		}
+		var polygons [][][][2]float64
+		var err error
+		switch feature.Geometry.Type {
+		case "Polygon":
+			var polygon [][][2]float64
+			err = json.Unmarshal(feature.Geometry.Coordinates, &polygon)
+			polygons = append(polygons, polygon)
+		case "MultiPolygon":
+			err = json.Unmarshal(feature.Geometry.Coordinates, &polygons)
+		default:
+			err = fmt.Errorf("unsupported geometry type %q", feature.Geometry.Type)
+		}
+		if err != nil {
+			w = append(w, newWarning("feature %q: %s", feature.Id, err))
+			continue
+		}
+		location := Location{
+			Id:          feature.Id,
+			Name:        feature.Properties.StopName,
+			Description: feature.Properties.StopDesc,
+		}
+		for _, rawPolygon := range polygons {
+			var polygon Polygon
+			for _, rawRing := range rawPolygon {
+				ring := make([]Point, 0, len(rawRing))
+				for _, coordinates := range rawRing {
+					// GeoJSON positions are longitude first.
+					ring = append(ring, Point{Longitude: coordinates[0], Latitude: coordinates[1]})
+				}
+				polygon = append(polygon, ring)
+			}
+			location.Polygons = append(location.Polygons, polygon)
+		}
+		locations = append(locations, location)
+	}
+	return locations, w
+}
+
+func parseLocationGroups(csv *csv.File) ([]LocationGroup, []warnings.StaticWarning) {
+	var w []warnings.StaticWarning
+	idColumn := csv.RequiredColumn("location_group_id")
+	nameColumn := csv.OptionalColumn("location_group_name")
+
+	if warnings := checkForMissingColumns(csv); len(warnings) > 0 {
+		return nil, warnings
+	}
+
+	var groups []LocationGroup
+	for csv.NextRow() {
+		group := LocationGroup{
+			Id:    idColumn.Read(),
+			Name:  nameColumn.Read(),
+			Extra: csv.ExtraColumns(),
+		}
+		if missingKeys := csv.MissingRowKeys(); len(missingKeys) > 0 {
+			w = append(w, warnings.NewStaticWarning(csv, warnings.MissingValues{Columns: missingKeys}))
+			continue
+		}
+		groups = append(groups, group)
+	}
+	return groups, w
+}
+
+func parseLocationGroupStops(csv *csv.File, groups []LocationGroup, stops []Stop) []warnings.StaticWarning {
+	var w []warnings.StaticWarning
+	groupIDColumn := csv.RequiredColumn("location_group_id")
+	stopIDColumn := csv.RequiredColumn("stop_id")
+
+	if warnings := checkForMissingColumns(csv); len(warnings) > 0 {
+		return warnings
+	}
+
+	idToGroup := map[string]*LocationGroup{}
+	for i := range groups {
+		idToGroup[groups[i].Id] = &groups[i]
+	}
+	idToStop := map[string]*Stop{}
+	for i := range stops {
+		idToStop[stops[i].Id] = &stops[i]
+	}
+	for csv.NextRow() {
+		groupID := groupIDColumn.Read()
+		stopID := stopIDColumn.Read()
+		if missingKeys := csv.MissingRowKeys(); len(missingKeys) > 0 {
+			w = append(w, warnings.NewStaticWarning(csv, warnings.MissingValues{Columns: missingKeys}))
+			continue
+		}
+		group, ok := idToGroup[groupID]
+		if !ok {
+			w = append(w, warnings.NewStaticWarning(csv, warnings.InvalidLocationGroupReference{LocationGroupID: groupID}))
+			continue
+		}
+		stop, ok := idToStop[stopID]
+		if !ok {
+			w = append(w, warnings.NewStaticWarning(csv, warnings.InvalidStopReference{StopID: stopID}))
+			continue
+		}
+		group.Stops = append(group.Stops, stop)
+	}
+	return w
+}
+
+func parseBookingRules(csv *csv.File, services []Service) ([]BookingRule, []warnings.StaticWarning) {
+	var w []warnings.StaticWarning
+	idColumn := csv.RequiredColumn("booking_rule_id")
+	typeColumn := csv.RequiredColumn("booking_type")
+	priorNoticeDurationMinColumn := csv.OptionalColumn("prior_notice_duration_min")
+	priorNoticeDurationMaxColumn := csv.OptionalColumn("prior_notice_duration_max")
+	priorNoticeLastDayColumn := csv.OptionalColumn("prior_notice_last_day")
+	priorNoticeLastTimeColumn := csv.OptionalColumn("prior_notice_last_time")
+	priorNoticeStartDayColumn := csv.OptionalColumn("prior_notice_start_day")
+	priorNoticeStartTimeColumn := csv.OptionalColumn("prior_notice_start_time")
+	priorNoticeServiceIDColumn := csv.OptionalColumn("prior_notice_service_id")
+	messageColumn := csv.OptionalColumn("message")
+	pickupMessageColumn := csv.OptionalColumn("pickup_message")
+	dropOffMessageColumn := csv.OptionalColumn("drop_off_message")
+	phoneNumberColumn := csv.OptionalColumn("phone_number")
+	infoUrlColumn := csv.OptionalColumn("info_url")
+	bookingUrlColumn := csv.OptionalColumn("booking_url")
+
+	if warnings := checkForMissingColumns(csv); len(warnings) > 0 {
+		return nil, warnings
+	}
+
+	idToService := map[string]*Service{}
+	for i := range services {
+		idToService[services[i].Id] = &services[i]
+	}
+	var rules []BookingRule
+rows:
+	for csv.NextRow() {
+		rawType := typeColumn.Read()
+		rule := BookingRule{
+			Id:             idColumn.Read(),
+			Message:        messageColumn.Read(),
+			PickupMessage:  pickupMessageColumn.Read(),
+			DropOffMessage: dropOffMessageColumn.Read(),
+			PhoneNumber:    phoneNumberColumn.Read(),
+			InfoUrl:        infoUrlColumn.Read(),
+			BookingUrl:     bookingUrlColumn.Read(),
+			Extra:          csv.ExtraColumns(),
+		}
+		if missingKeys := csv.MissingRowKeys(); len(missingKeys) > 0 {
+			w = append(w, warnings.NewStaticWarning(csv, warnings.MissingValues{Columns: missingKeys}))
+			continue
+		}
+		var ok bool
+		rule.Type, ok = parseBookingType(rawType)
+		if !ok {
+			w = append(w, warnings.NewStaticWarning(csv, warnings.InvalidValue{Column: "booking_type", Value: rawType}))
+			continue
+		}
+		// Skipping rules with invalid values is safer than loading them without the constraint.
+		for _, c := range []struct {
+			column string
+			value  string
+			out    **int32
+		}{
+			{"prior_notice_last_day", priorNoticeLastDayColumn.Read(), &rule.PriorNoticeLastDay},
+			{"prior_notice_start_day", priorNoticeStartDayColumn.Read(), &rule.PriorNoticeStartDay},
+		} {
+			if c.value == "" {
+				continue
+			}
+			if *c.out = parseInt32(c.value); *c.out == nil {
+				w = append(w, warnings.NewStaticWarning(csv, warnings.InvalidValue{Column: c.column, Value: c.value}))
+				continue rows
+			}
+		}
+		for _, c := range []struct {
+			column string
+			value  string
+			out    **time.Duration
+		}{
+			{"prior_notice_duration_min", priorNoticeDurationMinColumn.Read(), &rule.PriorNoticeDurationMin},
+			{"prior_notice_duration_max", priorNoticeDurationMaxColumn.Read(), &rule.PriorNoticeDurationMax},
+		} {
+			if c.value == "" {
+				continue
+			}
+			minutes := parseInt32(c.value)
+			if minutes == nil {
+				w = append(w, warnings.NewStaticWarning(csv, warnings.InvalidValue{Column: c.column, Value: c.value}))
+				continue rows
+			}
+			d := time.Duration(*minutes) * time.Minute
+			*c.out = &d
+		}
+		for _, c := range []struct {
+			column string
+			value  string
+			out    **time.Duration
+		}{
+			{"prior_notice_last_time", priorNoticeLastTimeColumn.Read(), &rule.PriorNoticeLastTime},
+			{"prior_notice_start_time", priorNoticeStartTimeColumn.Read(), &rule.PriorNoticeStartTime},
+		} {
+			if c.value == "" {
+				continue
+			}
+			d, ok := parseGtfsTimeToDuration(c.value)
+			if !ok {
+				w = append(w, warnings.NewStaticWarning(csv, warnings.InvalidValue{Column: c.column, Value: c.value}))
+				continue rows
+			}
+			*c.out = &d
+		}
+		if serviceID := priorNoticeServiceIDColumn.Read(); serviceID != "" {
+			service, ok := idToService[serviceID]
+			if !ok {
+				w = append(w, warnings.NewStaticWarning(csv, warnings.InvalidServiceReference{ServiceID: serviceID}))
+				continue
+			}
+			rule.PriorNoticeService = service
+		}
+		rules = append(rules, rule)
+	}
+	return rules, w
+}
+
+// flexReferences contains lookup maps for the GTFS-Flex entities that stop times can reference.
+type flexReferences struct {
+	idToLocation      map[string]*Location
+	idToLocationGroup map[string]*LocationGroup
+	idToBookingRule   map[string]*BookingRule
+}
+
+func newFlexReferences(static *Static) *flexReferences {
+	refs := &flexReferences{
+		idToLocation:      map[string]*Location{},
+		idToLocationGroup: map[string]*LocationGroup{},
+		idToBookingRule:   map[string]*BookingRule{},
+	}
+	for i := range static.Locations {
+		refs.idToLocation[static.Locations[i].Id] = &static.Locations[i]
+	}
+	for i := range static.LocationGroups {
+		refs.idToLocationGroup[static.LocationGroups[i].Id] = &static.LocationGroups[i]
+	}
+	for i := range static.BookingRules {
+		refs.idToBookingRule[static.BookingRules[i].Id] = &static.BookingRules[i]
+	}
+	return refs
+}
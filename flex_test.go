@@ -0,0 +1,269 @@
+package gtfs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jamespfennell/gtfs/warnings"
+)
+
+const flexLocationsGeoJSON = `{
+  "type": "FeatureCollection",
+  "features": [
+    {
+      "type": "Feature",
+      "id": "zone_1",
+      "properties": {"stop_name": "Zone 1", "stop_desc": "Downtown"},
+      "geometry": {
+        "type": "Polygon",
+        "coordinates": [
+          [[0, 0], [10, 0], [10, 10], [0, 10], [0, 0]],
+          [[4, 4], [6, 4], [6, 6], [4, 6], [4, 4]]
+        ]
+      }
+    },
+    {
+      "type": "Feature",
+      "id": "zone_2",
+      "properties": {},
+      "geometry": {
+        "type": "MultiPolygon",
+        "coordinates": [
+          [[[20, 20], [21, 20], [21, 21], [20, 20]]],
+          [[[30, 30], [31, 30], [31, 31], [30, 30]]]
+        ]
+      }
+    },
+    {
+      "type": "Feature",
+      "id": "point",
+      "properties": {},
+      "geometry": {"type": "Point", "coordinates": [1, 2]}
+    }
+  ]
+}`
+
+func newFlexZipBuilder() *zipBuilder {
+	return newZipBuilderWithDefaults().add(
+		"stops.txt",
+		"stop_id",
+		"stop_1",
+		"stop_2",
+	).add(
+		"locations.geojson",
+		flexLocationsGeoJSON,
+	).add(
+		"location_groups.txt",
+		"location_group_id,location_group_name",
+		"group_1,Group 1",
+	).add(
+		"location_group_stops.txt",
+		"location_group_id,stop_id",
+		"group_1,stop_1",
+		"group_1,stop_2",
+	).add(
+		"booking_rules.txt",
+		"booking_rule_id,booking_type,prior_notice_duration_min,prior_notice_last_day,prior_notice_last_time,prior_notice_service_id,message,phone_number",
+		"same_day,1,30,,,,Call ahead,555-1234",
+		"prior_day,2,,1,17:00:00,service_id,,",
+	).add(
+		"trips.txt",
+		"route_id,service_id,trip_id",
+		"route_id,service_id,trip_id",
+	).add(
+		"stop_times.txt",
+		"trip_id,stop_sequence,stop_id,location_group_id,location_id,arrival_time,departure_time,start_pickup_drop_off_window,end_pickup_drop_off_window,pickup_booking_rule_id,drop_off_booking_rule_id",
+		"trip_id,1,stop_1,,,08:00:00,08:00:00,,,,",
+		"trip_id,2,,group_1,,,,08:00:00,10:00:00,same_day,prior_day",
+		"trip_id,3,,,zone_1,,,09:00:00,11:00:00,,same_day",
+	)
+}
+
+func TestParseFlex(t *testing.T) {
+	static, err := ParseStatic(newFlexZipBuilder().build(), ParseStaticOptions{})
+	if err != nil {
+		t.Fatalf("error when parsing: %s", err)
+	}
+
+	wantLocations := []Location{
+		{
+			Id:          "zone_1",
+			Name:        "Zone 1",
+			Description: "Downtown",
+			Polygons: []Polygon{
+				{
+					{{0, 0}, {0, 10}, {10, 10}, {10, 0}, {0, 0}},
+					{{4, 4}, {4, 6}, {6, 6}, {6, 4}, {4, 4}},
+				},
+			},
+		},
+		{
+			Id: "zone_2",
+			Polygons: []Polygon{
+				{{{20, 20}, {20, 21}, {21, 21}, {20, 20}}},
+				{{{30, 30}, {30, 31}, {31, 31}, {30, 30}}},
+			},
+		},
+	}
+	if diff := cmp.Diff(wantLocations, static.Locations); diff != "" {
+		t.Errorf("locations not the same: %s", diff)
+	}
+
+	group := &static.LocationGroups[0]
+	if group.Id != "group_1" || len(group.Stops) != 2 || group.Stops[1] != &static.Stops[1] {
+		t.Errorf("unexpected location group: %+v", group)
+	}
+
+	wantBookingRules := []BookingRule{
+		{
+			Id:                     "same_day",
+			Type:                   BookingType_SameDay,
+			PriorNoticeDurationMin: ptr(30 * time.Minute),
+			Message:                "Call ahead",
+			PhoneNumber:            "555-1234",
+		},
+		{
+			Id:                  "prior_day",
+			Type:                BookingType_PriorDays,
+			PriorNoticeLastDay:  ptr(int32(1)),
+			PriorNoticeLastTime: ptr(17 * time.Hour),
+			PriorNoticeService:  &static.Services[0],
+		},
+	}
+	if diff := cmp.Diff(wantBookingRules, static.BookingRules); diff != "" {
+		t.Errorf("booking rules not the same: %s", diff)
+	}
+
+	stopTimes := static.Trips[0].StopTimes
+	if len(stopTimes) != 3 {
+		t.Fatalf("number of stop times: got %d, want 3", len(stopTimes))
+	}
+	if stopTimes[0].Stop != &static.Stops[0] || stopTimes[0].StartPickupDropOffWindow != nil {
+		t.Errorf("unexpected regular stop time: %+v", stopTimes[0])
+	}
+	groupStopTime := stopTimes[1]
+	if groupStopTime.Stop != nil || groupStopTime.LocationGroup != group {
+		t.Errorf("group stop time location: got %+v", groupStopTime)
+	}
+	if diff := cmp.Diff(ptr(8*time.Hour), groupStopTime.StartPickupDropOffWindow); diff != "" {
+		t.Errorf("window start not the same: %s", diff)
+	}
+	if diff := cmp.Diff(ptr(10*time.Hour), groupStopTime.EndPickupDropOffWindow); diff != "" {
+		t.Errorf("window end not the same: %s", diff)
+	}
+	if groupStopTime.PickupBookingRule != &static.BookingRules[0] || groupStopTime.DropOffBookingRule != &static.BookingRules[1] {
+		t.Errorf("group stop time booking rules not resolved")
+	}
+	zoneStopTime := stopTimes[2]
+	if zoneStopTime.Location != &static.Locations[0] || zoneStopTime.PickupBookingRule != nil {
+		t.Errorf("unexpected zone stop time: %+v", zoneStopTime)
+	}
+
+	var gotWarnings []warnings.StaticWarningKind
+	for _, w := range static.Warnings {
+		gotWarnings = append(gotWarnings, w.Kind)
+	}
+	wantWarnings := []warnings.StaticWarningKind{
+		warnings.InvalidGeoJSON{Reason: `feature "point": unsupported geometry type "Point"`},
+	}
+	if diff := cmp.Diff(wantWarnings, gotWarnings); diff != "" {
+		t.Errorf("warnings not the same: %s", diff)
+	}
+}
+
+func TestParseFlex_InvalidStopTimes(t *testing.T) {
+	static, err := ParseStatic(newFlexZipBuilder().add(
+		"stop_times.txt",
+		"trip_id,stop_sequence,stop_id,location_group_id,location_id,start_pickup_drop_off_window,end_pickup_drop_off_window,pickup_booking_rule_id",
+		"trip_id,1,stop_1,group_1,,08:00:00,10:00:00,",
+		"trip_id,2,,group_2,,08:00:00,10:00:00,",
+		"trip_id,3,,,zone_3,08:00:00,10:00:00,",
+		"trip_id,4,,group_1,,08:00:00,10:00:00,no_rule",
+	).add(
+		"locations.geojson",
+		"[]",
+	).build(), ParseStaticOptions{})
+	if err != nil {
+		t.Fatalf("error when parsing: %s", err)
+	}
+	var gotWarnings []warnings.StaticWarningKind
+	for _, w := range static.Warnings {
+		gotWarnings = append(gotWarnings, w.Kind)
+	}
+	wantWarnings := []warnings.StaticWarningKind{
+		warnings.InvalidGeoJSON{Reason: "json: cannot unmarshal array into Go value of type gtfs.geoJSONFeatureCollection"},
+		warnings.MutuallyExclusiveColumns{Columns: []string{"stop_id", "location_group_id", "location_id"}},
+		warnings.InvalidLocationGroupReference{LocationGroupID: "group_2"},
+		warnings.InvalidLocationReference{LocationID: "zone_3"},
+		warnings.InvalidBookingRuleReference{BookingRuleID: "no_rule"},
+	}
+	if diff := cmp.Diff(wantWarnings, gotWarnings); diff != "" {
+		t.Errorf("warnings not the same: %s", diff)
+	}
+	stopTimes := static.Trips[0].StopTimes
+	if len(stopTimes) != 1 || stopTimes[0].StopSequence != 4 || stopTimes[0].PickupBookingRule != nil {
+		t.Errorf("stop times: got %+v, want only the stop time with the invalid booking rule", stopTimes)
+	}
+}
+
+func TestParseFlex_InvalidBookingRules(t *testing.T) {
+	static, err := ParseStatic(newFlexZipBuilder().add(
+		"booking_rules.txt",
+		"booking_rule_id,booking_type,prior_notice_duration_min,prior_notice_duration_max,prior_notice_last_day,prior_notice_last_time",
+		"same_day,1,30,60,,",
+		"prior_day,2,,,1,17:00:00",
+		"bad_type,same_day,,,,",
+		"bad_min,1,30m,,,",
+		"bad_max,1,30,1h,,",
+		"bad_last_day,2,,,one,17:00:00",
+		"bad_last_time,2,,,1,5pm",
+	).build(), ParseStaticOptions{})
+	if err != nil {
+		t.Fatalf("error when parsing: %s", err)
+	}
+	var gotWarnings []warnings.StaticWarningKind
+	for _, w := range static.Warnings {
+		gotWarnings = append(gotWarnings, w.Kind)
+	}
+	wantWarnings := []warnings.StaticWarningKind{
+		warnings.InvalidGeoJSON{Reason: `feature "point": unsupported geometry type "Point"`},
+		warnings.InvalidValue{Column: "booking_type", Value: "same_day"},
+		warnings.InvalidValue{Column: "prior_notice_duration_min", Value: "30m"},
+		warnings.InvalidValue{Column: "prior_notice_duration_max", Value: "1h"},
+		warnings.InvalidValue{Column: "prior_notice_last_day", Value: "one"},
+		warnings.InvalidValue{Column: "prior_notice_last_time", Value: "5pm"},
+	}
+	if diff := cmp.Diff(wantWarnings, gotWarnings); diff != "" {
+		t.Errorf("warnings not the same: %s", diff)
+	}
+	var gotIDs []string
+	for _, rule := range static.BookingRules {
+		gotIDs = append(gotIDs, rule.Id)
+	}
+	if diff := cmp.Diff([]string{"same_day", "prior_day"}, gotIDs); diff != "" {
+		t.Errorf("booking rules not the same: %s", diff)
+	}
+}
+
+func TestLocationContains(t *testing.T) {
+	static, err := ParseStatic(newFlexZipBuilder().build(), ParseStaticOptions{})
+	if err != nil {
+		t.Fatalf("error when parsing: %s", err)
+	}
+	for _, tc := range []struct {
+		location int
+		point    Point
+		want     bool
+	}{
+		{0, Point{Latitude: 1, Longitude: 1}, true},
+		{0, Point{Latitude: 5, Longitude: 5}, false},
+		{0, Point{Latitude: 11, Longitude: 1}, false},
+		{1, Point{Latitude: 30.2, Longitude: 30.8}, true},
+		{1, Point{Latitude: 25, Longitude: 25}, false},
+	} {
+		if got := static.Locations[tc.location].Contains(tc.point); got != tc.want {
+			t.Errorf("location %d contains %+v: got %t, want %t", tc.location, tc.point, got, tc.want)
+		}
+	}
+}
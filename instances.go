@@ -0,0 +1,132 @@
+package gtfs
+
+import (
+	"sort"
+	"time"
+)
+
+// TripInstance is a run of a scheduled trip on a specific day.
+type TripInstance struct {
+	Trip *ScheduledTrip
+	// Service date of the run, as midnight in the time zone of the service. Runs that continue
+	// past midnight, with times after 24:00:00, belong to the service date they start on.
+	Date time.Time
+	// Frequency that the run comes from, or nil if the trip has no frequencies.
+	Frequency *Frequency
+	// Whether the times are exact. They are not exact if the run comes from a frequency with
+	// FrequencyBased exact times, in which case vehicles only run about every headway and the
+	// times are an example of when they run.
+	ExactTimes bool
+	// Arrival and departure times at the stops, in the order of the stop times of the trip, so that
+	// ArrivalTimes[i] is the arrival time of trip.StopTime(i). The times are zero for GTFS-Flex
+	// stop times that specify a pickup/drop-off window instead of arrival and departure times.
+	ArrivalTimes   []time.Time
+	DepartureTimes []time.Time
+}
+
+// TripInstances returns the runs of the trips on the days between the two dates, inclusive.
+//
+// The days of the dates are taken in the time zone of the services, like for Service.IsActiveOn.
+// Each trip whose service is active on a day runs once on that day, or, if it has frequencies, once
+// for each start time of each frequency: from the start time of the frequency, every headway, until
+// before the end time. The times of the stop times of a trip with frequencies are relative to its
+// first departure time.
+//
+// As the GTFS specification says, times are measured from noon minus 12 hours on the service date.
+// This is midnight except on days with daylight saving time changes, so that times of day are
+// correct on those days, and times after 24:00:00 are on the following days.
+//
+// The runs are ordered by date, then in the order of the Trips field, then by start time.
+func (static *Static) TripInstances(from, to time.Time) []TripInstance {
+	// Service dates by service, keyed by the day of the date as midnight UTC.
+	serviceToDates := map[*Service]map[time.Time]time.Time{}
+	var days []time.Time
+	for i := range static.Trips {
+		service := static.Trips[i].Service
+		if _, ok := serviceToDates[service]; ok || service == nil {
+			continue
+		}
+		dates := map[time.Time]time.Time{}
+		for _, date := range service.ActiveDates(from, to) {
+			day := civilDate(date)
+			dates[day] = date
+			days = append(days, day)
+		}
+		serviceToDates[service] = dates
+	}
+	sort.Slice(days, func(i, j int) bool {
+		return days[i].Before(days[j])
+	})
+	var instances []TripInstance
+	for i, day := range days {
+		if i > 0 && days[i-1].Equal(day) {
+			continue
+		}
+		for j := range static.Trips {
+			trip := &static.Trips[j]
+			if date, ok := serviceToDates[trip.Service][day]; ok {
+				instances = append(instances, trip.instances(date)...)
+			}
+		}
+	}
+	return instances
+}
+
+// instances returns the runs of the trip on the service date, which is midnight in the time zone of the service.
+func (trip *ScheduledTrip) instances(date time.Time) []TripInstance {
+	year, month, day := date.Date()
+	reference := time.Date(year, month, day, 12, 0, 0, 0, date.Location()).Add(-12 * time.Hour)
+	if len(trip.Frequencies) == 0 {
+		return []TripInstance{trip.instance(date, reference, nil)}
+	}
+	var firstDepartureTime time.Duration
+	if trip.NumStopTimes() > 0 {
+		firstDepartureTime = trip.StopTime(0).DepartureTime
+	}
+	var instances []TripInstance
+	for i := range trip.Frequencies {
+		frequency := &trip.Frequencies[i]
+		if frequency.Headway <= 0 {
+			continue
+		}
+		for start := frequency.StartTime; start < frequency.EndTime; start += frequency.Headway {
+			instances = append(instances, trip.instance(date, reference.Add(start-firstDepartureTime), frequency))
+		}
+	}
+	sort.SliceStable(instances, func(i, j int) bool {
+		return instances[i].start().Before(instances[j].start())
+	})
+	return instances
+}
+
+// instance returns the run of the trip whose stop time times are measured from the reference time.
+func (trip *ScheduledTrip) instance(date, reference time.Time, frequency *Frequency) TripInstance {
+	n := trip.NumStopTimes()
+	instance := TripInstance{
+		Trip:           trip,
+		Date:           date,
+		Frequency:      frequency,
+		ExactTimes:     frequency == nil || frequency.ExactTimes == ScheduleBased,
+		ArrivalTimes:   make([]time.Time, n),
+		DepartureTimes: make([]time.Time, n),
+	}
+	for i := 0; i < n; i++ {
+		stopTime := trip.StopTime(i)
+		if stopTime.StartPickupDropOffWindow != nil || stopTime.EndPickupDropOffWindow != nil {
+			continue
+		}
+		instance.ArrivalTimes[i] = reference.Add(stopTime.ArrivalTime)
+		instance.DepartureTimes[i] = reference.Add(stopTime.DepartureTime)
+	}
+	return instance
+}
+
+// start returns the first departure time of the run, or the zero time if it has none.
+func (instance *TripInstance) start() time.Time {
+	for _, departureTime := range instance.DepartureTimes {
+		if !departureTime.IsZero() {
+			return departureTime
+		}
+	}
+	return time.Time{}
+}
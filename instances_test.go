@@ -0,0 +1,247 @@
+package gtfs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTripInstances(t *testing.T) {
+	static := mustParseStatic(t, newZipBuilderWithDefaults().add(
+		"agency.txt",
+		"agency_id,agency_name,agency_url,agency_timezone",
+		"agency,Agency,https://example.com,America/New_York",
+	).add(
+		"stops.txt",
+		"stop_id",
+		"stop_1",
+		"stop_2",
+	).add(
+		"calendar.txt",
+		"service_id,monday,tuesday,wednesday,thursday,friday,saturday,sunday,start_date,end_date",
+		"weekday,1,1,1,1,1,0,0,20240101,20241231",
+		"sunday,0,0,0,0,0,0,1,20240101,20241231",
+		"one_week,1,1,1,1,1,0,0,20250106,20250110",
+	).add(
+		"calendar_dates.txt",
+		"service_id,date,exception_type",
+		"may_5,20240505,1",
+		"may_6,20240506,1",
+		"one_week,20250131,2",
+	).add(
+		"trips.txt",
+		"route_id,service_id,trip_id",
+		"route_id,weekday,late_night",
+		"route_id,sunday,sunday_morning",
+		"route_id,may_6,exact_frequency",
+		"route_id,may_5,inexact_frequency",
+		"route_id,one_week,one_week",
+	).add(
+		"stop_times.txt",
+		"trip_id,stop_id,stop_sequence,arrival_time,departure_time",
+		"late_night,stop_1,1,23:50:00,23:50:00",
+		"late_night,stop_2,2,25:30:00,25:30:00",
+		"sunday_morning,stop_1,1,01:30:00,01:30:00",
+		"sunday_morning,stop_2,2,08:00:00,08:00:00",
+		"exact_frequency,stop_1,1,00:00:00,00:00:00",
+		"exact_frequency,stop_2,2,00:10:00,00:12:00",
+		"inexact_frequency,stop_1,1,10:00:00,10:00:00",
+		"inexact_frequency,stop_2,2,10:05:00,10:05:00",
+		"one_week,stop_1,1,07:00:00,07:00:00",
+		"one_week,stop_2,2,07:30:00,07:30:00",
+	).add(
+		"frequencies.txt",
+		"trip_id,start_time,end_time,headway_secs,exact_times",
+		"exact_frequency,06:30:00,07:00:00,900,1",
+		"exact_frequency,06:00:00,06:30:00,1200,1",
+		"inexact_frequency,12:00:00,12:30:00,1800,0",
+	).build())
+	newYork := static.Services[0].StartDate.Location()
+	date := func(month time.Month, day int) time.Time {
+		return time.Date(2024, month, day, 0, 0, 0, 0, newYork)
+	}
+	date2025 := func(month time.Month, day int) time.Time {
+		return time.Date(2025, month, day, 0, 0, 0, 0, newYork)
+	}
+
+	type stopTimes struct {
+		Arrivals   []string
+		Departures []string
+	}
+	type instance struct {
+		Trip       string
+		Date       string
+		ExactTimes bool
+		Times      stopTimes
+	}
+	format := func(times []time.Time) []string {
+		var s []string
+		for _, t := range times {
+			s = append(s, t.Format("2006-01-02 15:04 MST"))
+		}
+		return s
+	}
+	for _, tc := range []struct {
+		desc     string
+		from, to time.Time
+		want     []instance
+	}{
+		{
+			desc: "times after midnight",
+			from: date(time.May, 7),
+			to:   date(time.May, 7),
+			want: []instance{
+				{
+					Trip:       "late_night",
+					Date:       "2024-05-07",
+					ExactTimes: true,
+					Times: stopTimes{
+						Arrivals:   []string{"2024-05-07 23:50 EDT", "2024-05-08 01:30 EDT"},
+						Departures: []string{"2024-05-07 23:50 EDT", "2024-05-08 01:30 EDT"},
+					},
+				},
+			},
+		},
+		{
+			// On the day clocks go forward, noon minus 12 hours is 23:00 of the day before, so
+			// times before 03:00 are an hour earlier on the clock and later times are correct.
+			desc: "start of daylight saving time",
+			from: date(time.March, 10),
+			to:   date(time.March, 10),
+			want: []instance{
+				{
+					Trip:       "sunday_morning",
+					Date:       "2024-03-10",
+					ExactTimes: true,
+					Times: stopTimes{
+						Arrivals:   []string{"2024-03-10 00:30 EST", "2024-03-10 08:00 EDT"},
+						Departures: []string{"2024-03-10 00:30 EST", "2024-03-10 08:00 EDT"},
+					},
+				},
+			},
+		},
+		{
+			// On the day clocks go back, noon minus 12 hours is 01:00.
+			desc: "end of daylight saving time",
+			from: date(time.November, 3),
+			to:   date(time.November, 3),
+			want: []instance{
+				{
+					Trip:       "sunday_morning",
+					Date:       "2024-11-03",
+					ExactTimes: true,
+					Times: stopTimes{
+						Arrivals:   []string{"2024-11-03 01:30 EST", "2024-11-03 08:00 EST"},
+						Departures: []string{"2024-11-03 01:30 EST", "2024-11-03 08:00 EST"},
+					},
+				},
+			},
+		},
+		{
+			// The removed date is outside of the calendar.txt range, and doesn't extend it.
+			desc: "removed date outside of calendar range",
+			from: date2025(time.January, 10),
+			to:   date2025(time.January, 31),
+			want: []instance{
+				{
+					Trip:       "one_week",
+					Date:       "2025-01-10",
+					ExactTimes: true,
+					Times: stopTimes{
+						Arrivals:   []string{"2025-01-10 07:00 EST", "2025-01-10 07:30 EST"},
+						Departures: []string{"2025-01-10 07:00 EST", "2025-01-10 07:30 EST"},
+					},
+				},
+			},
+		},
+		{
+			desc: "frequencies",
+			from: date(time.May, 5),
+			to:   date(time.May, 6),
+			want: []instance{
+				{
+					Trip:       "sunday_morning",
+					Date:       "2024-05-05",
+					ExactTimes: true,
+					Times: stopTimes{
+						Arrivals:   []string{"2024-05-05 01:30 EDT", "2024-05-05 08:00 EDT"},
+						Departures: []string{"2024-05-05 01:30 EDT", "2024-05-05 08:00 EDT"},
+					},
+				},
+				{
+					Trip:       "inexact_frequency",
+					Date:       "2024-05-05",
+					ExactTimes: false,
+					Times: stopTimes{
+						Arrivals:   []string{"2024-05-05 12:00 EDT", "2024-05-05 12:05 EDT"},
+						Departures: []string{"2024-05-05 12:00 EDT", "2024-05-05 12:05 EDT"},
+					},
+				},
+				{
+					Trip:       "late_night",
+					Date:       "2024-05-06",
+					ExactTimes: true,
+					Times: stopTimes{
+						Arrivals:   []string{"2024-05-06 23:50 EDT", "2024-05-07 01:30 EDT"},
+						Departures: []string{"2024-05-06 23:50 EDT", "2024-05-07 01:30 EDT"},
+					},
+				},
+				{
+					Trip:       "exact_frequency",
+					Date:       "2024-05-06",
+					ExactTimes: true,
+					Times: stopTimes{
+						Arrivals:   []string{"2024-05-06 06:00 EDT", "2024-05-06 06:10 EDT"},
+						Departures: []string{"2024-05-06 06:00 EDT", "2024-05-06 06:12 EDT"},
+					},
+				},
+				{
+					Trip:       "exact_frequency",
+					Date:       "2024-05-06",
+					ExactTimes: true,
+					Times: stopTimes{
+						Arrivals:   []string{"2024-05-06 06:20 EDT", "2024-05-06 06:30 EDT"},
+						Departures: []string{"2024-05-06 06:20 EDT", "2024-05-06 06:32 EDT"},
+					},
+				},
+				{
+					Trip:       "exact_frequency",
+					Date:       "2024-05-06",
+					ExactTimes: true,
+					Times: stopTimes{
+						Arrivals:   []string{"2024-05-06 06:30 EDT", "2024-05-06 06:40 EDT"},
+						Departures: []string{"2024-05-06 06:30 EDT", "2024-05-06 06:42 EDT"},
+					},
+				},
+				{
+					Trip:       "exact_frequency",
+					Date:       "2024-05-06",
+					ExactTimes: true,
+					Times: stopTimes{
+						Arrivals:   []string{"2024-05-06 06:45 EDT", "2024-05-06 06:55 EDT"},
+						Departures: []string{"2024-05-06 06:45 EDT", "2024-05-06 06:57 EDT"},
+					},
+				},
+			},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			var got []instance
+			for _, tripInstance := range static.TripInstances(tc.from, tc.to) {
+				got = append(got, instance{
+					Trip:       tripInstance.Trip.ID,
+					Date:       tripInstance.Date.Format("2006-01-02"),
+					ExactTimes: tripInstance.ExactTimes,
+					Times: stopTimes{
+						Arrivals:   format(tripInstance.ArrivalTimes),
+						Departures: format(tripInstance.DepartureTimes),
+					},
+				})
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("trip instances not the same: %s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,20 @@
+// Package geo contains geographic calculations shared by the packages of the module.
+package geo
+
+import "math"
+
+// Mean radius of the Earth in meters.
+const EarthRadius = 6371000
+
+// Distance returns the great-circle distance in meters between two points, using the haversine formula.
+func Distance(lat1, lon1, lat2, lon2 float64) float64 {
+	phi1, phi2 := Radians(lat1), Radians(lat2)
+	dPhi, dLambda := Radians(lat2-lat1), Radians(lon2-lon1)
+	h := math.Sin(dPhi/2)*math.Sin(dPhi/2) + math.Cos(phi1)*math.Cos(phi2)*math.Sin(dLambda/2)*math.Sin(dLambda/2)
+	return 2 * EarthRadius * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// Radians converts an angle in degrees to radians.
+func Radians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
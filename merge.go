@@ -0,0 +1,536 @@
+package gtfs
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// MergeCollision describes what Merge does when two feeds contain different entities with the same ID.
+type MergeCollision int32
+
+const (
+	// Merging fails with an error.
+	MergeCollision_Error MergeCollision = 0
+	// The entity of the earlier feed is kept. The entity of the later feed is dropped and
+	// references to it are redirected to the entity that is kept.
+	MergeCollision_KeepFirst MergeCollision = 1
+	// The entity of the later feed is kept with a new ID, made by appending an underscore
+	// and a number to its ID.
+	MergeCollision_Rename MergeCollision = 2
+)
+
+type MergeOptions struct {
+	// Prefixes that are prepended to the IDs of the entities of each feed, in the same order as
+	// the feeds. Zone IDs and block IDs are prefixed too, because they are only meaningful within
+	// a feed. If nil, IDs are not changed.
+	Prefixes []string
+
+	// What to do when two feeds contain different entities with the same ID, after prefixing.
+	Collision MergeCollision
+}
+
+// Merge merges the feeds into a single feed.
+//
+// Agencies and stops that are identical in two feeds, including their ID before prefixing, are
+// kept once, in the form of the earlier feed. This is useful when feeds of the same operator share
+// stations or the agency.
+//
+// All references between the entities of the merged feed point to entities of the merged feed,
+// and the record IDs of translations are updated with the new IDs. Stop times are always stored in
+// the ScheduledTrip.StopTimes field, even if the feeds were parsed with the CompactStopTimes option.
+// The feed info of the merged feed is the one of the first feed that has one, and the warnings of
+// the feeds are not copied.
+//
+// The merged feed shares strings, maps and slices of values, like the points of shapes, with the feeds.
+func Merge(opts MergeOptions, feeds ...*Static) (*Static, error) {
+	if opts.Prefixes != nil && len(opts.Prefixes) != len(feeds) {
+		return nil, fmt.Errorf("got %d prefixes for %d feeds", len(opts.Prefixes), len(feeds))
+	}
+	m := &merger{opts: opts}
+
+	t := &staticTables{
+		agencies: mergeTable(m, "agency_id", tablesOf(feeds, func(s *Static) []Agency { return s.Agencies }),
+			func(a *Agency) *string { return &a.Id }, identicalAgencies),
+		levels: mergeTable(m, "level_id", tablesOf(feeds, func(s *Static) []Level { return s.Levels }),
+			func(l *Level) *string { return &l.Id }, nil),
+		stops: mergeTable(m, "stop_id", tablesOf(feeds, func(s *Static) []Stop { return s.Stops }),
+			func(s *Stop) *string { return &s.Id }, identicalStops),
+		areas: mergeTable(m, "area_id", tablesOf(feeds, func(s *Static) []Area { return s.Areas }),
+			func(a *Area) *string { return &a.Id }, nil),
+		networks: mergeTable(m, "network_id", tablesOf(feeds, func(s *Static) []Network { return s.Networks }),
+			func(n *Network) *string { return &n.Id }, nil),
+		routes: mergeTable(m, "route_id", tablesOf(feeds, func(s *Static) []Route { return s.Routes }),
+			func(r *Route) *string { return &r.Id }, nil),
+		services: mergeTable(m, "service_id", tablesOf(feeds, func(s *Static) []Service { return s.Services }),
+			func(s *Service) *string { return &s.Id }, nil),
+		shapes: mergeTable(m, "shape_id", tablesOf(feeds, func(s *Static) []Shape { return s.Shapes }),
+			func(s *Shape) *string { return &s.ID }, nil),
+		trips: mergeTable(m, "trip_id", tablesOf(feeds, func(s *Static) []ScheduledTrip { return s.Trips }),
+			func(t *ScheduledTrip) *string { return &t.ID }, nil),
+		transfers: mergeTable(m, "", tablesOf(feeds, func(s *Static) []Transfer { return s.Transfers }), nil, nil),
+		pathways: mergeTable(m, "pathway_id", tablesOf(feeds, func(s *Static) []Pathway { return s.Pathways }),
+			func(p *Pathway) *string { return &p.Id }, nil),
+		locations: mergeTable(m, "location_id", tablesOf(feeds, func(s *Static) []Location { return s.Locations }),
+			func(l *Location) *string { return &l.Id }, nil),
+		locationGroups: mergeTable(m, "location_group_id", tablesOf(feeds, func(s *Static) []LocationGroup { return s.LocationGroups }),
+			func(g *LocationGroup) *string { return &g.Id }, nil),
+		bookingRules: mergeTable(m, "booking_rule_id", tablesOf(feeds, func(s *Static) []BookingRule { return s.BookingRules }),
+			func(r *BookingRule) *string { return &r.Id }, nil),
+		fareAttributes: mergeTable(m, "fare_id", tablesOf(feeds, func(s *Static) []FareAttribute { return s.FareAttributes }),
+			func(a *FareAttribute) *string { return &a.Id }, nil),
+		fareRules: mergeTable(m, "", tablesOf(feeds, func(s *Static) []FareRule { return s.FareRules }), nil, nil),
+		timeframes: mergeTable(m, "timeframe_group_id", tablesOf(feeds, func(s *Static) []Timeframe { return s.Timeframes }),
+			func(t *Timeframe) *string { return &t.GroupId }, nil),
+		fareMedia: mergeTable(m, "fare_media_id", tablesOf(feeds, func(s *Static) []FareMedia { return s.FareMedia }),
+			func(f *FareMedia) *string { return &f.Id }, nil),
+		fareProducts: mergeTable(m, "fare_product_id", tablesOf(feeds, func(s *Static) []FareProduct { return s.FareProducts }),
+			func(p *FareProduct) *string { return &p.Id }, nil),
+		fareLegGroups: mergeTable(m, "leg_group_id", tablesOf(feeds, func(s *Static) []FareLegGroup { return s.FareLegGroups }),
+			func(g *FareLegGroup) *string { return &g.Id }, nil),
+		fareLegRules:      mergeTable(m, "", tablesOf(feeds, func(s *Static) []FareLegRule { return s.FareLegRules }), nil, nil),
+		fareLegJoinRules:  mergeTable(m, "", tablesOf(feeds, func(s *Static) []FareLegJoinRule { return s.FareLegJoinRules }), nil, nil),
+		fareTransferRules: mergeTable(m, "", tablesOf(feeds, func(s *Static) []FareTransferRule { return s.FareTransferRules }), nil, nil),
+		attributions: mergeTable(m, "attribution_id", tablesOf(feeds, func(s *Static) []Attribution { return s.Attributions }),
+			func(a *Attribution) *string { return &a.Id }, nil),
+		translations: mergeTable(m, "", tablesOf(feeds, func(s *Static) []Translation { return s.Translations }), nil, nil),
+	}
+	if m.err != nil {
+		return nil, m.err
+	}
+	return t.build(m, feeds), nil
+}
+
+// staticTables contains the tables of a feed that is built from the entities of other feeds,
+// either by merging them or by filtering one of them.
+type staticTables struct {
+	agencies          *mergedTable[Agency]
+	levels            *mergedTable[Level]
+	stops             *mergedTable[Stop]
+	areas             *mergedTable[Area]
+	networks          *mergedTable[Network]
+	routes            *mergedTable[Route]
+	services          *mergedTable[Service]
+	shapes            *mergedTable[Shape]
+	trips             *mergedTable[ScheduledTrip]
+	transfers         *mergedTable[Transfer]
+	pathways          *mergedTable[Pathway]
+	locations         *mergedTable[Location]
+	locationGroups    *mergedTable[LocationGroup]
+	bookingRules      *mergedTable[BookingRule]
+	fareAttributes    *mergedTable[FareAttribute]
+	fareRules         *mergedTable[FareRule]
+	timeframes        *mergedTable[Timeframe]
+	fareMedia         *mergedTable[FareMedia]
+	fareProducts      *mergedTable[FareProduct]
+	fareLegGroups     *mergedTable[FareLegGroup]
+	fareLegRules      *mergedTable[FareLegRule]
+	fareLegJoinRules  *mergedTable[FareLegJoinRule]
+	fareTransferRules *mergedTable[FareTransferRule]
+	attributions      *mergedTable[Attribution]
+	translations      *mergedTable[Translation]
+}
+
+// build links the entities of the tables to each other and returns the feed.
+//
+// The feeds are the feeds that the entities come from.
+func (t *staticTables) build(m *merger, feeds []*Static) *Static {
+	for i := range t.stops.entities {
+		stop := &t.stops.entities[i]
+		feed := t.stops.feeds[i]
+		stop.ZoneId = m.prefix(feed, stop.ZoneId)
+		stop.Parent = t.stops.get(feed, stop.Parent)
+		stop.Level = t.levels.get(feed, stop.Level)
+		stop.Areas = remapAll(t.areas, feed, stop.Areas)
+	}
+	for i := range t.areas.entities {
+		area := &t.areas.entities[i]
+		area.Stops = remapAll(t.stops, t.areas.feeds[i], area.Stops)
+		// A stop that is kept once for several feeds is in the areas of all of the feeds.
+		for _, stop := range area.Stops {
+			if !contains(stop.Areas, area) {
+				stop.Areas = append(stop.Areas, area)
+			}
+		}
+	}
+	for i := range t.networks.entities {
+		network := &t.networks.entities[i]
+		network.Routes = remapAll(t.routes, t.networks.feeds[i], network.Routes)
+	}
+	for i := range t.routes.entities {
+		route := &t.routes.entities[i]
+		feed := t.routes.feeds[i]
+		route.Agency = t.agencies.get(feed, route.Agency)
+		route.Network = t.networks.get(feed, route.Network)
+	}
+	for i := range t.trips.entities {
+		trip := &t.trips.entities[i]
+		feed := t.trips.feeds[i]
+		trip.Route = t.routes.get(feed, trip.Route)
+		trip.Service = t.services.get(feed, trip.Service)
+		trip.Shape = t.shapes.get(feed, trip.Shape)
+		trip.BlockID = m.prefix(feed, trip.BlockID)
+		var stopTimes []ScheduledStopTime
+		for j := 0; j < trip.NumStopTimes(); j++ {
+			stopTime := trip.StopTime(j)
+			stopTime.Trip = trip
+			stopTime.Stop = t.stops.get(feed, stopTime.Stop)
+			stopTime.LocationGroup = t.locationGroups.get(feed, stopTime.LocationGroup)
+			stopTime.Location = t.locations.get(feed, stopTime.Location)
+			stopTime.PickupBookingRule = t.bookingRules.get(feed, stopTime.PickupBookingRule)
+			stopTime.DropOffBookingRule = t.bookingRules.get(feed, stopTime.DropOffBookingRule)
+			stopTimes = append(stopTimes, stopTime)
+		}
+		trip.StopTimes = stopTimes
+		trip.CompactStopTimes = nil
+	}
+	for i := range t.transfers.entities {
+		transfer := &t.transfers.entities[i]
+		feed := t.transfers.feeds[i]
+		transfer.From = t.stops.get(feed, transfer.From)
+		transfer.To = t.stops.get(feed, transfer.To)
+		transfer.FromRoute = t.routes.get(feed, transfer.FromRoute)
+		transfer.ToRoute = t.routes.get(feed, transfer.ToRoute)
+		transfer.FromTrip = t.trips.get(feed, transfer.FromTrip)
+		transfer.ToTrip = t.trips.get(feed, transfer.ToTrip)
+	}
+	for i := range t.pathways.entities {
+		pathway := &t.pathways.entities[i]
+		feed := t.pathways.feeds[i]
+		pathway.From = t.stops.get(feed, pathway.From)
+		pathway.To = t.stops.get(feed, pathway.To)
+	}
+	for i := range t.locationGroups.entities {
+		locationGroup := &t.locationGroups.entities[i]
+		locationGroup.Stops = remapAll(t.stops, t.locationGroups.feeds[i], locationGroup.Stops)
+	}
+	for i := range t.bookingRules.entities {
+		bookingRule := &t.bookingRules.entities[i]
+		bookingRule.PriorNoticeService = t.services.get(t.bookingRules.feeds[i], bookingRule.PriorNoticeService)
+	}
+	for i := range t.fareAttributes.entities {
+		fareAttribute := &t.fareAttributes.entities[i]
+		fareAttribute.Agency = t.agencies.get(t.fareAttributes.feeds[i], fareAttribute.Agency)
+	}
+	for i := range t.fareRules.entities {
+		fareRule := &t.fareRules.entities[i]
+		feed := t.fareRules.feeds[i]
+		fareRule.Fare = t.fareAttributes.get(feed, fareRule.Fare)
+		fareRule.Route = t.routes.get(feed, fareRule.Route)
+		fareRule.OriginZoneId = m.prefix(feed, fareRule.OriginZoneId)
+		fareRule.DestinationZoneId = m.prefix(feed, fareRule.DestinationZoneId)
+		fareRule.ContainsZoneId = m.prefix(feed, fareRule.ContainsZoneId)
+	}
+	for i := range t.timeframes.entities {
+		timeframe := &t.timeframes.entities[i]
+		timeframe.Service = t.services.get(t.timeframes.feeds[i], timeframe.Service)
+	}
+	for i := range t.fareProducts.entities {
+		fareProduct := &t.fareProducts.entities[i]
+		fareProduct.Media = t.fareMedia.get(t.fareProducts.feeds[i], fareProduct.Media)
+	}
+	// Fare leg and transfer rules reference all of the rows of a fare product or timeframe group.
+	fareProductRows := t.fareProducts.rowsByID()
+	timeframeRows := t.timeframes.rowsByID()
+	for i := range t.fareLegGroups.entities {
+		fareLegGroup := &t.fareLegGroups.entities[i]
+		fareLegGroup.Rules = remapAll(t.fareLegRules, t.fareLegGroups.feeds[i], fareLegGroup.Rules)
+	}
+	for i := range t.fareLegRules.entities {
+		fareLegRule := &t.fareLegRules.entities[i]
+		feed := t.fareLegRules.feeds[i]
+		fareLegRule.LegGroup = t.fareLegGroups.get(feed, fareLegRule.LegGroup)
+		fareLegRule.Network = t.networks.get(feed, fareLegRule.Network)
+		fareLegRule.FromArea = t.areas.get(feed, fareLegRule.FromArea)
+		fareLegRule.ToArea = t.areas.get(feed, fareLegRule.ToArea)
+		fareLegRule.FromTimeframes = remapRows(t.timeframes, timeframeRows, feed, fareLegRule.FromTimeframes)
+		fareLegRule.ToTimeframes = remapRows(t.timeframes, timeframeRows, feed, fareLegRule.ToTimeframes)
+		fareLegRule.FareProducts = remapRows(t.fareProducts, fareProductRows, feed, fareLegRule.FareProducts)
+		// A leg group that is kept for several feeds has the rules of all of the feeds.
+		if legGroup := fareLegRule.LegGroup; legGroup != nil && !contains(legGroup.Rules, fareLegRule) {
+			legGroup.Rules = append(legGroup.Rules, fareLegRule)
+		}
+	}
+	for i := range t.fareLegJoinRules.entities {
+		fareLegJoinRule := &t.fareLegJoinRules.entities[i]
+		feed := t.fareLegJoinRules.feeds[i]
+		fareLegJoinRule.FromNetwork = t.networks.get(feed, fareLegJoinRule.FromNetwork)
+		fareLegJoinRule.ToNetwork = t.networks.get(feed, fareLegJoinRule.ToNetwork)
+		fareLegJoinRule.FromStop = t.stops.get(feed, fareLegJoinRule.FromStop)
+		fareLegJoinRule.ToStop = t.stops.get(feed, fareLegJoinRule.ToStop)
+	}
+	for i := range t.fareTransferRules.entities {
+		fareTransferRule := &t.fareTransferRules.entities[i]
+		feed := t.fareTransferRules.feeds[i]
+		fareTransferRule.FromLegGroup = t.fareLegGroups.get(feed, fareTransferRule.FromLegGroup)
+		fareTransferRule.ToLegGroup = t.fareLegGroups.get(feed, fareTransferRule.ToLegGroup)
+		fareTransferRule.FareProducts = remapRows(t.fareProducts, fareProductRows, feed, fareTransferRule.FareProducts)
+	}
+	for i := range t.attributions.entities {
+		attribution := &t.attributions.entities[i]
+		feed := t.attributions.feeds[i]
+		attribution.Agency = t.agencies.get(feed, attribution.Agency)
+		attribution.Route = t.routes.get(feed, attribution.Route)
+		attribution.Trip = t.trips.get(feed, attribution.Trip)
+	}
+	tableNameToIDs := map[string][]map[string]string{
+		"agency":       t.agencies.ids,
+		"stops":        t.stops.ids,
+		"routes":       t.routes.ids,
+		"trips":        t.trips.ids,
+		"stop_times":   t.trips.ids,
+		"pathways":     t.pathways.ids,
+		"levels":       t.levels.ids,
+		"attributions": t.attributions.ids,
+	}
+	for i := range t.translations.entities {
+		translation := &t.translations.entities[i]
+		ids, ok := tableNameToIDs[translation.TableName]
+		if !ok {
+			continue
+		}
+		if id, ok := ids[t.translations.feeds[i]][translation.RecordId]; ok {
+			translation.RecordId = id
+		}
+	}
+
+	merged := &Static{
+		Agencies:          t.agencies.entities,
+		Routes:            t.routes.entities,
+		Stops:             t.stops.entities,
+		Transfers:         t.transfers.entities,
+		Services:          t.services.entities,
+		Trips:             t.trips.entities,
+		Shapes:            t.shapes.entities,
+		FareAttributes:    t.fareAttributes.entities,
+		FareRules:         t.fareRules.entities,
+		Areas:             t.areas.entities,
+		Networks:          t.networks.entities,
+		Levels:            t.levels.entities,
+		Pathways:          t.pathways.entities,
+		Locations:         t.locations.entities,
+		LocationGroups:    t.locationGroups.entities,
+		BookingRules:      t.bookingRules.entities,
+		Translations:      t.translations.entities,
+		Attributions:      t.attributions.entities,
+		Timeframes:        t.timeframes.entities,
+		FareMedia:         t.fareMedia.entities,
+		FareProducts:      t.fareProducts.entities,
+		FareLegGroups:     t.fareLegGroups.entities,
+		FareLegRules:      t.fareLegRules.entities,
+		FareLegJoinRules:  t.fareLegJoinRules.entities,
+		FareTransferRules: t.fareTransferRules.entities,
+	}
+	for _, feed := range feeds {
+		if feed.FeedInfo != nil {
+			feedInfo := *feed.FeedInfo
+			merged.FeedInfo = &feedInfo
+			break
+		}
+	}
+	return merged
+}
+
+type merger struct {
+	opts MergeOptions
+	// First error that occurred while merging.
+	err error
+}
+
+// prefix returns the ID with the prefix of the feed, if the ID is not empty.
+func (m *merger) prefix(feed int, id string) string {
+	if id == "" || m.opts.Prefixes == nil {
+		return id
+	}
+	return m.opts.Prefixes[feed] + id
+}
+
+// mergedTable contains the entities of a table of a merged or filtered feed.
+type mergedTable[T any] struct {
+	entities []T
+	// ID of an entity, or nil if the entities have no ID.
+	id func(*T) *string
+	// Index of the feed that each entity comes from.
+	feeds []int
+	// For each feed, the entity of the merged feed that each entity of the feed is mapped to.
+	entityMaps []map[*T]*T
+	// For each feed, the ID in the merged feed of each ID of the feed.
+	ids []map[string]string
+}
+
+// get returns the entity of the merged feed that the entity of the feed is mapped to, or nil if
+// the entity is nil or not in the feed.
+func (t *mergedTable[T]) get(feed int, entity *T) *T {
+	if entity == nil {
+		return nil
+	}
+	return t.entityMaps[feed][entity]
+}
+
+// mergeTable merges the entities of the same table of each feed.
+//
+// If the id function is nil, the entities have no ID and all of them are kept. Entities of the same
+// feed with the same ID, like the rows of a fare product, are all kept too. An entity with the same
+// ID as an entity of an earlier feed is kept once if the identical function reports that the two
+// are identical, and otherwise is handled according to the collision option.
+func mergeTable[T any](m *merger, column string, tables [][]T, id func(*T) *string, identical func(a, b *T) bool) *mergedTable[T] {
+	t := &mergedTable[T]{id: id}
+	var sources []*T
+	var newIDs []string
+	// Index of the first merged entity with each ID, and the feed it comes from.
+	idToIndex := map[string]int{}
+	originalIDToIndex := map[string]int{}
+	var indices [][]int
+	for feed, table := range tables {
+		feedIndices := make([]int, len(table))
+		ids := map[string]string{}
+		// Index of the merged entity that the entities with each ID are mapped to, for IDs
+		// that are not kept in this feed.
+		reused := map[string]int{}
+		for i := range table {
+			entity := &table[i]
+			var originalID, newID string
+			if id != nil {
+				originalID = *id(entity)
+				newID = m.prefix(feed, originalID)
+			}
+			if j, ok := reused[originalID]; ok {
+				feedIndices[i] = j
+				continue
+			}
+			if previousID, ok := ids[originalID]; ok {
+				newID = previousID
+			} else if originalID != "" {
+				j, inEarlierFeed := originalIDToIndex[originalID]
+				if inEarlierFeed && t.feeds[j] != feed && identical != nil && identical(sources[j], entity) {
+					reused[originalID] = j
+					ids[originalID] = newIDs[j]
+					feedIndices[i] = j
+					continue
+				}
+				if j, ok := idToIndex[newID]; ok && t.feeds[j] != feed {
+					switch m.opts.Collision {
+					case MergeCollision_KeepFirst:
+						reused[originalID] = j
+						ids[originalID] = newID
+						feedIndices[i] = j
+						continue
+					case MergeCollision_Rename:
+						newID = renamed(newID, idToIndex)
+					default:
+						if m.err == nil {
+							m.err = fmt.Errorf("%s %q is used in feeds %d and %d", column, newID, t.feeds[j], feed)
+						}
+					}
+				}
+				ids[originalID] = newID
+			}
+			feedIndices[i] = len(sources)
+			if _, ok := idToIndex[newID]; !ok {
+				idToIndex[newID] = len(sources)
+			}
+			if _, ok := originalIDToIndex[originalID]; !ok {
+				originalIDToIndex[originalID] = len(sources)
+			}
+			sources = append(sources, entity)
+			newIDs = append(newIDs, newID)
+			t.feeds = append(t.feeds, feed)
+		}
+		indices = append(indices, feedIndices)
+		t.ids = append(t.ids, ids)
+	}
+	t.entities = make([]T, len(sources))
+	for i, source := range sources {
+		t.entities[i] = *source
+		if id != nil {
+			*id(&t.entities[i]) = newIDs[i]
+		}
+	}
+	for feed, table := range tables {
+		entityMap := map[*T]*T{}
+		for i := range table {
+			entityMap[&table[i]] = &t.entities[indices[feed][i]]
+		}
+		t.entityMaps = append(t.entityMaps, entityMap)
+	}
+	return t
+}
+
+// renamed returns the ID with an underscore and the smallest number that make it unused.
+func renamed(id string, idToIndex map[string]int) string {
+	for n := 2; ; n++ {
+		candidate := fmt.Sprintf("%s_%d", id, n)
+		if _, ok := idToIndex[candidate]; !ok {
+			return candidate
+		}
+	}
+}
+
+func tablesOf[T any](feeds []*Static, table func(*Static) []T) [][]T {
+	var tables [][]T
+	for _, feed := range feeds {
+		tables = append(tables, table(feed))
+	}
+	return tables
+}
+
+// remapAll returns the entities of the merged feed that the entities of the feed are mapped to,
+// without duplicates.
+func remapAll[T any](t *mergedTable[T], feed int, entities []*T) []*T {
+	if entities == nil {
+		return nil
+	}
+	remapped := make([]*T, 0, len(entities))
+	for _, entity := range entities {
+		if entity := t.get(feed, entity); entity != nil && !contains(remapped, entity) {
+			remapped = append(remapped, entity)
+		}
+	}
+	return remapped
+}
+
+// rowsByID returns the entities of the merged feed with each ID.
+func (t *mergedTable[T]) rowsByID() map[string][]*T {
+	rows := map[string][]*T{}
+	for i := range t.entities {
+		entity := &t.entities[i]
+		rows[*t.id(entity)] = append(rows[*t.id(entity)], entity)
+	}
+	return rows
+}
+
+// remapRows returns all of the rows of the merged feed with the ID of the rows of the feed, which
+// all have the same ID.
+//
+// If an entity with the ID is kept for several feeds, the rows of the feed are mapped to the first
+// row of the entity, so the rows are looked up by ID instead.
+func remapRows[T any](t *mergedTable[T], rows map[string][]*T, feed int, entities []*T) []*T {
+	for _, entity := range entities {
+		if entity := t.get(feed, entity); entity != nil {
+			return rows[*t.id(entity)]
+		}
+	}
+	return nil
+}
+
+func contains[T any](entities []*T, entity *T) bool {
+	for _, e := range entities {
+		if e == entity {
+			return true
+		}
+	}
+	return false
+}
+
+func identicalAgencies(a, b *Agency) bool {
+	return reflect.DeepEqual(*a, *b)
+}
+
+// identicalStops returns whether the stops are identical. Stops are compared by value, and the
+// stops and levels they reference by ID. The areas of the stops are not compared.
+func identicalStops(a, b *Stop) bool {
+	x, y := *a, *b
+	x.Parent, y.Parent = nil, nil
+	x.Level, y.Level = nil, nil
+	x.Areas, y.Areas = nil, nil
+	return reflect.DeepEqual(x, y) && idOf(a.Parent) == idOf(b.Parent) && idOf(a.Level) == idOf(b.Level)
+}
@@ -0,0 +1,241 @@
+package gtfs
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestMerge_SingleFeed(t *testing.T) {
+	testCases := staticTestCases()
+	for _, tc := range []staticTestCase{
+		{desc: "pathways", content: newPathwaysZipBuilder().build()},
+		{desc: "flex", content: newFlexZipBuilder().build()},
+		{desc: "fares v2", content: newFaresV2ZipBuilder().build()},
+	} {
+		testCases = append(testCases, tc)
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			want, err := ParseStatic(tc.content, tc.opts)
+			if err != nil {
+				t.Fatalf("error when parsing: %s", err)
+			}
+			got, err := Merge(MergeOptions{}, want)
+			if err != nil {
+				t.Fatalf("error when merging: %s", err)
+			}
+			opts := []cmp.Option{
+				cmpopts.IgnoreFields(Static{}, "Warnings"),
+				cmpopts.IgnoreFields(ScheduledTrip{}, "StopTimes", "CompactStopTimes"),
+				cmpopts.EquateEmpty(),
+			}
+			if diff := cmp.Diff(want, got, opts...); diff != "" {
+				t.Errorf("merged feed not the same: %s", diff)
+			}
+			for i := range want.Trips {
+				wantTrip, gotTrip := &want.Trips[i], &got.Trips[i]
+				if wantTrip.NumStopTimes() != gotTrip.NumStopTimes() {
+					t.Fatalf("trip %s: got %d stop times, want %d", wantTrip.ID, gotTrip.NumStopTimes(), wantTrip.NumStopTimes())
+				}
+				for j := 0; j < wantTrip.NumStopTimes(); j++ {
+					if diff := cmp.Diff(wantTrip.StopTime(j), gotTrip.StopTime(j), opts...); diff != "" {
+						t.Errorf("trip %s: stop time %d not the same: %s", wantTrip.ID, j, diff)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestMerge(t *testing.T) {
+	subway := mustParseStatic(t, newZipBuilderWithDefaults().add(
+		"agency.txt",
+		"agency_id,agency_name,agency_url,agency_timezone",
+		"mta,MTA,https://mta.info,America/New_York",
+	).add(
+		"routes.txt",
+		"route_id,agency_id,route_type",
+		"A,mta,1",
+	).add(
+		"stops.txt",
+		"stop_id,stop_name,zone_id",
+		"shared,Jamaica,zone_1",
+		"subway_stop,Subway stop,zone_1",
+	).add(
+		"trips.txt",
+		"route_id,service_id,trip_id,block_id",
+		"A,service_id,trip_id,block_1",
+	).add(
+		"stop_times.txt",
+		"trip_id,stop_id,stop_sequence,arrival_time,departure_time",
+		"trip_id,shared,1,08:00:00,08:00:00",
+		"trip_id,subway_stop,2,08:10:00,08:10:00",
+	).add(
+		"transfers.txt",
+		"from_stop_id,to_stop_id,transfer_type",
+		"shared,subway_stop,2",
+	).add(
+		"translations.txt",
+		"table_name,field_name,language,translation,record_id",
+		"stops,stop_name,es,Metro,subway_stop",
+	).build())
+	rail := mustParseStatic(t, newZipBuilderWithDefaults().add(
+		"agency.txt",
+		"agency_id,agency_name,agency_url,agency_timezone",
+		"mta,MTA,https://mta.info,America/New_York",
+	).add(
+		"routes.txt",
+		"route_id,agency_id,route_type",
+		"A,mta,2",
+	).add(
+		"stops.txt",
+		"stop_id,stop_name,zone_id",
+		"shared,Jamaica,zone_1",
+		"rail_stop,Rail stop,zone_1",
+	).add(
+		"trips.txt",
+		"route_id,service_id,trip_id",
+		"A,service_id,trip_id",
+	).add(
+		"stop_times.txt",
+		"trip_id,stop_id,stop_sequence,arrival_time,departure_time",
+		"trip_id,shared,1,09:00:00,09:00:00",
+		"trip_id,rail_stop,2,09:30:00,09:30:00",
+	).build())
+
+	merged, err := Merge(MergeOptions{Prefixes: []string{"subway:", "rail:"}}, subway, rail)
+	if err != nil {
+		t.Fatalf("error when merging: %s", err)
+	}
+
+	if got, want := ids(merged.Agencies, func(a *Agency) string { return a.Id }), []string{"subway:mta"}; !cmp.Equal(got, want) {
+		t.Errorf("agencies: got %v, want %v", got, want)
+	}
+	if got, want := ids(merged.Stops, func(s *Stop) string { return s.Id }), []string{"subway:shared", "subway:subway_stop", "rail:rail_stop"}; !cmp.Equal(got, want) {
+		t.Errorf("stops: got %v, want %v", got, want)
+	}
+	if got, want := ids(merged.Routes, func(r *Route) string { return r.Id }), []string{"subway:A", "rail:A"}; !cmp.Equal(got, want) {
+		t.Errorf("routes: got %v, want %v", got, want)
+	}
+	if got, want := ids(merged.Trips, func(t *ScheduledTrip) string { return t.ID }), []string{"subway:trip_id", "rail:trip_id"}; !cmp.Equal(got, want) {
+		t.Errorf("trips: got %v, want %v", got, want)
+	}
+	if got, want := merged.Stops[0].ZoneId, "subway:zone_1"; got != want {
+		t.Errorf("zone ID: got %q, want %q", got, want)
+	}
+	if got, want := merged.Trips[0].BlockID, "subway:block_1"; got != want {
+		t.Errorf("block ID: got %q, want %q", got, want)
+	}
+	if got, want := merged.Translations[0].RecordId, "subway:subway_stop"; got != want {
+		t.Errorf("translation record ID: got %q, want %q", got, want)
+	}
+
+	for i := range merged.Routes {
+		if merged.Routes[i].Agency != &merged.Agencies[0] {
+			t.Errorf("route %s: agency not in merged feed", merged.Routes[i].Id)
+		}
+	}
+	for i, route := range []*Route{&merged.Routes[0], &merged.Routes[1]} {
+		trip := &merged.Trips[i]
+		if trip.Route != route {
+			t.Errorf("trip %s: got route %s, want %s", trip.ID, trip.Route.Id, route.Id)
+		}
+		if trip.Service != &merged.Services[i] {
+			t.Errorf("trip %s: service not in merged feed", trip.ID)
+		}
+		for _, stopTime := range trip.StopTimes {
+			if stopTime.Trip != trip {
+				t.Errorf("trip %s: stop time does not point to the trip", trip.ID)
+			}
+		}
+		// The shared stop is kept once and both trips stop there.
+		if trip.StopTimes[0].Stop != &merged.Stops[0] {
+			t.Errorf("trip %s: first stop is %s, want the shared stop", trip.ID, trip.StopTimes[0].Stop.Id)
+		}
+	}
+	if got := merged.Trips[1].StopTimes[1].Stop; got != &merged.Stops[2] {
+		t.Errorf("rail trip: second stop not in merged feed")
+	}
+	transfer := merged.Transfers[0]
+	if transfer.From != &merged.Stops[0] || transfer.To != &merged.Stops[1] {
+		t.Errorf("transfer: stops not in merged feed")
+	}
+}
+
+func TestMerge_Collisions(t *testing.T) {
+	a := mustParseStatic(t, newZipBuilderWithDefaults().add(
+		"stops.txt",
+		"stop_id,stop_name",
+		"stop_id,Stop A",
+	).add(
+		"stop_times.txt",
+		"trip_id,stop_id,stop_sequence,arrival_time,departure_time",
+		"trip_id,stop_id,1,08:00:00,08:00:00",
+	).build())
+	b := mustParseStatic(t, newZipBuilderWithDefaults().add(
+		"stops.txt",
+		"stop_id,stop_name",
+		"stop_id,Stop B",
+	).add(
+		"stop_times.txt",
+		"trip_id,stop_id,stop_sequence,arrival_time,departure_time",
+		"trip_id,stop_id,1,09:00:00,09:00:00",
+	).build())
+
+	t.Run("error", func(t *testing.T) {
+		_, err := Merge(MergeOptions{}, a, b)
+		if err == nil {
+			t.Errorf("got no error, want an error")
+		}
+	})
+	t.Run("keep first", func(t *testing.T) {
+		merged, err := Merge(MergeOptions{Collision: MergeCollision_KeepFirst}, a, b)
+		if err != nil {
+			t.Fatalf("error when merging: %s", err)
+		}
+		if got, want := ids(merged.Stops, func(s *Stop) string { return s.Name }), []string{"Stop A"}; !cmp.Equal(got, want) {
+			t.Errorf("stops: got %v, want %v", got, want)
+		}
+		if got, want := len(merged.Trips), 1; got != want {
+			t.Errorf("got %d trips, want %d", got, want)
+		}
+	})
+	t.Run("rename", func(t *testing.T) {
+		merged, err := Merge(MergeOptions{Collision: MergeCollision_Rename}, a, b)
+		if err != nil {
+			t.Fatalf("error when merging: %s", err)
+		}
+		if got, want := ids(merged.Stops, func(s *Stop) string { return s.Id }), []string{"stop_id", "stop_id_2"}; !cmp.Equal(got, want) {
+			t.Errorf("stops: got %v, want %v", got, want)
+		}
+		if got, want := ids(merged.Trips, func(t *ScheduledTrip) string { return t.ID }), []string{"trip_id", "trip_id_2"}; !cmp.Equal(got, want) {
+			t.Errorf("trips: got %v, want %v", got, want)
+		}
+		if got := merged.Trips[1].StopTimes[0].Stop; got != &merged.Stops[1] {
+			t.Errorf("renamed trip: got stop %s, want the renamed stop", got.Id)
+		}
+		// The identical agencies are kept once.
+		if got, want := len(merged.Agencies), 1; got != want {
+			t.Errorf("got %d agencies, want %d", got, want)
+		}
+	})
+}
+
+func mustParseStatic(t *testing.T, content []byte) *Static {
+	t.Helper()
+	static, err := ParseStatic(content, ParseStaticOptions{})
+	if err != nil {
+		t.Fatalf("error when parsing: %s", err)
+	}
+	return static
+}
+
+func ids[T any](entities []T, id func(*T) string) []string {
+	var ids []string
+	for i := range entities {
+		ids = append(ids, id(&entities[i]))
+	}
+	return ids
+}
@@ -0,0 +1,329 @@
+package gtfs
+
+import (
+	"container/heap"
+	"time"
+
+	"github.com/jamespfennell/gtfs/csv"
+	"github.com/jamespfennell/gtfs/warnings"
+)
+
+// Level corresponds to a single row in the levels.txt file.
+type Level struct {
+	Id string
+	// Numeric index of the level. Ground level is 0, levels above ground are positive
+	// and levels below ground are negative.
+	Index float64
+	Name  string
+	Extra map[string]string
+}
+
+// Pathway corresponds to a single row in the pathways.txt file.
+type Pathway struct {
+	Id              string
+	From            *Stop
+	To              *Stop
+	Mode            PathwayMode
+	IsBidirectional bool
+	// Length of the pathway in meters.
+	Length        *float64
+	TraversalTime *time.Duration
+	// Number of stairs of the pathway. Positive if the stairs go up from the from stop
+	// to the to stop, and negative if they go down.
+	StairCount *int32
+	MaxSlope   *float64
+	// Minimum width of the pathway in meters.
+	MinWidth             *float64
+	SignpostedAs         string
+	ReversedSignpostedAs string
+	Extra                map[string]string
+}
+
+func parseLevels(csv *csv.File) ([]Level, []warnings.StaticWarning) {
+	var w []warnings.StaticWarning
+	idColumn := csv.RequiredColumn("level_id")
+	indexColumn := csv.RequiredColumn("level_index")
+	nameColumn := csv.OptionalColumn("level_name")
+
+	if warnings := checkForMissingColumns(csv); len(warnings) > 0 {
+		return nil, warnings
+	}
+
+	var levels []Level
+	for csv.NextRow() {
+		id := idColumn.Read()
+		rawIndex := indexColumn.Read()
+		if missingKeys := csv.MissingRowKeys(); len(missingKeys) > 0 {
+			w = append(w, warnings.NewStaticWarning(csv, warnings.MissingValues{Columns: missingKeys}))
+			continue
+		}
+		index := parseFloat64(rawIndex)
+		if index == nil {
+			w = append(w, warnings.NewStaticWarning(csv, warnings.InvalidValue{Column: "level_index", Value: rawIndex}))
+			continue
+		}
+		levels = append(levels, Level{
+			Id:    id,
+			Index: *index,
+			Name:  nameColumn.Read(),
+			Extra: csv.ExtraColumns(),
+		})
+	}
+	return levels, w
+}
+
+func parsePathways(csv *csv.File, stops []Stop) ([]Pathway, []warnings.StaticWarning) {
+	var w []warnings.StaticWarning
+	idColumn := csv.RequiredColumn("pathway_id")
+	fromStopIDColumn := csv.RequiredColumn("from_stop_id")
+	toStopIDColumn := csv.RequiredColumn("to_stop_id")
+	modeColumn := csv.RequiredColumn("pathway_mode")
+	isBidirectionalColumn := csv.RequiredColumn("is_bidirectional")
+	lengthColumn := csv.OptionalColumn("length")
+	traversalTimeColumn := csv.OptionalColumn("traversal_time")
+	stairCountColumn := csv.OptionalColumn("stair_count")
+	maxSlopeColumn := csv.OptionalColumn("max_slope")
+	minWidthColumn := csv.OptionalColumn("min_width")
+	signpostedAsColumn := csv.OptionalColumn("signposted_as")
+	reversedSignpostedAsColumn := csv.OptionalColumn("reversed_signposted_as")
+
+	if warnings := checkForMissingColumns(csv); len(warnings) > 0 {
+		return nil, warnings
+	}
+
+	idToStop := map[string]*Stop{}
+	for i := range stops {
+		idToStop[stops[i].Id] = &stops[i]
+	}
+	var pathways []Pathway
+	for csv.NextRow() {
+		pathway := Pathway{
+			Id:                   idColumn.Read(),
+			Length:               parseFloat64(lengthColumn.Read()),
+			StairCount:           parseInt32(stairCountColumn.Read()),
+			MaxSlope:             parseFloat64(maxSlopeColumn.Read()),
+			MinWidth:             parseFloat64(minWidthColumn.Read()),
+			SignpostedAs:         signpostedAsColumn.Read(),
+			ReversedSignpostedAs: reversedSignpostedAsColumn.Read(),
+			Extra:                csv.ExtraColumns(),
+		}
+		fromStopID := fromStopIDColumn.Read()
+		toStopID := toStopIDColumn.Read()
+		rawMode := modeColumn.Read()
+		rawIsBidirectional := isBidirectionalColumn.Read()
+		if missingKeys := csv.MissingRowKeys(); len(missingKeys) > 0 {
+			w = append(w, warnings.NewStaticWarning(csv, warnings.MissingValues{Columns: missingKeys}))
+			continue
+		}
+		var ok bool
+		pathway.Mode, ok = parsePathwayMode(rawMode)
+		if !ok {
+			w = append(w, warnings.NewStaticWarning(csv, warnings.InvalidValue{Column: "pathway_mode", Value: rawMode}))
+			continue
+		}
+		switch rawIsBidirectional {
+		case "0":
+		case "1":
+			pathway.IsBidirectional = true
+		default:
+			w = append(w, warnings.NewStaticWarning(csv, warnings.InvalidValue{Column: "is_bidirectional", Value: rawIsBidirectional}))
+			continue
+		}
+		if pathway.From, ok = idToStop[fromStopID]; !ok {
+			w = append(w, warnings.NewStaticWarning(csv, warnings.InvalidStopReference{StopID: fromStopID}))
+			continue
+		}
+		if pathway.To, ok = idToStop[toStopID]; !ok {
+			w = append(w, warnings.NewStaticWarning(csv, warnings.InvalidStopReference{StopID: toStopID}))
+			continue
+		}
+		if traversalTime := parseInt32(traversalTimeColumn.Read()); traversalTime != nil {
+			d := time.Duration(*traversalTime) * time.Second
+			pathway.TraversalTime = &d
+		}
+		pathways = append(pathways, pathway)
+	}
+	return pathways, w
+}
+
+// StationGraph is the graph of pathways inside a single station.
+type StationGraph struct {
+	Station *Stop
+	// Pathways whose stops are both in the station.
+	Pathways []*Pathway
+
+	stopToSteps map[*Stop][]StationPathStep
+}
+
+// StationGraph returns the graph of pathways inside the station that the stop belongs to.
+func (static *Static) StationGraph(stop *Stop) *StationGraph {
+	graph := &StationGraph{
+		Station:     stop.Root(),
+		stopToSteps: map[*Stop][]StationPathStep{},
+	}
+	for i := range static.Pathways {
+		pathway := &static.Pathways[i]
+		if pathway.From.Root() != graph.Station || pathway.To.Root() != graph.Station {
+			continue
+		}
+		graph.Pathways = append(graph.Pathways, pathway)
+		graph.stopToSteps[pathway.From] = append(graph.stopToSteps[pathway.From], StationPathStep{Pathway: pathway})
+		if pathway.IsBidirectional {
+			graph.stopToSteps[pathway.To] = append(graph.stopToSteps[pathway.To], StationPathStep{Pathway: pathway, Reversed: true})
+		}
+	}
+	return graph
+}
+
+// StationPathOptions configures how paths are found in a station graph.
+type StationPathOptions struct {
+	// If true, only step-free pathways are used. Stairs, escalators and pathways
+	// with a nonzero stair count are excluded.
+	StepFree bool
+}
+
+// StationPath is a path between two stops in a station.
+type StationPath struct {
+	Steps []StationPathStep
+	// Estimated time to traverse the path.
+	Duration time.Duration
+}
+
+// StationPathStep is a single pathway in a station path.
+type StationPathStep struct {
+	Pathway *Pathway
+	// If true, the pathway is traversed from its to stop to its from stop.
+	Reversed bool
+}
+
+// From returns the stop the step starts at.
+func (step StationPathStep) From() *Stop {
+	if step.Reversed {
+		return step.Pathway.To
+	}
+	return step.Pathway.From
+}
+
+// To returns the stop the step ends at.
+func (step StationPathStep) To() *Stop {
+	if step.Reversed {
+		return step.Pathway.From
+	}
+	return step.Pathway.To
+}
+
+// SignpostedAs returns the signage a rider follows to take the step.
+func (step StationPathStep) SignpostedAs() string {
+	if step.Reversed {
+		return step.Pathway.ReversedSignpostedAs
+	}
+	return step.Pathway.SignpostedAs
+}
+
+const (
+	// Walking speed used to estimate the traversal time of pathways that only specify a length.
+	walkingSpeedMetersPerSecond = 1.2
+	// Time used to estimate the traversal time of stairs that only specify a stair count.
+	timePerStair = time.Second
+	// Traversal time assumed for pathways that specify neither a traversal time, a length
+	// nor a stair count.
+	defaultPathwayTraversalTime = time.Minute
+)
+
+// estimatedTraversalTime returns the traversal time of the pathway if specified,
+// and otherwise estimates it from the length or stair count of the pathway.
+func (pathway *Pathway) estimatedTraversalTime() time.Duration {
+	if pathway.TraversalTime != nil {
+		return *pathway.TraversalTime
+	}
+	if pathway.Length != nil {
+		return time.Duration(*pathway.Length / walkingSpeedMetersPerSecond * float64(time.Second))
+	}
+	if pathway.StairCount != nil {
+		stairCount := *pathway.StairCount
+		if stairCount < 0 {
+			stairCount = -stairCount
+		}
+		return time.Duration(stairCount) * timePerStair
+	}
+	return defaultPathwayTraversalTime
+}
+
+func (pathway *Pathway) isStepFree() bool {
+	if pathway.Mode == PathwayMode_Stairs || pathway.Mode == PathwayMode_Escalator {
+		return false
+	}
+	return pathway.StairCount == nil || *pathway.StairCount == 0
+}
+
+// ShortestPath returns the fastest path between two stops in the station.
+//
+// The second return value is false if there is no path between the stops.
+func (graph *StationGraph) ShortestPath(from, to *Stop, opts StationPathOptions) (StationPath, bool) {
+	type visit struct {
+		duration time.Duration
+		step     StationPathStep
+	}
+	visits := map[*Stop]visit{from: {}}
+	done := map[*Stop]bool{}
+	queue := &stationPathQueue{{stop: from}}
+	for queue.Len() > 0 {
+		current := heap.Pop(queue).(stationPathQueueItem)
+		if done[current.stop] {
+			continue
+		}
+		done[current.stop] = true
+		if current.stop == to {
+			break
+		}
+		for _, step := range graph.stopToSteps[current.stop] {
+			if opts.StepFree && !step.Pathway.isStepFree() {
+				continue
+			}
+			next := step.To()
+			duration := current.duration + step.Pathway.estimatedTraversalTime()
+			if v, ok := visits[next]; ok && v.duration <= duration {
+				continue
+			}
+			visits[next] = visit{duration: duration, step: step}
+			heap.Push(queue, stationPathQueueItem{stop: next, duration: duration})
+		}
+	}
+	if !done[to] {
+		return StationPath{}, false
+	}
+	path := StationPath{Duration: visits[to].duration}
+	for stop := to; stop != from; {
+		step := visits[stop].step
+		path.Steps = append(path.Steps, step)
+		stop = step.From()
+	}
+	for i, j := 0, len(path.Steps)-1; i < j; i, j = i+1, j-1 {
+		path.Steps[i], path.Steps[j] = path.Steps[j], path.Steps[i]
+	}
+	return path, true
+}
+
+type stationPathQueueItem struct {
+	stop     *Stop
+	duration time.Duration
+}
+
+// stationPathQueue is a min-heap of stops ordered by the duration to reach them.
+type stationPathQueue []stationPathQueueItem
+
+func (q stationPathQueue) Len() int           { return len(q) }
+func (q stationPathQueue) Less(i, j int) bool { return q[i].duration < q[j].duration }
+func (q stationPathQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+
+func (q *stationPathQueue) Push(x any) {
+	*q = append(*q, x.(stationPathQueueItem))
+}
+
+func (q *stationPathQueue) Pop() any {
+	old := *q
+	n := len(old)
+	item := old[n-1]
+	*q = old[:n-1]
+	return item
+}
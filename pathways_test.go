@@ -0,0 +1,195 @@
+package gtfs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jamespfennell/gtfs/warnings"
+)
+
+func newPathwaysZipBuilder() *zipBuilder {
+	return newZipBuilderWithDefaults().add(
+		"levels.txt",
+		"level_id,level_index,level_name",
+		"street,0,Street",
+		"mezzanine,-1,Mezzanine",
+		"platforms,-2,Platforms",
+	).add(
+		"stops.txt",
+		"stop_id,location_type,parent_station,level_id",
+		"station,1,,",
+		"entrance,2,station,street",
+		"node,3,station,mezzanine",
+		"platform_1,0,station,platforms",
+		"platform_2,0,station,platforms",
+		"other_station,1,,",
+		"other_platform,0,other_station,",
+	).add(
+		"pathways.txt",
+		"pathway_id,from_stop_id,to_stop_id,pathway_mode,is_bidirectional,length,traversal_time,stair_count,signposted_as,reversed_signposted_as",
+		"walkway,entrance,node,1,1,12,,,To platforms,Exit",
+		"stairs,node,platform_1,2,1,,30,-20,Platform 1,Mezzanine",
+		"elevator,node,platform_1,5,1,,90,,Platform 1,Mezzanine",
+		"passage,platform_1,platform_2,1,0,,60,,Platform 2,",
+		"other,platform_2,other_platform,1,1,,60,,,",
+	)
+}
+
+func TestParsePathways(t *testing.T) {
+	static, err := ParseStatic(newPathwaysZipBuilder().add(
+		"pathways.txt",
+		"pathway_id,from_stop_id,to_stop_id,pathway_mode,is_bidirectional,length,traversal_time,stair_count,max_slope,min_width,signposted_as,reversed_signposted_as",
+		"stairs,node,platform_1,2,1,8.5,30,-20,,1.5,Platform 1,Mezzanine",
+		"invalid_mode,node,platform_1,8,1,,,,,,,",
+		"invalid_bidirectional,node,platform_1,1,2,,,,,,,",
+		"invalid_stop,node,platform_3,1,1,,,,,,,",
+	).build(), ParseStaticOptions{})
+	if err != nil {
+		t.Fatalf("error when parsing: %s", err)
+	}
+
+	wantLevels := []Level{
+		{Id: "street", Index: 0, Name: "Street"},
+		{Id: "mezzanine", Index: -1, Name: "Mezzanine"},
+		{Id: "platforms", Index: -2, Name: "Platforms"},
+	}
+	if diff := cmp.Diff(wantLevels, static.Levels); diff != "" {
+		t.Errorf("levels not the same: %s", diff)
+	}
+	if static.Stops[1].Level != &static.Levels[0] || static.Stops[3].Level != &static.Levels[2] || static.Stops[0].Level != nil {
+		t.Errorf("stop levels not resolved")
+	}
+
+	wantPathways := []Pathway{
+		{
+			Id:                   "stairs",
+			From:                 &static.Stops[2],
+			To:                   &static.Stops[3],
+			Mode:                 PathwayMode_Stairs,
+			IsBidirectional:      true,
+			Length:               ptr(8.5),
+			TraversalTime:        ptr(30 * time.Second),
+			StairCount:           ptr(int32(-20)),
+			MinWidth:             ptr(1.5),
+			SignpostedAs:         "Platform 1",
+			ReversedSignpostedAs: "Mezzanine",
+		},
+	}
+	if diff := cmp.Diff(wantPathways, static.Pathways); diff != "" {
+		t.Errorf("pathways not the same: %s", diff)
+	}
+
+	var gotWarnings []warnings.StaticWarningKind
+	for _, w := range static.Warnings {
+		gotWarnings = append(gotWarnings, w.Kind)
+	}
+	wantWarnings := []warnings.StaticWarningKind{
+		warnings.InvalidValue{Column: "pathway_mode", Value: "8"},
+		warnings.InvalidValue{Column: "is_bidirectional", Value: "2"},
+		warnings.InvalidStopReference{StopID: "platform_3"},
+	}
+	if diff := cmp.Diff(wantWarnings, gotWarnings); diff != "" {
+		t.Errorf("warnings not the same: %s", diff)
+	}
+}
+
+func TestStationGraph(t *testing.T) {
+	static, err := ParseStatic(newPathwaysZipBuilder().build(), ParseStaticOptions{})
+	if err != nil {
+		t.Fatalf("error when parsing: %s", err)
+	}
+	stopIDToStop := map[string]*Stop{}
+	for i := range static.Stops {
+		stopIDToStop[static.Stops[i].Id] = &static.Stops[i]
+	}
+
+	graph := static.StationGraph(stopIDToStop["platform_1"])
+	if graph.Station != stopIDToStop["station"] {
+		t.Errorf("station: got %+v, want the station", graph.Station)
+	}
+	if got := len(graph.Pathways); got != 4 {
+		t.Errorf("number of pathways: got %d, want 4", got)
+	}
+
+	type step struct {
+		pathwayID    string
+		reversed     bool
+		signpostedAs string
+	}
+	for _, tc := range []struct {
+		desc         string
+		from, to     string
+		opts         StationPathOptions
+		wantSteps    []step
+		wantDuration time.Duration
+		wantNoPath   bool
+	}{
+		{
+			desc: "shortest path",
+			from: "entrance",
+			to:   "platform_1",
+			wantSteps: []step{
+				{"walkway", false, "To platforms"},
+				{"stairs", false, "Platform 1"},
+			},
+			wantDuration: 40 * time.Second,
+		},
+		{
+			desc: "step-free path",
+			from: "entrance",
+			to:   "platform_1",
+			opts: StationPathOptions{StepFree: true},
+			wantSteps: []step{
+				{"walkway", false, "To platforms"},
+				{"elevator", false, "Platform 1"},
+			},
+			wantDuration: 100 * time.Second,
+		},
+		{
+			desc:       "one-way pathway",
+			from:       "platform_2",
+			to:         "platform_1",
+			wantNoPath: true,
+		},
+		{
+			desc: "reversed pathways",
+			from: "platform_1",
+			to:   "entrance",
+			wantSteps: []step{
+				{"stairs", true, "Mezzanine"},
+				{"walkway", true, "Exit"},
+			},
+			wantDuration: 40 * time.Second,
+		},
+		{
+			desc:         "same stop",
+			from:         "node",
+			to:           "node",
+			wantDuration: 0,
+		},
+		{
+			desc:       "other station",
+			from:       "platform_2",
+			to:         "other_platform",
+			wantNoPath: true,
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			path, ok := graph.ShortestPath(stopIDToStop[tc.from], stopIDToStop[tc.to], tc.opts)
+			if ok == tc.wantNoPath {
+				t.Fatalf("path found: got %t, want %t", ok, !tc.wantNoPath)
+			}
+			var gotSteps []step
+			for _, s := range path.Steps {
+				gotSteps = append(gotSteps, step{s.Pathway.Id, s.Reversed, s.SignpostedAs()})
+			}
+			if diff := cmp.Diff(tc.wantSteps, gotSteps, cmp.AllowUnexported(step{})); diff != "" {
+				t.Errorf("steps not the same: %s", diff)
+			}
+			if path.Duration != tc.wantDuration {
+				t.Errorf("duration: got %s, want %s", path.Duration, tc.wantDuration)
+			}
+		})
+	}
+}
@@ -5,12 +5,15 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"runtime"
 	"runtime/pprof"
+	"time"
 
 	"github.com/jamespfennell/gtfs"
 )
 
 var out = flag.String("out", "gtfs_package_profile.pb.gz", "file path to output the profile to")
+var concurrency = flag.Int("concurrency", 0, "concurrency of the parser; if zero, GOMAXPROCS is used")
 
 func main() {
 	if err := run(); err != nil {
@@ -36,7 +39,7 @@ func run() error {
 	pprof.StartCPUProfile(&profile)
 	for i, in := range gtfsBytes {
 		fmt.Printf("parsing file %d/%d\n", i+1, len(gtfsBytes))
-		_, err := gtfs.ParseStatic(in, gtfs.ParseStaticOptions{})
+		_, err := gtfs.ParseStatic(in, gtfs.ParseStaticOptions{Concurrency: *concurrency})
 		if err != nil {
 			return err
 		}
@@ -45,5 +48,32 @@ func run() error {
 
 	fmt.Println("writing profile to", *out)
 	os.WriteFile(*out, profile.Bytes(), 0644)
+
+	// On multi-core machines, report the speed-up of parsing concurrently over parsing sequentially.
+	maxConcurrency := *concurrency
+	if maxConcurrency <= 0 {
+		maxConcurrency = runtime.GOMAXPROCS(0)
+	}
+	if maxConcurrency <= 1 {
+		return nil
+	}
+	for i, in := range gtfsBytes {
+		sequential, err := timeParse(in, 1)
+		if err != nil {
+			return err
+		}
+		concurrent, err := timeParse(in, maxConcurrency)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("file %d/%d: sequential %s, concurrency %d %s, speed-up %.2fx\n",
+			i+1, len(gtfsBytes), sequential, maxConcurrency, concurrent, float64(sequential)/float64(concurrent))
+	}
 	return nil
 }
+
+func timeParse(in []byte, concurrency int) (time.Duration, error) {
+	start := time.Now()
+	_, err := gtfs.ParseStatic(in, gtfs.ParseStaticOptions{Concurrency: concurrency})
+	return time.Since(start), err
+}
@@ -5,10 +5,14 @@ import (
 	"archive/zip"
 	"bytes"
 	"fmt"
-	"log"
+	"io"
+	"io/fs"
+	"runtime"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 	"unicode"
 
@@ -27,6 +31,32 @@ type Static struct {
 	Trips     []ScheduledTrip
 	Shapes    []Shape
 
+	FareAttributes []FareAttribute
+	FareRules      []FareRule
+
+	Areas    []Area
+	Networks []Network
+
+	Levels   []Level
+	Pathways []Pathway
+
+	Locations      []Location
+	LocationGroups []LocationGroup
+	BookingRules   []BookingRule
+
+	Translations []Translation
+
+	FeedInfo     *FeedInfo
+	Attributions []Attribution
+
+	Timeframes        []Timeframe
+	FareMedia         []FareMedia
+	FareProducts      []FareProduct
+	FareLegGroups     []FareLegGroup
+	FareLegRules      []FareLegRule
+	FareLegJoinRules  []FareLegJoinRule
+	FareTransferRules []FareTransferRule
+
 	// Warnings raised during GTFS static parsing.
 	Warnings []warnings.StaticWarning
 }
@@ -41,6 +71,9 @@ type Agency struct {
 	Phone    string
 	FareUrl  string
 	Email    string
+	// Values of the columns of the row that the parser does not read, if the feed is parsed
+	// with the KeepExtraColumns option.
+	Extra map[string]string
 }
 
 // Route corresponds to a single row in the routes.txt file.
@@ -57,6 +90,10 @@ type Route struct {
 	SortOrder         *int32
 	ContinuousPickup  PickupDropOffPolicy
 	ContinuousDropOff PickupDropOffPolicy
+	// Network the route belongs to, as specified in either the route_networks.txt file
+	// or the network_id column of the routes.txt file.
+	Network *Network
+	Extra   map[string]string
 }
 
 type Stop struct {
@@ -73,6 +110,10 @@ type Stop struct {
 	Timezone           string
 	WheelchairBoarding WheelchairBoarding
 	PlatformCode       string
+	Level              *Level
+	// Areas the stop belongs to, as specified in the stop_areas.txt file.
+	Areas []*Area
+	Extra map[string]string
 }
 
 // Root returns the root stop.
@@ -85,11 +126,20 @@ func (stop *Stop) Root() *Stop {
 	}
 }
 
+// Transfer corresponds to a single row in the transfers.txt file.
+//
+// The stops are nil for in-seat transfers that don't specify them. The routes and trips are
+// nil unless the transfer only applies to them.
 type Transfer struct {
 	From            *Stop
 	To              *Stop
+	FromRoute       *Route
+	ToRoute         *Route
+	FromTrip        *ScheduledTrip
+	ToTrip          *ScheduledTrip
 	Type            TransferType
 	MinTransferTime *int32
+	Extra           map[string]string
 }
 
 type Service struct {
@@ -105,6 +155,12 @@ type Service struct {
 	EndDate      time.Time
 	AddedDates   []time.Time
 	RemovedDates []time.Time
+	Extra        map[string]string
+	// Range of the calendar.txt row of the service, in which it runs on its days of the week, or
+	// zero if the service has no row in calendar.txt. StartDate and EndDate are wider if the service
+	// has dates in calendar_dates.txt outside of this range.
+	CalendarStartDate time.Time
+	CalendarEndDate   time.Time
 }
 
 type ScheduledTrip struct {
@@ -120,11 +176,23 @@ type ScheduledTrip struct {
 	StopTimes            []ScheduledStopTime
 	Shape                *Shape
 	Frequencies          []Frequency
+	// Stop times of the trip if the feed is parsed with the CompactStopTimes option, in which
+	// case StopTimes is nil, even if the trip has stop times. The NumStopTimes and StopTime
+	// methods work in both cases.
+	CompactStopTimes *CompactStopTimes
+	Extra            map[string]string
 }
 
+// ScheduledStopTime corresponds to a single row in the stop_times.txt file.
+//
+// Exactly one of Stop, LocationGroup and Location is set. For GTFS-Flex stop times that
+// specify a pickup/drop-off window instead of arrival and departure times, the arrival
+// and departure times are zero.
 type ScheduledStopTime struct {
 	Trip                  *ScheduledTrip
 	Stop                  *Stop
+	LocationGroup         *LocationGroup
+	Location              *Location
 	ArrivalTime           time.Duration
 	DepartureTime         time.Duration
 	StopSequence          int
@@ -135,12 +203,19 @@ type ScheduledStopTime struct {
 	ContinuousDropOff     PickupDropOffPolicy
 	ShapeDistanceTraveled *float64
 	ExactTimes            bool
+
+	StartPickupDropOffWindow *time.Duration
+	EndPickupDropOffWindow   *time.Duration
+	PickupBookingRule        *BookingRule
+	DropOffBookingRule       *BookingRule
+	Extra                    map[string]string
 }
 
 type ShapePoint struct {
 	Latitude  float64
 	Longitude float64
 	Distance  *float64
+	Extra     map[string]string
 }
 
 type Shape struct {
@@ -153,12 +228,58 @@ type Frequency struct {
 	EndTime    time.Duration
 	Headway    time.Duration
 	ExactTimes ExactTimes
+	Extra      map[string]string
 }
 
 type ParseStaticOptions struct {
 	// If true, wheelchair boarding information is inherited from parent station
 	// when unspecified for a child stop/platform, entrance, or exit.
 	InheritWheelchairBoarding bool
+
+	// Tables to load. If empty, all tables are loaded.
+	//
+	// Otherwise only the listed tables and the tables they reference are loaded, and the
+	// remaining files are not read. For example, loading constants.TripsFile also loads the
+	// routes, agencies, calendars and shapes that trips reference, but not stop times.
+	Tables []constants.StaticFile
+
+	// Maximum number of files that are parsed at the same time. Files that don't reference
+	// each other, like shapes.txt and stops.txt, are parsed concurrently, and the rows of
+	// the stop_times.txt file are split across this many workers.
+	//
+	// If zero, runtime.GOMAXPROCS(0) is used. If one, the files are parsed sequentially.
+	// The result does not depend on the concurrency.
+	Concurrency int
+
+	// If true, stop times are stored in a compact columnar form that uses several times less
+	// memory, and are accessed through ScheduledTrip.CompactStopTimes instead of
+	// ScheduledTrip.StopTimes. This option is ignored when streaming stop times.
+	//
+	// This changes the API: the ScheduledTrip.StopTimes field is nil, so code that ranges over it
+	// sees no stop times. Such code must use the ScheduledTrip.NumStopTimes and
+	// ScheduledTrip.StopTime methods instead, which work with and without this option.
+	CompactStopTimes bool
+
+	// If true, values of string columns that typically repeat across rows, like headsigns,
+	// block IDs and zone IDs, are interned: identical values share memory. This reduces the
+	// memory retained by the parsed feed but not the number of allocations made while parsing,
+	// and each interned value costs a lookup in a map shared by all of the files.
+	InternStrings bool
+
+	// If true, the values of columns that the parser does not read, like columns that are
+	// specific to an agency, are kept in the Extra field of the parsed entities.
+	KeepExtraColumns bool
+
+	// Kinds of warnings that are treated as errors. If a warning of one of these kinds is
+	// raised, parsing fails with an error that wraps the first such warning, in file order.
+	//
+	// Only the types of the kinds are compared, so kinds are given as zero values, for example
+	// warnings.InvalidStopReference{}. When streaming, stop times may be passed to the handler
+	// before parsing fails.
+	Strict []warnings.StaticWarningKind
+
+	// If non-zero, warnings with this severity or a higher severity are also treated as errors.
+	StrictSeverity warnings.Severity
 }
 
 // ParseStatic parses the content as a GTFS static feed.
@@ -167,21 +288,65 @@ func ParseStatic(content []byte, opts ParseStaticOptions) (*Static, error) {
 	if err != nil {
 		return nil, err
 	}
+	return parseStatic(reader, opts, nil)
+}
+
+// ParseStaticFS parses the GTFS static feed whose files are in the root of the file system.
+//
+// For example, to parse an unzipped feed in a directory use os.DirFS.
+// If the root contains no agency.txt file and a single directory, the feed is
+// read from that directory instead.
+func ParseStaticFS(fsys fs.FS, opts ParseStaticOptions) (*Static, error) {
+	return parseStatic(fsys, opts, nil)
+}
+
+// parseStatic parses the feed in the file system.
+//
+// If the stop times handler is nil, stop times are stored in their trips. Otherwise they are
+// passed to the handler and the trips are left without stop times.
+func parseStatic(fsys fs.FS, opts ParseStaticOptions, stopTimesHandler StopTimesHandler) (*Static, error) {
+	fsys, err := feedRoot(fsys)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
 	result := &Static{}
-	fileNameToFile := map[constants.StaticFile]*zip.File{}
-	for _, file := range reader.File {
-		fileNameToFile[constants.StaticFile(file.Name)] = file
+	fileExists := map[constants.StaticFile]bool{}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			fileExists[constants.StaticFile(entry.Name())] = true
+		}
 	}
 	serviceIdToService := map[string]Service{}
 	shapeIdToShape := map[string]*Shape{}
 	tripIdToScheduledTrip := map[string]*ScheduledTrip{}
+	var routeIdToNetworkId map[string]string
+	// Error returned by the stop times handler, which aborts parsing.
+	var handlerErr error
 	timezone := time.UTC
-	for _, table := range []struct {
-		File        constants.StaticFile
-		Action      func(file *csv.File) []warnings.StaticWarning
-		PostProcess func()
-		Optional    bool
-	}{
+	var interner *csv.Interner
+	if opts.InternStrings {
+		interner = csv.NewInterner()
+	}
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = runtime.GOMAXPROCS(0)
+	}
+	buildServices := func() []warnings.StaticWarning {
+		result.Services = nil
+		for _, service := range serviceIdToService {
+			result.Services = append(result.Services, service)
+		}
+		// Sort the services by ID so that the result does not depend on the map order.
+		sort.Slice(result.Services, func(i, j int) bool {
+			return result.Services[i].Id < result.Services[j].Id
+		})
+		return nil
+	}
+	tables := []staticTable{
 		{
 			File: constants.AgencyFile,
 			Action: func(file *csv.File) (w []warnings.StaticWarning) {
@@ -197,52 +362,60 @@ func ParseStatic(content []byte, opts ParseStaticOptions) (*Static, error) {
 			},
 		},
 		{
-			File: "routes.txt",
+			File:         constants.RoutesFile,
+			Dependencies: []constants.StaticFile{constants.AgencyFile},
 			Action: func(file *csv.File) (w []warnings.StaticWarning) {
-				result.Routes = parseRoutes(file, result.Agencies)
+				result.Routes, routeIdToNetworkId, w = parseRoutes(file, result.Agencies)
 				return
 			},
 		},
 		{
-			File: "stops.txt",
+			File: constants.LevelsFile,
 			Action: func(file *csv.File) (w []warnings.StaticWarning) {
-				result.Stops = parseStops(file, opts.InheritWheelchairBoarding)
+				result.Levels, w = parseLevels(file)
 				return
 			},
+			Optional: true,
 		},
 		{
-			File: "transfers.txt",
+			File:         constants.StopsFile,
+			Dependencies: []constants.StaticFile{constants.LevelsFile},
 			Action: func(file *csv.File) (w []warnings.StaticWarning) {
-				result.Transfers = parseTransfers(file, result.Stops)
+				result.Stops, w = parseStops(file, result.Levels, opts.InheritWheelchairBoarding)
 				return
 			},
-			Optional: true,
 		},
 		{
-			File: "calendar.txt",
+			File:         constants.PathwaysFile,
+			Dependencies: []constants.StaticFile{constants.StopsFile},
 			Action: func(file *csv.File) (w []warnings.StaticWarning) {
-				parseCalendar(file, serviceIdToService, timezone)
+				result.Pathways, w = parsePathways(file, result.Stops)
 				return
 			},
 			Optional: true,
 		},
 		{
-			File: "calendar_dates.txt",
+			File:         constants.CalendarFile,
+			Dependencies: []constants.StaticFile{constants.AgencyFile},
 			Action: func(file *csv.File) (w []warnings.StaticWarning) {
-				parseCalendarDates(file, serviceIdToService, timezone)
-				return
+				return parseCalendar(file, serviceIdToService, timezone)
 			},
-			PostProcess: func() {
-				for _, service := range serviceIdToService {
-					result.Services = append(result.Services, service)
-				}
+			PostProcess: buildServices,
+			Optional:    true,
+		},
+		{
+			File:         constants.CalendarDatesFile,
+			Dependencies: []constants.StaticFile{constants.AgencyFile, constants.CalendarFile},
+			Action: func(file *csv.File) (w []warnings.StaticWarning) {
+				return parseCalendarDates(file, serviceIdToService, timezone)
 			},
-			Optional: true,
+			PostProcess: buildServices,
+			Optional:    true,
 		},
 		{
-			File: "shapes.txt",
+			File: constants.ShapesFile,
 			Action: func(file *csv.File) (w []warnings.StaticWarning) {
-				result.Shapes = parseShapes(file)
+				result.Shapes, w = parseShapes(file)
 				for idx, shape := range result.Shapes {
 					shapeIdToShape[shape.ID] = &result.Shapes[idx]
 				}
@@ -251,9 +424,14 @@ func ParseStatic(content []byte, opts ParseStaticOptions) (*Static, error) {
 			Optional: true,
 		},
 		{
-			File: "trips.txt",
+			File: constants.TripsFile,
+			Dependencies: []constants.StaticFile{
+				constants.RoutesFile,
+				constants.CalendarDatesFile,
+				constants.ShapesFile,
+			},
 			Action: func(file *csv.File) (w []warnings.StaticWarning) {
-				result.Trips = parseScheduledTrips(file, result.Routes, result.Services, shapeIdToShape)
+				result.Trips, w = parseScheduledTrips(file, result.Routes, result.Services, shapeIdToShape)
 				for idx, trip := range result.Trips {
 					tripIdToScheduledTrip[trip.ID] = &result.Trips[idx]
 				}
@@ -261,48 +439,469 @@ func ParseStatic(content []byte, opts ParseStaticOptions) (*Static, error) {
 			},
 		},
 		{
-			File: "frequencies.txt",
+			File: constants.TransfersFile,
+			Dependencies: []constants.StaticFile{
+				constants.StopsFile,
+				constants.RoutesFile,
+				constants.TripsFile,
+			},
+			Action: func(file *csv.File) (w []warnings.StaticWarning) {
+				result.Transfers, w = parseTransfers(file, result.Stops, result.Routes, result.Trips)
+				return
+			},
+			Optional: true,
+		},
+		{
+			File:         constants.FrequenciesFile,
+			Dependencies: []constants.StaticFile{constants.TripsFile},
 			Action: func(file *csv.File) (w []warnings.StaticWarning) {
-				parseFrequencies(file, tripIdToScheduledTrip)
+				return parseFrequencies(file, tripIdToScheduledTrip)
+			},
+			Optional: true,
+		},
+		{
+			File: constants.LocationsFile,
+			RawAction: func(content io.Reader) (w []warnings.StaticWarning) {
+				result.Locations, w = parseLocations(constants.LocationsFile, content)
 				return
 			},
 			Optional: true,
 		},
 		{
-			File: "stop_times.txt",
+			File: constants.LocationGroupsFile,
 			Action: func(file *csv.File) (w []warnings.StaticWarning) {
-				parseScheduledStopTimes(file, result.Stops, result.Trips)
+				result.LocationGroups, w = parseLocationGroups(file)
 				return
 			},
+			Optional: true,
 		},
-	} {
-		if table.PostProcess == nil {
-			table.PostProcess = func() {}
+		{
+			File:         constants.LocationGroupStopsFile,
+			Dependencies: []constants.StaticFile{constants.LocationGroupsFile, constants.StopsFile},
+			Action: func(file *csv.File) (w []warnings.StaticWarning) {
+				return parseLocationGroupStops(file, result.LocationGroups, result.Stops)
+			},
+			Optional: true,
+		},
+		{
+			File:         constants.BookingRulesFile,
+			Dependencies: []constants.StaticFile{constants.CalendarDatesFile},
+			Action: func(file *csv.File) (w []warnings.StaticWarning) {
+				result.BookingRules, w = parseBookingRules(file, result.Services)
+				return
+			},
+			Optional: true,
+		},
+		{
+			File: constants.StopTimesFile,
+			Dependencies: []constants.StaticFile{
+				constants.StopsFile,
+				constants.TripsFile,
+				constants.LocationsFile,
+				constants.LocationGroupsFile,
+				constants.BookingRulesFile,
+			},
+			Action: func(file *csv.File) (w []warnings.StaticWarning) {
+				refs := newStopTimeReferences(result)
+				if stopTimesHandler != nil {
+					stream := newStopTimesStream(stopTimesHandler)
+					w, handlerErr = parseScheduledStopTimesConcurrently(file, refs, concurrency, stream.add)
+					if handlerErr == nil {
+						handlerErr = stream.flush()
+					}
+					return
+				}
+				if opts.CompactStopTimes {
+					builder := newCompactStopTimesBuilder(result.Stops, result.Trips)
+					w, _ = parseScheduledStopTimesConcurrently(file, refs, concurrency, builder.add)
+					builder.build()
+					return
+				}
+				w, _ = parseScheduledStopTimesConcurrently(file, refs, concurrency, newStopTimeAppender())
+				for i := range result.Trips {
+					sortStopTimes(result.Trips[i].StopTimes)
+				}
+				return
+			},
+		},
+		{
+			File:         constants.FareAttributesFile,
+			Dependencies: []constants.StaticFile{constants.AgencyFile},
+			Action: func(file *csv.File) (w []warnings.StaticWarning) {
+				result.FareAttributes, w = parseFareAttributes(file, result.Agencies)
+				return
+			},
+			Optional: true,
+		},
+		{
+			File: constants.FareRulesFile,
+			Dependencies: []constants.StaticFile{
+				constants.FareAttributesFile,
+				constants.RoutesFile,
+				constants.StopsFile,
+			},
+			Action: func(file *csv.File) (w []warnings.StaticWarning) {
+				result.FareRules, w = parseFareRules(file, result.FareAttributes, result.Routes, result.Stops)
+				return
+			},
+			Optional: true,
+		},
+		{
+			File: constants.AreasFile,
+			Action: func(file *csv.File) (w []warnings.StaticWarning) {
+				result.Areas, w = parseAreas(file)
+				return
+			},
+			Optional: true,
+		},
+		{
+			File:         constants.StopAreasFile,
+			Dependencies: []constants.StaticFile{constants.AreasFile, constants.StopsFile},
+			Action: func(file *csv.File) (w []warnings.StaticWarning) {
+				return parseStopAreas(file, result.Areas, result.Stops)
+			},
+			Optional: true,
+		},
+		{
+			File:         constants.NetworksFile,
+			Dependencies: []constants.StaticFile{constants.RoutesFile},
+			Action: func(file *csv.File) (w []warnings.StaticWarning) {
+				if routeIdToNetworkId != nil {
+					return []warnings.StaticWarning{forbiddenByRouteNetworkIdColumn(file)}
+				}
+				result.Networks, w = parseNetworks(file)
+				return
+			},
+			PostProcess: func() []warnings.StaticWarning {
+				if routeIdToNetworkId != nil {
+					result.Networks = buildNetworksFromRoutes(result.Routes, routeIdToNetworkId)
+				}
+				return nil
+			},
+			Optional: true,
+		},
+		{
+			File:         constants.RouteNetworksFile,
+			Dependencies: []constants.StaticFile{constants.NetworksFile, constants.RoutesFile},
+			Action: func(file *csv.File) (w []warnings.StaticWarning) {
+				if routeIdToNetworkId != nil {
+					return []warnings.StaticWarning{forbiddenByRouteNetworkIdColumn(file)}
+				}
+				return parseRouteNetworks(file, result.Networks, result.Routes)
+			},
+			Optional: true,
+		},
+		{
+			File:         constants.TimeframesFile,
+			Dependencies: []constants.StaticFile{constants.CalendarDatesFile},
+			Action: func(file *csv.File) (w []warnings.StaticWarning) {
+				result.Timeframes, w = parseTimeframes(file, result.Services)
+				return
+			},
+			Optional: true,
+		},
+		{
+			File: constants.FareMediaFile,
+			Action: func(file *csv.File) (w []warnings.StaticWarning) {
+				result.FareMedia, w = parseFareMedia(file)
+				return
+			},
+			Optional: true,
+		},
+		{
+			File:         constants.FareProductsFile,
+			Dependencies: []constants.StaticFile{constants.FareMediaFile},
+			Action: func(file *csv.File) (w []warnings.StaticWarning) {
+				result.FareProducts, w = parseFareProducts(file, result.FareMedia)
+				return
+			},
+			Optional: true,
+		},
+		{
+			File: constants.FareLegRulesFile,
+			Dependencies: []constants.StaticFile{
+				constants.RouteNetworksFile,
+				constants.AreasFile,
+				constants.TimeframesFile,
+				constants.FareProductsFile,
+			},
+			Action: func(file *csv.File) (w []warnings.StaticWarning) {
+				// Only the entities of the dependencies are used, as other files may be parsed at the same time.
+				refs := newFareV2References(&Static{
+					Networks:     result.Networks,
+					Areas:        result.Areas,
+					Timeframes:   result.Timeframes,
+					FareProducts: result.FareProducts,
+				})
+				result.FareLegRules, result.FareLegGroups, w = parseFareLegRules(file, refs)
+				return
+			},
+			Optional: true,
+		},
+		{
+			File:         constants.FareLegJoinRulesFile,
+			Dependencies: []constants.StaticFile{constants.RouteNetworksFile, constants.StopsFile},
+			Action: func(file *csv.File) (w []warnings.StaticWarning) {
+				// Only the entities that join rules reference are used, as the fare_leg_rules.txt
+				// file may be parsed at the same time.
+				refs := newFareV2References(&Static{Networks: result.Networks, Stops: result.Stops})
+				result.FareLegJoinRules, w = parseFareLegJoinRules(file, refs)
+				return
+			},
+			Optional: true,
+		},
+		{
+			File:         constants.FareTransferRulesFile,
+			Dependencies: []constants.StaticFile{constants.FareLegRulesFile, constants.FareProductsFile},
+			Action: func(file *csv.File) (w []warnings.StaticWarning) {
+				refs := newFareV2References(&Static{FareLegGroups: result.FareLegGroups, FareProducts: result.FareProducts})
+				result.FareTransferRules, w = parseFareTransferRules(file, refs)
+				return
+			},
+			Optional: true,
+		},
+		{
+			File: constants.TranslationsFile,
+			Action: func(file *csv.File) (w []warnings.StaticWarning) {
+				result.Translations, w = parseTranslations(file)
+				return
+			},
+			Optional: true,
+		},
+		{
+			File:         constants.FeedInfoFile,
+			Dependencies: []constants.StaticFile{constants.AgencyFile},
+			Action: func(file *csv.File) (w []warnings.StaticWarning) {
+				result.FeedInfo, w = parseFeedInfo(file, timezone)
+				return
+			},
+			PostProcess: func() []warnings.StaticWarning {
+				// The feed_info.txt file is required if the translations.txt file is provided.
+				if !fileExists[constants.FeedInfoFile] && fileExists[constants.TranslationsFile] {
+					return []warnings.StaticWarning{{
+						Kind: warnings.MissingConditionallyRequiredFile{Reason: "translations.txt is provided"},
+						File: constants.FeedInfoFile,
+					}}
+				}
+				return nil
+			},
+			Optional: true,
+		},
+		{
+			File: constants.AttributionsFile,
+			Dependencies: []constants.StaticFile{
+				constants.AgencyFile,
+				constants.RoutesFile,
+				constants.TripsFile,
+			},
+			Action: func(file *csv.File) (w []warnings.StaticWarning) {
+				result.Attributions, w = parseAttributions(file, result.Agencies, result.Routes, result.Trips)
+				return
+			},
+			Optional: true,
+		},
+	}
+	tablesToLoad, err := resolveTables(tables, opts.Tables)
+	if err != nil {
+		return nil, err
+	}
+	for _, table := range tables {
+		if tablesToLoad[table.File] && !table.Optional && !fileExists[table.File] {
+			return nil, fmt.Errorf("no %q file in GTFS static feed", table.File)
 		}
-		zipFile := fileNameToFile[table.File]
-		if zipFile == nil {
-			if table.Optional {
-				table.PostProcess()
-				continue
+	}
+	result.Warnings, err = loadTables(tables, tablesToLoad, concurrency, func(table *staticTable) ([]warnings.StaticWarning, error) {
+		var w []warnings.StaticWarning
+		if fileExists[table.File] {
+			var err error
+			if w, err = loadTable(fsys, table, interner, opts.KeepExtraColumns); err != nil {
+				return nil, err
 			}
-			return nil, fmt.Errorf("no %q file in GTFS static feed", table.File)
 		}
-		file, err := openCsvFile(table.File, zipFile)
+		if table.PostProcess != nil {
+			w = append(w, table.PostProcess()...)
+		}
+		return w, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	// The handler error is checked once all tables are loaded, as no table depends on stop times.
+	if handlerErr != nil {
+		return nil, handlerErr
+	}
+	// Strict warnings are checked once all tables are loaded so that the error does not depend
+	// on the order in which tables are loaded.
+	for _, w := range result.Warnings {
+		if w.Matches(opts.Strict) || (opts.StrictSeverity != 0 && w.Kind.Severity() >= opts.StrictSeverity) {
+			return nil, fmt.Errorf("strict parsing: %w", w)
+		}
+	}
+	return result, nil
+}
+
+type staticTable struct {
+	File constants.StaticFile
+	// Tables whose entities are referenced by this table, and so must be parsed first.
+	Dependencies []constants.StaticFile
+	Action       func(file *csv.File) []warnings.StaticWarning
+	// RawAction is used instead of Action for files that are not CSV files.
+	RawAction func(content io.Reader) []warnings.StaticWarning
+	// PostProcess is run after the file is parsed, or instead of parsing the file if it is
+	// optional and does not exist.
+	PostProcess func() []warnings.StaticWarning
+	Optional    bool
+}
+
+// loadTables loads the tables using the load function.
+//
+// A table is loaded once the tables it depends on are loaded, and at most concurrency tables
+// are loaded at the same time. The warnings are returned in the order of the tables,
+// irrespective of the order in which the tables are loaded. If loading a table fails,
+// tables that have not started loading are skipped.
+func loadTables(tables []staticTable, tablesToLoad map[constants.StaticFile]bool, concurrency int,
+	load func(table *staticTable) ([]warnings.StaticWarning, error)) ([]warnings.StaticWarning, error) {
+	fileToDone := map[constants.StaticFile]chan struct{}{}
+	for _, table := range tables {
+		fileToDone[table.File] = make(chan struct{})
+	}
+	tableWarnings := make([][]warnings.StaticWarning, len(tables))
+	tableErrs := make([]error, len(tables))
+	semaphore := make(chan struct{}, concurrency)
+	var failed atomic.Bool
+	var wg sync.WaitGroup
+	for i := range tables {
+		table := &tables[i]
+		if !tablesToLoad[table.File] {
+			close(fileToDone[table.File])
+			continue
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer close(fileToDone[table.File])
+			for _, dependency := range table.Dependencies {
+				<-fileToDone[dependency]
+			}
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+			if failed.Load() {
+				return
+			}
+			tableWarnings[i], tableErrs[i] = load(table)
+			if tableErrs[i] != nil {
+				failed.Store(true)
+			}
+		}(i)
+	}
+	wg.Wait()
+	var w []warnings.StaticWarning
+	for i := range tables {
+		if tableErrs[i] != nil {
+			return nil, tableErrs[i]
+		}
+		w = append(w, tableWarnings[i]...)
+	}
+	return w, nil
+}
+
+// loadTable parses the file of the table.
+func loadTable(fsys fs.FS, table *staticTable, interner *csv.Interner, keepExtraColumns bool) ([]warnings.StaticWarning, error) {
+	if table.RawAction != nil {
+		content, err := fsys.Open(string(table.File))
 		if err != nil {
 			return nil, fmt.Errorf("failed to read %q: %w", table.File, err)
 		}
-		w := table.Action(file)
-		table.PostProcess()
-		result.Warnings = append(result.Warnings, w...)
-		if err := file.Close(); err != nil {
+		w := table.RawAction(content)
+		if err := content.Close(); err != nil {
 			return nil, fmt.Errorf("failed to read %q: %w", table.File, err)
 		}
+		return w, nil
 	}
-	return result, nil
+	file, err := openCsvFile(fsys, table.File)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %q: %w", table.File, err)
+	}
+	file.SetInterner(interner)
+	file.SetKeepExtraColumns(keepExtraColumns)
+	w := table.Action(file)
+	if err := file.Close(); err != nil {
+		return nil, fmt.Errorf("failed to read %q: %w", table.File, err)
+	}
+	return w, nil
 }
 
-func openCsvFile(file constants.StaticFile, zipFile *zip.File) (*csv.File, error) {
-	content, err := zipFile.Open()
+// resolveTables returns the set of tables that need to be parsed in order to load the requested
+// tables: the requested tables and, recursively, their dependencies.
+// If no tables are requested, all tables are loaded.
+func resolveTables(tables []staticTable, requested []constants.StaticFile) (map[constants.StaticFile]bool, error) {
+	fileToTable := map[constants.StaticFile]*staticTable{}
+	for i := range tables {
+		fileToTable[tables[i].File] = &tables[i]
+	}
+	toLoad := map[constants.StaticFile]bool{}
+	if len(requested) == 0 {
+		for _, table := range tables {
+			toLoad[table.File] = true
+		}
+		return toLoad, nil
+	}
+	var visit func(file constants.StaticFile) error
+	visit = func(file constants.StaticFile) error {
+		if toLoad[file] {
+			return nil
+		}
+		table, ok := fileToTable[file]
+		if !ok {
+			return fmt.Errorf("unknown GTFS static table %q", file)
+		}
+		toLoad[file] = true
+		for _, dependency := range table.Dependencies {
+			if err := visit(dependency); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, file := range requested {
+		if err := visit(file); err != nil {
+			return nil, err
+		}
+	}
+	return toLoad, nil
+}
+
+// feedRoot returns the directory of the file system that contains the feed.
+//
+// Feeds are sometimes published as zip files in which all of the files are inside a single
+// top-level directory. In this case the root contains no agency.txt file and the directory
+// is returned. Directories created by archiving tools, like __MACOSX, are ignored.
+func feedRoot(fsys fs.FS) (fs.FS, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+	var dirs []string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			if entry.Name() == string(constants.AgencyFile) {
+				return fsys, nil
+			}
+			continue
+		}
+		if entry.Name() == "__MACOSX" || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		dirs = append(dirs, entry.Name())
+	}
+	if len(dirs) != 1 {
+		return fsys, nil
+	}
+	return fs.Sub(fsys, dirs[0])
+}
+
+func openCsvFile(fsys fs.FS, file constants.StaticFile) (*csv.File, error) {
+	content, err := fsys.Open(string(file))
 	if err != nil {
 		return nil, err
 	}
@@ -341,6 +940,7 @@ func parseAgencies(csv *csv.File) ([]Agency, []warnings.StaticWarning) {
 			Phone:    phoneColumn.Read(),
 			FareUrl:  fareUrlColumn.Read(),
 			Email:    emailColumn.Read(),
+			Extra:    csv.ExtraColumns(),
 		}
 		if missingKeys := csv.MissingRowKeys(); len(missingKeys) > 0 {
 			w = append(w, warnings.NewStaticWarning(csv, warnings.AgencyMissingValues{
@@ -354,7 +954,12 @@ func parseAgencies(csv *csv.File) ([]Agency, []warnings.StaticWarning) {
 	return agencies, w
 }
 
-func parseRoutes(csv *csv.File, agencies []Agency) []Route {
+// parseRoutes parses the routes.txt file.
+//
+// If the file has a network_id column, the second return value maps route IDs to network IDs.
+// Otherwise it is nil.
+func parseRoutes(csv *csv.File, agencies []Agency) ([]Route, map[string]string, []warnings.StaticWarning) {
+	var w []warnings.StaticWarning
 	idColumn := csv.RequiredColumn("route_id")
 	agencyIDColumn := csv.OptionalColumn("agency_id")
 	colorColumn := csv.OptionalColumn("route_color")
@@ -367,13 +972,18 @@ func parseRoutes(csv *csv.File, agencies []Agency) []Route {
 	sortOrderColumn := csv.OptionalColumn("route_sort_order")
 	continuousPickupColumn := csv.OptionalColumn("continuous_pickup")
 	continuousDropOffColumn := csv.OptionalColumn("continuous_drop_off")
+	networkIDColumn := csv.OptionalColumn("network_id")
 
-	if err := csv.MissingRequiredColumns(); err != nil {
-		fmt.Println(err)
-		return nil
+	if warnings := checkForMissingColumns(csv); len(warnings) > 0 {
+		return nil, nil, warnings
 	}
 
 	var routes []Route
+	routeIDs := map[string]bool{}
+	var routeIDToNetworkID map[string]string
+	if networkIDColumn.Exists() {
+		routeIDToNetworkID = map[string]string{}
+	}
 	for csv.NextRow() {
 		routeID := idColumn.Read()
 		agencyID := agencyIDColumn.Read()
@@ -386,7 +996,7 @@ func parseRoutes(csv *csv.File, agencies []Agency) []Route {
 				}
 			}
 			if agency == nil {
-				log.Printf("skipping route %s: no match for agency ID %s", routeID, agencyID)
+				w = append(w, warnings.NewStaticWarning(csv, warnings.InvalidAgencyReference{AgencyID: agencyID}))
 				continue
 			}
 		} else if len(agencies) == 1 {
@@ -394,7 +1004,7 @@ func parseRoutes(csv *csv.File, agencies []Agency) []Route {
 			// which case the route's agency is the unique agency in the feed.
 			agency = &agencies[0]
 		} else {
-			log.Printf("skipping route %s: no agency ID provided but no unique agency", routeID)
+			w = append(w, warnings.NewStaticWarning(csv, warnings.InvalidAgencyReference{}))
 			continue
 		}
 		route := Route{
@@ -408,16 +1018,25 @@ func parseRoutes(csv *csv.File, agencies []Agency) []Route {
 			Type:              parseRouteType_GTFSStatic(routeTypeColumn.Read()),
 			Url:               urlColumn.Read(),
 			SortOrder:         parseRouteSortOrder(sortOrderColumn.Read()),
-			ContinuousPickup:  parsePickupDropOffPolicy(continuousPickupColumn.ReadOr("")),
-			ContinuousDropOff: parsePickupDropOffPolicy(continuousDropOffColumn.ReadOr("")),
+			ContinuousPickup:  parsePickupDropOffPolicy(continuousPickupColumn.Read(), PickupDropOffPolicy_No),
+			ContinuousDropOff: parsePickupDropOffPolicy(continuousDropOffColumn.Read(), PickupDropOffPolicy_No),
+			Extra:             csv.ExtraColumns(),
 		}
 		if missingKeys := csv.MissingRowKeys(); len(missingKeys) > 0 {
-			log.Printf("Skipping route %+v because of missing keys %s", route, missingKeys)
+			w = append(w, warnings.NewStaticWarning(csv, warnings.MissingValues{Columns: missingKeys}))
+			continue
+		}
+		if routeIDs[routeID] {
+			w = append(w, warnings.NewStaticWarning(csv, warnings.DuplicateID{Column: "route_id", ID: routeID}))
 			continue
 		}
+		routeIDs[routeID] = true
+		if networkID := networkIDColumn.Read(); networkID != "" {
+			routeIDToNetworkID[routeID] = networkID
+		}
 		routes = append(routes, route)
 	}
-	return routes
+	return routes, routeIDToNetworkID, w
 }
 
 func parseRouteSortOrder(raw string) *int32 {
@@ -432,36 +1051,37 @@ func parseRouteSortOrder(raw string) *int32 {
 	return &i32
 }
 
-func parseStops(csv *csv.File, inheritWheelchairBoarding bool) []Stop {
+func parseStops(csv *csv.File, levels []Level, inheritWheelchairBoarding bool) ([]Stop, []warnings.StaticWarning) {
+	var w []warnings.StaticWarning
 	idColumn := csv.RequiredColumn("stop_id")
 	codeColumn := csv.OptionalColumn("stop_code")
-	nameColumn := csv.OptionalColumn("stop_name")
+	nameColumn := csv.OptionalColumn("stop_name").Interned()
 	descriptionColumn := csv.OptionalColumn("stop_desc")
-	zoneIdColumn := csv.OptionalColumn("zone_id")
+	zoneIdColumn := csv.OptionalColumn("zone_id").Interned()
 	longitudeColumn := csv.OptionalColumn("stop_lon")
 	latitudeColumn := csv.OptionalColumn("stop_lat")
 	urlColumn := csv.OptionalColumn("stop_url")
 	typeColumn := csv.OptionalColumn("location_type")
-	timezoneColumn := csv.OptionalColumn("stop_timezone")
+	timezoneColumn := csv.OptionalColumn("stop_timezone").Interned()
 	wheelchairBoardingColumn := csv.OptionalColumn("wheelchair_boarding")
-	platformCodeColumn := csv.OptionalColumn("platform_code")
+	platformCodeColumn := csv.OptionalColumn("platform_code").Interned()
 	parentStationColumn := csv.OptionalColumn("parent_station")
+	levelIdColumn := csv.OptionalColumn("level_id")
 
-	if err := csv.MissingRequiredColumns(); err != nil {
-		fmt.Println(err)
-		return nil
+	if warnings := checkForMissingColumns(csv); len(warnings) > 0 {
+		return nil, warnings
 	}
 
+	levelIdToLevel := map[string]*Level{}
+	for i := range levels {
+		levelIdToLevel[levels[i].Id] = &levels[i]
+	}
 	var stops []Stop
 	stopIdToIndex := map[string]int{}
 	stopIdToParent := map[string]string{}
 	for csv.NextRow() {
 		stopID := idColumn.Read()
-		hasParentStop := false
-		if parentStopId := parentStationColumn.Read(); parentStopId != "" {
-			stopIdToParent[stopID] = parentStopId
-			hasParentStop = true
-		}
+		parentStopId := parentStationColumn.Read()
 		stop := Stop{
 			Id:                 stopID,
 			Code:               codeColumn.Read(),
@@ -471,15 +1091,31 @@ func parseStops(csv *csv.File, inheritWheelchairBoarding bool) []Stop {
 			Longitude:          parseFloat64(longitudeColumn.Read()),
 			Latitude:           parseFloat64(latitudeColumn.Read()),
 			Url:                urlColumn.Read(),
-			Type:               parseStopType(typeColumn.Read(), hasParentStop),
+			Type:               parseStopType(typeColumn.Read(), parentStopId != ""),
 			Timezone:           timezoneColumn.Read(),
 			WheelchairBoarding: parseWheelchairBoarding(wheelchairBoardingColumn.Read()),
 			PlatformCode:       platformCodeColumn.Read(),
+			Extra:              csv.ExtraColumns(),
 		}
 		if missingKeys := csv.MissingRowKeys(); len(missingKeys) > 0 {
-			log.Printf("Skipping stop %+v because of missing keys %s", stop, missingKeys)
+			w = append(w, warnings.NewStaticWarning(csv, warnings.MissingValues{Columns: missingKeys}))
 			continue
 		}
+		if _, ok := stopIdToIndex[stop.Id]; ok {
+			w = append(w, warnings.NewStaticWarning(csv, warnings.DuplicateID{Column: "stop_id", ID: stop.Id}))
+			continue
+		}
+		if levelId := levelIdColumn.Read(); levelId != "" {
+			level, ok := levelIdToLevel[levelId]
+			if !ok {
+				// The stop is kept without a level.
+				w = append(w, warnings.NewStaticWarning(csv, warnings.InvalidLevelReference{LevelID: levelId}))
+			}
+			stop.Level = level
+		}
+		if parentStopId != "" {
+			stopIdToParent[stopID] = parentStopId
+		}
 		stopIdToIndex[stop.Id] = len(stops)
 		stops = append(stops, stop)
 	}
@@ -501,7 +1137,7 @@ func parseStops(csv *csv.File, inheritWheelchairBoarding bool) []Stop {
 		}
 	}
 
-	return stops
+	return stops, w
 }
 
 func parseFloat64(s string) *float64 {
@@ -515,51 +1151,73 @@ func parseFloat64(s string) *float64 {
 	return &f
 }
 
-func parseTransfers(csv *csv.File, stops []Stop) []Transfer {
-	fromStopIDColumn := csv.RequiredColumn("from_stop_id")
-	toStopIDColumn := csv.RequiredColumn("to_stop_id")
+func parseTransfers(csv *csv.File, stops []Stop, routes []Route, trips []ScheduledTrip) ([]Transfer, []warnings.StaticWarning) {
+	var w []warnings.StaticWarning
+	fromStopIDColumn := csv.OptionalColumn("from_stop_id")
+	toStopIDColumn := csv.OptionalColumn("to_stop_id")
+	fromRouteIDColumn := csv.OptionalColumn("from_route_id")
+	toRouteIDColumn := csv.OptionalColumn("to_route_id")
+	fromTripIDColumn := csv.OptionalColumn("from_trip_id")
+	toTripIDColumn := csv.OptionalColumn("to_trip_id")
 	typeColumn := csv.OptionalColumn("transfer_type")
 	transferTimeColumn := csv.OptionalColumn("min_transfer_time")
 
-	if err := csv.MissingRequiredColumns(); err != nil {
-		fmt.Println(err)
-		return nil
-	}
-
 	stopIdToStop := map[string]*Stop{}
 	for i := range stops {
 		stopIdToStop[stops[i].Id] = &stops[i]
 	}
+	routeIdToRoute := map[string]*Route{}
+	for i := range routes {
+		routeIdToRoute[routes[i].Id] = &routes[i]
+	}
+	tripIdToTrip := map[string]*ScheduledTrip{}
+	for i := range trips {
+		tripIdToTrip[trips[i].ID] = &trips[i]
+	}
 	var transfers []Transfer
 	for csv.NextRow() {
+		transfer := Transfer{
+			Type:            parseTransferType(typeColumn.Read()),
+			MinTransferTime: parseInt32(transferTimeColumn.Read()),
+			Extra:           csv.ExtraColumns(),
+		}
 		fromStopID := fromStopIDColumn.Read()
 		toStopID := toStopIDColumn.Read()
-		if missingKeys := csv.MissingRowKeys(); len(missingKeys) > 0 {
-			log.Printf("Skipping transfer because of missing keys %s", missingKeys)
-			continue
-		}
-		fromStop, fromStopOk := stopIdToStop[fromStopID]
-		toStop, toStopOk := stopIdToStop[toStopID]
-		if !fromStopOk {
-			log.Printf("Skipping transfer because from_stop_id %q is invalid", fromStopID)
-			continue
+		fromTripID := fromTripIDColumn.Read()
+		toTripID := toTripIDColumn.Read()
+		// Stops are required for regular transfers, and trips are required for in-seat transfers.
+		var missingKeys []string
+		for _, c := range []struct {
+			column string
+			value  string
+		}{
+			{"from_stop_id", fromStopID},
+			{"to_stop_id", toStopID},
+			{"from_trip_id", fromTripID},
+			{"to_trip_id", toTripID},
+		} {
+			isStopColumn := c.column == "from_stop_id" || c.column == "to_stop_id"
+			if c.value == "" && isStopColumn != transfer.Type.IsInSeat() {
+				missingKeys = append(missingKeys, c.column)
+			}
 		}
-		if !toStopOk {
-			log.Printf("Skipping transfer because to_stop_id %q is invalid", toStopID)
+		if len(missingKeys) > 0 {
+			w = append(w, warnings.NewStaticWarning(csv, warnings.MissingValues{Columns: missingKeys}))
 			continue
 		}
-		if fromStop.Id == toStop.Id {
-			// log.Printf("Skipping transfer between the same stop %q", fromStop.Id)
+		var fromStopOk, toStopOk, fromRouteOk, toRouteOk, fromTripOk, toTripOk bool
+		transfer.From, fromStopOk = readReference(csv, fromStopIDColumn, stopIdToStop, invalidStopReference, &w)
+		transfer.To, toStopOk = readReference(csv, toStopIDColumn, stopIdToStop, invalidStopReference, &w)
+		transfer.FromRoute, fromRouteOk = readReference(csv, fromRouteIDColumn, routeIdToRoute, invalidRouteReference, &w)
+		transfer.ToRoute, toRouteOk = readReference(csv, toRouteIDColumn, routeIdToRoute, invalidRouteReference, &w)
+		transfer.FromTrip, fromTripOk = readReference(csv, fromTripIDColumn, tripIdToTrip, invalidTripReference, &w)
+		transfer.ToTrip, toTripOk = readReference(csv, toTripIDColumn, tripIdToTrip, invalidTripReference, &w)
+		if !fromStopOk || !toStopOk || !fromRouteOk || !toRouteOk || !fromTripOk || !toTripOk {
 			continue
 		}
-		transfers = append(transfers, Transfer{
-			From:            fromStop,
-			To:              toStop,
-			Type:            parseTransferType(typeColumn.Read()),
-			MinTransferTime: parseInt32(transferTimeColumn.Read()),
-		})
+		transfers = append(transfers, transfer)
 	}
-	return transfers
+	return transfers, w
 }
 
 func parseInt32(s string) *int32 {
@@ -574,7 +1232,8 @@ func parseInt32(s string) *int32 {
 	return &i32
 }
 
-func parseCalendar(f *csv.File, m map[string]Service, timezone *time.Location) {
+func parseCalendar(f *csv.File, m map[string]Service, timezone *time.Location) []warnings.StaticWarning {
+	var w []warnings.StaticWarning
 	startDateColumn := f.RequiredColumn("start_date")
 	endDateColumn := f.RequiredColumn("end_date")
 	serviceIDColumn := f.RequiredColumn("service_id")
@@ -591,23 +1250,16 @@ func parseCalendar(f *csv.File, m map[string]Service, timezone *time.Location) {
 		dayColumns[i] = f.RequiredColumn(days)
 	}
 
-	if err := f.MissingRequiredColumns(); err != nil {
-		fmt.Println(err)
-		return
+	if warnings := checkForMissingColumns(f); len(warnings) > 0 {
+		return warnings
 	}
 
 	parseBool := func(s string) bool {
 		return s == "1"
 	}
 	for f.NextRow() {
-		startDate, err := parseTime(startDateColumn.Read(), timezone)
-		if err != nil {
-			continue
-		}
-		endDate, err := parseTime(endDateColumn.Read(), timezone)
-		if err != nil {
-			continue
-		}
+		rawStartDate := startDateColumn.Read()
+		rawEndDate := endDateColumn.Read()
 		service := Service{
 			Id:        serviceIDColumn.Read(),
 			Monday:    parseBool(dayColumns[0].Read()),
@@ -617,36 +1269,57 @@ func parseCalendar(f *csv.File, m map[string]Service, timezone *time.Location) {
 			Friday:    parseBool(dayColumns[4].Read()),
 			Saturday:  parseBool(dayColumns[5].Read()),
 			Sunday:    parseBool(dayColumns[6].Read()),
-			StartDate: startDate,
-			EndDate:   endDate,
+			Extra:     f.ExtraColumns(),
 		}
 		if missingKeys := f.MissingRowKeys(); len(missingKeys) > 0 {
-			log.Printf("Skipping calendar because of missing keys %s", missingKeys)
+			w = append(w, warnings.NewStaticWarning(f, warnings.MissingValues{Columns: missingKeys}))
+			continue
+		}
+		var err error
+		if service.StartDate, err = parseTime(rawStartDate, timezone); err != nil {
+			w = append(w, warnings.NewStaticWarning(f, warnings.UnparsableTime{Column: "start_date", Value: rawStartDate}))
+			continue
+		}
+		if service.EndDate, err = parseTime(rawEndDate, timezone); err != nil {
+			w = append(w, warnings.NewStaticWarning(f, warnings.UnparsableTime{Column: "end_date", Value: rawEndDate}))
 			continue
 		}
+		if _, ok := m[service.Id]; ok {
+			w = append(w, warnings.NewStaticWarning(f, warnings.DuplicateID{Column: "service_id", ID: service.Id}))
+			continue
+		}
+		service.CalendarStartDate = service.StartDate
+		service.CalendarEndDate = service.EndDate
 		m[service.Id] = service
 	}
+	return w
 }
 
-func parseCalendarDates(csv *csv.File, m map[string]Service, timezone *time.Location) {
+func parseCalendarDates(csv *csv.File, m map[string]Service, timezone *time.Location) []warnings.StaticWarning {
+	var w []warnings.StaticWarning
 	serviceIDColumn := csv.RequiredColumn("service_id")
 	dateColumn := csv.RequiredColumn("date")
 	exceptionTypeColumn := csv.RequiredColumn("exception_type")
 
-	if err := csv.MissingRequiredColumns(); err != nil {
-		fmt.Println(err)
-		return
+	if warnings := checkForMissingColumns(csv); len(warnings) > 0 {
+		return warnings
 	}
 
 	for csv.NextRow() {
 		serviceId := serviceIDColumn.Read()
-		date, err := parseTime(dateColumn.Read(), timezone)
+		rawDate := dateColumn.Read()
+		exceptionType := exceptionTypeColumn.Read()
+		if missingKeys := csv.MissingRowKeys(); len(missingKeys) > 0 {
+			w = append(w, warnings.NewStaticWarning(csv, warnings.MissingValues{Columns: missingKeys}))
+			continue
+		}
+		date, err := parseTime(rawDate, timezone)
 		if err != nil {
+			w = append(w, warnings.NewStaticWarning(csv, warnings.UnparsableTime{Column: "date", Value: rawDate}))
 			continue
 		}
-		exceptionType := exceptionTypeColumn.Read()
-		if missingKeys := csv.MissingRowKeys(); len(missingKeys) > 0 {
-			log.Printf("Skipping calendar because of missing keys %s", missingKeys)
+		if exceptionType != "1" && exceptionType != "2" {
+			w = append(w, warnings.NewStaticWarning(csv, warnings.InvalidValue{Column: "exception_type", Value: exceptionType}))
 			continue
 		}
 		service, ok := m[serviceId]
@@ -662,37 +1335,35 @@ func parseCalendarDates(csv *csv.File, m map[string]Service, timezone *time.Loca
 				service.EndDate = date
 			}
 		}
-		switch exceptionType {
-		case "1":
+		if exceptionType == "1" {
 			service.AddedDates = append(service.AddedDates, date)
-		case "2":
+		} else {
 			service.RemovedDates = append(service.RemovedDates, date)
-		default:
-			continue
 		}
 		m[service.Id] = service
 	}
+	return w
 }
 
 func parseTime(s string, timezone *time.Location) (time.Time, error) {
 	return time.ParseInLocation("20060102", s, timezone)
 }
 
-func parseScheduledTrips(csv *csv.File, routes []Route, services []Service, shapeIDToShape map[string]*Shape) []ScheduledTrip {
+func parseScheduledTrips(csv *csv.File, routes []Route, services []Service, shapeIDToShape map[string]*Shape) ([]ScheduledTrip, []warnings.StaticWarning) {
+	var w []warnings.StaticWarning
 	routeIDColumn := csv.RequiredColumn("route_id")
 	serviceIDColumn := csv.RequiredColumn("service_id")
 	tripIDColumn := csv.RequiredColumn("trip_id")
-	tripHeadsignColumn := csv.OptionalColumn("trip_headsign")
-	tripShortNameColumn := csv.OptionalColumn("trip_short_name")
+	tripHeadsignColumn := csv.OptionalColumn("trip_headsign").Interned()
+	tripShortNameColumn := csv.OptionalColumn("trip_short_name").Interned()
 	directionIDColumn := csv.OptionalColumn("direction_id")
-	blockIDColumn := csv.OptionalColumn("block_id")
+	blockIDColumn := csv.OptionalColumn("block_id").Interned()
 	wheelchairAccessibleColumn := csv.OptionalColumn("wheelchair_accessible")
 	bikesAllowedColumn := csv.OptionalColumn("bikes_allowed")
 	shapeIDColumn := csv.OptionalColumn("shape_id")
 
-	if err := csv.MissingRequiredColumns(); err != nil {
-		fmt.Println(err)
-		return nil
+	if warnings := checkForMissingColumns(csv); len(warnings) > 0 {
+		return nil, warnings
 	}
 
 	idToService := map[string]*Service{}
@@ -704,10 +1375,11 @@ func parseScheduledTrips(csv *csv.File, routes []Route, services []Service, shap
 		idToRoute[routes[i].Id] = &routes[i]
 	}
 	var trips []ScheduledTrip
+	tripIDs := map[string]bool{}
 	for csv.NextRow() {
+		routeID := routeIDColumn.Read()
+		serviceID := serviceIDColumn.Read()
 		trip := ScheduledTrip{
-			Route:                idToRoute[routeIDColumn.Read()],
-			Service:              idToService[serviceIDColumn.Read()],
 			ID:                   tripIDColumn.Read(),
 			Headsign:             tripHeadsignColumn.Read(),
 			ShortName:            tripShortNameColumn.Read(),
@@ -715,120 +1387,326 @@ func parseScheduledTrips(csv *csv.File, routes []Route, services []Service, shap
 			BlockID:              blockIDColumn.Read(),
 			WheelchairAccessible: parseWheelchairBoarding(wheelchairAccessibleColumn.Read()),
 			BikesAllowed:         parseBikesAllowed(bikesAllowedColumn.ReadOr("")),
+			Extra:                csv.ExtraColumns(),
 		}
-
-		shapeIDOrNil := shapeIDColumn.Read()
-		if shapeIDOrNil != "" {
-			if shape, ok := shapeIDToShape[shapeIDOrNil]; ok {
-				trip.Shape = shape
-			} else {
-				log.Printf("Shape %s not found for trip %s", shapeIDOrNil, trip.ID)
-			}
-		}
-
 		if missingKeys := csv.MissingRowKeys(); len(missingKeys) > 0 {
-			log.Printf("Skipping trip because of missing keys %s", missingKeys)
+			w = append(w, warnings.NewStaticWarning(csv, warnings.MissingValues{Columns: missingKeys}))
+			continue
+		}
+		var ok bool
+		if trip.Route, ok = idToRoute[routeID]; !ok {
+			w = append(w, warnings.NewStaticWarning(csv, warnings.InvalidRouteReference{RouteID: routeID}))
 			continue
 		}
-		if trip.Route == nil {
-			log.Print("Skipping trip because of missing route")
+		if trip.Service, ok = idToService[serviceID]; !ok {
+			w = append(w, warnings.NewStaticWarning(csv, warnings.InvalidServiceReference{ServiceID: serviceID}))
 			continue
 		}
-		if trip.Service == nil {
-			log.Print("Skipping trip because of missing service")
+		if tripIDs[trip.ID] {
+			w = append(w, warnings.NewStaticWarning(csv, warnings.DuplicateID{Column: "trip_id", ID: trip.ID}))
 			continue
 		}
+		if shapeID := shapeIDColumn.Read(); shapeID != "" {
+			if trip.Shape, ok = shapeIDToShape[shapeID]; !ok {
+				// The trip is kept without a shape.
+				w = append(w, warnings.NewStaticWarning(csv, warnings.InvalidShapeReference{ShapeID: shapeID}))
+			}
+		}
+		tripIDs[trip.ID] = true
 		trips = append(trips, trip)
 	}
-	return trips
+	return trips, w
 }
 
-func parseScheduledStopTimes(csv *csv.File, stops []Stop, trips []ScheduledTrip) {
-	stopIDColumn := csv.RequiredColumn("stop_id")
+// stopTimeReferences contains lookup tables for the entities that stop times refer to.
+type stopTimeReferences struct {
+	idToStop map[string]*Stop
+	idToTrip map[string]*ScheduledTrip
+	flex     *flexReferences
+}
+
+func newStopTimeReferences(static *Static) *stopTimeReferences {
+	refs := &stopTimeReferences{
+		idToStop: map[string]*Stop{},
+		idToTrip: map[string]*ScheduledTrip{},
+		flex:     newFlexReferences(static),
+	}
+	for i := range static.Stops {
+		refs.idToStop[static.Stops[i].Id] = &static.Stops[i]
+	}
+	for i := range static.Trips {
+		refs.idToTrip[static.Trips[i].ID] = &static.Trips[i]
+	}
+	return refs
+}
+
+// Number of rows of the stop_times.txt file in each chunk that is parsed by a worker.
+const stopTimesChunkSize = 10000
+
+// parseScheduledStopTimesConcurrently splits the rows of the stop_times.txt file into chunks
+// that are parsed by up to concurrency workers.
+//
+// As in parseScheduledStopTimes, the stop times are added in the order of the file.
+func parseScheduledStopTimesConcurrently(file *csv.File, refs *stopTimeReferences, concurrency int, add func(trip *ScheduledTrip, stopTime ScheduledStopTime) error) ([]warnings.StaticWarning, error) {
+	// If columns are missing the file is parsed in one piece, so that the problem is reported once.
+	if concurrency <= 1 || !hasStopTimesColumns(file) {
+		return parseScheduledStopTimes(file, refs, add)
+	}
+	type chunkResult struct {
+		stopTimes []ScheduledStopTime
+		warnings  []warnings.StaticWarning
+	}
+	// The results of the chunks are received in the order of the chunks.
+	results := make(chan chan chunkResult, concurrency)
+	stop := make(chan struct{})
+	go func() {
+		defer close(results)
+		for {
+			chunk, ok := file.NextChunk(stopTimesChunkSize)
+			if !ok {
+				return
+			}
+			result := make(chan chunkResult, 1)
+			select {
+			case results <- result:
+			case <-stop:
+				return
+			}
+			go func() {
+				var r chunkResult
+				r.warnings, _ = parseScheduledStopTimes(chunk, refs, func(_ *ScheduledTrip, stopTime ScheduledStopTime) error {
+					r.stopTimes = append(r.stopTimes, stopTime)
+					return nil
+				})
+				result <- r
+			}()
+		}
+	}()
+	var w []warnings.StaticWarning
+	var err error
+	for result := range results {
+		r := <-result
+		if err != nil {
+			continue
+		}
+		w = append(w, r.warnings...)
+		for _, stopTime := range r.stopTimes {
+			if err = add(stopTime.Trip, stopTime); err != nil {
+				// Stop reading the file. The loop continues until the chunks being parsed are done.
+				close(stop)
+				break
+			}
+		}
+	}
+	return w, err
+}
+
+func hasStopTimesColumns(file *csv.File) bool {
+	for _, column := range []string{"trip_id", "stop_sequence"} {
+		if !file.OptionalColumn(column).Exists() {
+			return false
+		}
+	}
+	for _, column := range []string{"stop_id", "location_group_id", "location_id"} {
+		if file.OptionalColumn(column).Exists() {
+			return true
+		}
+	}
+	return false
+}
+
+// parseScheduledStopTimes parses the stop_times.txt file, passing each stop time and the trip it
+// belongs to to the add function. Parsing stops if the add function returns an error.
+func parseScheduledStopTimes(csv *csv.File, refs *stopTimeReferences, add func(trip *ScheduledTrip, stopTime ScheduledStopTime) error) ([]warnings.StaticWarning, error) {
+	var w []warnings.StaticWarning
+	stopIDColumn := csv.OptionalColumn("stop_id")
+	locationGroupIDColumn := csv.OptionalColumn("location_group_id")
+	locationIDColumn := csv.OptionalColumn("location_id")
 	stopSequenceKey := csv.RequiredColumn("stop_sequence")
 	tripIDColumn := csv.RequiredColumn("trip_id")
 	arrivalTimeColumn := csv.OptionalColumn("arrival_time")
 	departureTimeColumn := csv.OptionalColumn("departure_time")
-	stopHeadsignColumn := csv.OptionalColumn("stop_headsign")
+	startPickupDropOffWindowColumn := csv.OptionalColumn("start_pickup_drop_off_window")
+	endPickupDropOffWindowColumn := csv.OptionalColumn("end_pickup_drop_off_window")
+	stopHeadsignColumn := csv.OptionalColumn("stop_headsign").Interned()
 	pickupTypeColumn := csv.OptionalColumn("pickup_type")
 	dropOffTypeColumn := csv.OptionalColumn("drop_off_type")
 	continuousPickupColumn := csv.OptionalColumn("continuous_pickup")
 	continuousDropOffColumn := csv.OptionalColumn("continuous_drop_off")
 	shapeDistanceTraveledColumn := csv.OptionalColumn("shape_dist_traveled")
 	timepointColumn := csv.OptionalColumn("timepoint")
-	if err := csv.MissingRequiredColumns(); err != nil {
-		fmt.Println(err)
-		return
-	}
-
-	idToStop := map[string]*Stop{}
-	for i := range stops {
-		idToStop[stops[i].Id] = &stops[i]
+	pickupBookingRuleIDColumn := csv.OptionalColumn("pickup_booking_rule_id")
+	dropOffBookingRuleIDColumn := csv.OptionalColumn("drop_off_booking_rule_id")
+	if warnings := checkForMissingColumns(csv); len(warnings) > 0 {
+		return warnings, nil
 	}
-	idToTrip := map[string]*ScheduledTrip{}
-	for i := range trips {
-		idToTrip[trips[i].ID] = &trips[i]
+	if !stopIDColumn.Exists() && !locationGroupIDColumn.Exists() && !locationIDColumn.Exists() {
+		return []warnings.StaticWarning{
+			warnings.NewStaticWarning(csv, warnings.MissingColumns{Columns: []string{"stop_id"}}),
+		}, nil
 	}
+
+	idToStop := refs.idToStop
+	idToTrip := refs.idToTrip
+	flex := refs.flex
 	var currentTrip *ScheduledTrip
 	var currentTripID string
+rows:
 	for csv.NextRow() {
-		arrival, arrivalOk := parseGtfsTimeToDuration(arrivalTimeColumn.Read())
-		departure, departureOk := parseGtfsTimeToDuration(departureTimeColumn.Read())
-		if !arrivalOk && !departureOk {
+		var arrival, departure, windowStart, windowEnd time.Duration
+		var arrivalOk, departureOk, windowStartOk, windowEndOk bool
+		for _, c := range []struct {
+			column string
+			value  string
+			out    *time.Duration
+			ok     *bool
+		}{
+			{"arrival_time", arrivalTimeColumn.Read(), &arrival, &arrivalOk},
+			{"departure_time", departureTimeColumn.Read(), &departure, &departureOk},
+			{"start_pickup_drop_off_window", startPickupDropOffWindowColumn.Read(), &windowStart, &windowStartOk},
+			{"end_pickup_drop_off_window", endPickupDropOffWindowColumn.Read(), &windowEnd, &windowEndOk},
+		} {
+			if c.value == "" {
+				continue
+			}
+			if *c.out, *c.ok = parseGtfsTimeToDuration(c.value); !*c.ok {
+				w = append(w, warnings.NewStaticWarning(csv, warnings.UnparsableTime{Column: c.column, Value: c.value}))
+				continue rows
+			}
+		}
+		if !arrivalOk && !departureOk && !windowStartOk && !windowEndOk {
+			w = append(w, warnings.NewStaticWarning(csv, warnings.UntimedStopTime{
+				TripID:       tripIDColumn.Read(),
+				StopSequence: stopSequenceKey.Read(),
+			}))
 			continue
 		}
 		if !departureOk {
-			arrival = departure
+			departure = arrival
 		}
 		if !arrivalOk {
-			departure = arrival
+			arrival = departure
 		}
-		stopSequence, err := strconv.Atoi(stopSequenceKey.Read())
-		if err != nil {
-			// TODO: log a warning
+		rawStopSequence := stopSequenceKey.Read()
+		stopSequence, err := strconv.Atoi(rawStopSequence)
+		if err != nil && rawStopSequence != "" {
+			w = append(w, warnings.NewStaticWarning(csv, warnings.InvalidStopSequence{Value: rawStopSequence}))
 			continue
 		}
 		stopTime := ScheduledStopTime{
-			Stop:                  idToStop[stopIDColumn.Read()],
 			Headsign:              stopHeadsignColumn.Read(),
 			ArrivalTime:           arrival,
 			StopSequence:          stopSequence,
 			DepartureTime:         departure,
-			PickupType:            parsePickupDropOffPolicy(pickupTypeColumn.ReadOr("")),
-			DropOffType:           parsePickupDropOffPolicy(dropOffTypeColumn.ReadOr("")),
-			ContinuousPickup:      parsePickupDropOffPolicy(continuousPickupColumn.ReadOr("")),
-			ContinuousDropOff:     parsePickupDropOffPolicy(continuousDropOffColumn.ReadOr("")),
+			PickupType:            parsePickupDropOffPolicy(pickupTypeColumn.Read(), PickupDropOffPolicy_Yes),
+			DropOffType:           parsePickupDropOffPolicy(dropOffTypeColumn.Read(), PickupDropOffPolicy_Yes),
+			ContinuousPickup:      parsePickupDropOffPolicy(continuousPickupColumn.Read(), PickupDropOffPolicy_No),
+			ContinuousDropOff:     parsePickupDropOffPolicy(continuousDropOffColumn.Read(), PickupDropOffPolicy_No),
 			ShapeDistanceTraveled: parseFloat64(shapeDistanceTraveledColumn.Read()),
 			ExactTimes:            timepointColumn.ReadOr("1") == "1",
+			Extra:                 csv.ExtraColumns(),
+		}
+		if windowStartOk {
+			stopTime.StartPickupDropOffWindow = &windowStart
+		}
+		if windowEndOk {
+			stopTime.EndPickupDropOffWindow = &windowEnd
 		}
 		tripID := tripIDColumn.Read()
 		if currentTrip == nil || currentTripID != tripID {
-			thisTrip := idToTrip[tripID]
-			if currentTrip != nil && cap(thisTrip.StopTimes) == 0 {
-				thisTrip.StopTimes = make([]ScheduledStopTime, 0, len(currentTrip.StopTimes))
-			}
-			currentTrip = thisTrip
+			currentTrip = idToTrip[tripID]
 			currentTripID = tripID
 		}
 		if missingKeys := csv.MissingRowKeys(); len(missingKeys) > 0 {
-			log.Printf("Skipping stop time because of missing keys %s", missingKeys)
+			w = append(w, warnings.NewStaticWarning(csv, warnings.MissingValues{Columns: missingKeys}))
 			continue
 		}
-		if stopTime.Stop == nil {
+		stopID := stopIDColumn.Read()
+		locationGroupID := locationGroupIDColumn.Read()
+		locationID := locationIDColumn.Read()
+		var numLocationColumns int
+		for _, id := range []string{stopID, locationGroupID, locationID} {
+			if id != "" {
+				numLocationColumns++
+			}
+		}
+		if numLocationColumns == 0 {
+			w = append(w, warnings.NewStaticWarning(csv, warnings.MissingValues{Columns: []string{"stop_id"}}))
+			continue
+		}
+		if numLocationColumns > 1 {
+			w = append(w, warnings.NewStaticWarning(csv, warnings.MutuallyExclusiveColumns{
+				Columns: []string{"stop_id", "location_group_id", "location_id"},
+			}))
 			continue
 		}
+		var ok bool
+		switch {
+		case stopID != "":
+			if stopTime.Stop, ok = idToStop[stopID]; !ok {
+				w = append(w, warnings.NewStaticWarning(csv, warnings.InvalidStopReference{StopID: stopID}))
+				continue
+			}
+		case locationGroupID != "":
+			if stopTime.LocationGroup, ok = flex.idToLocationGroup[locationGroupID]; !ok {
+				w = append(w, warnings.NewStaticWarning(csv, warnings.InvalidLocationGroupReference{LocationGroupID: locationGroupID}))
+				continue
+			}
+		case locationID != "":
+			if stopTime.Location, ok = flex.idToLocation[locationID]; !ok {
+				w = append(w, warnings.NewStaticWarning(csv, warnings.InvalidLocationReference{LocationID: locationID}))
+				continue
+			}
+		}
+		for _, c := range []struct {
+			value string
+			out   **BookingRule
+		}{
+			{pickupBookingRuleIDColumn.Read(), &stopTime.PickupBookingRule},
+			{dropOffBookingRuleIDColumn.Read(), &stopTime.DropOffBookingRule},
+		} {
+			if c.value == "" {
+				continue
+			}
+			if *c.out, ok = flex.idToBookingRule[c.value]; !ok {
+				w = append(w, warnings.NewStaticWarning(csv, warnings.InvalidBookingRuleReference{BookingRuleID: c.value}))
+			}
+		}
 		if currentTrip == nil {
+			w = append(w, warnings.NewStaticWarning(csv, warnings.InvalidTripReference{TripID: tripID}))
 			continue
 		}
-		currentTrip.StopTimes = append(currentTrip.StopTimes, stopTime)
+		stopTime.Trip = currentTrip
+		if err := add(currentTrip, stopTime); err != nil {
+			return w, err
+		}
 	}
-	for _, trip := range idToTrip {
-		sort.Slice(trip.StopTimes, func(i, j int) bool {
-			return trip.StopTimes[i].StopSequence < trip.StopTimes[j].StopSequence
-		})
+	return w, nil
+}
+
+// newStopTimeAppender returns a function that appends stop times to their trips.
+func newStopTimeAppender() func(trip *ScheduledTrip, stopTime ScheduledStopTime) error {
+	var previousTrip *ScheduledTrip
+	return func(trip *ScheduledTrip, stopTime ScheduledStopTime) error {
+		if trip != previousTrip {
+			// Trips typically have a similar number of stop times, so we use the size of the
+			// previous trip to avoid repeatedly growing the slice.
+			if previousTrip != nil && cap(trip.StopTimes) == 0 {
+				trip.StopTimes = make([]ScheduledStopTime, 0, len(previousTrip.StopTimes))
+			}
+			previousTrip = trip
+		}
+		trip.StopTimes = append(trip.StopTimes, stopTime)
+		return nil
 	}
 }
 
+func sortStopTimes(stopTimes []ScheduledStopTime) {
+	sort.Slice(stopTimes, func(i, j int) bool {
+		return stopTimes[i].StopSequence < stopTimes[j].StopSequence
+	})
+}
+
 func parseGtfsTimeToDuration(s string) (time.Duration, bool) {
 	if s == "" {
 		return 0, false
@@ -860,18 +1738,19 @@ type ShapeRow struct {
 	ShapePtLon        float64
 	ShapePtSequence   int32
 	ShapeDistTraveled *float64
+	Extra             map[string]string
 }
 
-func parseShapes(csv *csv.File) []Shape {
+func parseShapes(csv *csv.File) ([]Shape, []warnings.StaticWarning) {
+	var w []warnings.StaticWarning
 	shapeIDColumn := csv.RequiredColumn("shape_id")
 	shapePtLatColumn := csv.RequiredColumn("shape_pt_lat")
 	shapePtLonColumn := csv.RequiredColumn("shape_pt_lon")
 	shapePtSequenceColumn := csv.RequiredColumn("shape_pt_sequence")
 	shapeDistTraveled := csv.OptionalColumn("shape_dist_traveled")
 
-	if err := csv.MissingRequiredColumns(); err != nil {
-		fmt.Println(err)
-		return nil
+	if warnings := checkForMissingColumns(csv); len(warnings) > 0 {
+		return nil, warnings
 	}
 
 	shapeIDToRowData := map[string][]ShapeRow{}
@@ -883,7 +1762,25 @@ func parseShapes(csv *csv.File) []Shape {
 		shapeDistTraveled := parseFloat64(shapeDistTraveled.Read())
 
 		if missingKeys := csv.MissingRowKeys(); len(missingKeys) > 0 {
-			log.Printf("Skipping shape because of missing keys %s", missingKeys)
+			w = append(w, warnings.NewStaticWarning(csv, warnings.MissingValues{Columns: missingKeys}))
+			continue
+		}
+		var invalid bool
+		for _, c := range []struct {
+			column string
+			value  string
+			valid  bool
+		}{
+			{"shape_pt_lat", shapePtLatColumn.Read(), shapePtLat != nil},
+			{"shape_pt_lon", shapePtLonColumn.Read(), shapePtLon != nil},
+			{"shape_pt_sequence", shapePtSequenceColumn.Read(), shapePtSequence != nil},
+		} {
+			if !c.valid {
+				w = append(w, warnings.NewStaticWarning(csv, warnings.InvalidValue{Column: c.column, Value: c.value}))
+				invalid = true
+			}
+		}
+		if invalid {
 			continue
 		}
 
@@ -892,6 +1789,7 @@ func parseShapes(csv *csv.File) []Shape {
 			ShapePtLon:        *shapePtLon,
 			ShapePtSequence:   *shapePtSequence,
 			ShapeDistTraveled: shapeDistTraveled,
+			Extra:             csv.ExtraColumns(),
 		})
 	}
 
@@ -908,6 +1806,7 @@ func parseShapes(csv *csv.File) []Shape {
 				Latitude:  row.ShapePtLat,
 				Longitude: row.ShapePtLon,
 				Distance:  row.ShapeDistTraveled,
+				Extra:     row.Extra,
 			})
 		}
 
@@ -922,19 +1821,19 @@ func parseShapes(csv *csv.File) []Shape {
 		return shapes[i].ID < shapes[j].ID
 	})
 
-	return shapes
+	return shapes, w
 }
 
-func parseFrequencies(csv *csv.File, tripIDToScheduledTrip map[string]*ScheduledTrip) {
+func parseFrequencies(csv *csv.File, tripIDToScheduledTrip map[string]*ScheduledTrip) []warnings.StaticWarning {
+	var w []warnings.StaticWarning
 	tripIDColumn := csv.RequiredColumn("trip_id")
 	startTimeColumn := csv.RequiredColumn("start_time")
 	endTimeColumn := csv.RequiredColumn("end_time")
 	headwaySecsColumn := csv.RequiredColumn("headway_secs")
 	exactTimesColumn := csv.OptionalColumn("exact_times")
 
-	if err := csv.MissingRequiredColumns(); err != nil {
-		fmt.Println(err)
-		return
+	if warnings := checkForMissingColumns(csv); len(warnings) > 0 {
+		return warnings
 	}
 
 	for csv.NextRow() {
@@ -944,27 +1843,27 @@ func parseFrequencies(csv *csv.File, tripIDToScheduledTrip map[string]*Scheduled
 		headwaySecs := headwaySecsColumn.Read()
 
 		if missingKeys := csv.MissingRowKeys(); len(missingKeys) > 0 {
-			log.Printf("Skipping frequency because of missing keys %s", missingKeys)
+			w = append(w, warnings.NewStaticWarning(csv, warnings.MissingValues{Columns: missingKeys}))
 			continue
 		}
 		scheduledTripOrNil := tripIDToScheduledTrip[tripID]
 		if scheduledTripOrNil == nil {
-			log.Printf("Skipping frequency because of missing trip %s", tripID)
+			w = append(w, warnings.NewStaticWarning(csv, warnings.InvalidTripReference{TripID: tripID}))
 			continue
 		}
 		headwaySecsOrNil := parseInt32(headwaySecs)
 		if headwaySecsOrNil == nil {
-			log.Print("Skipping frequency because of invalid headway_secs")
+			w = append(w, warnings.NewStaticWarning(csv, warnings.InvalidValue{Column: "headway_secs", Value: headwaySecs}))
 			continue
 		}
 		startTimeDuration, startTimeDurationOk := parseGtfsTimeToDuration(startTime)
 		if !startTimeDurationOk {
-			log.Print("Skipping frequency because of invalid start_time")
+			w = append(w, warnings.NewStaticWarning(csv, warnings.UnparsableTime{Column: "start_time", Value: startTime}))
 			continue
 		}
 		endTimeDuration, endTimeDurationOk := parseGtfsTimeToDuration(endTime)
 		if !endTimeDurationOk {
-			log.Print("Skipping frequency because of invalid end_time")
+			w = append(w, warnings.NewStaticWarning(csv, warnings.UnparsableTime{Column: "end_time", Value: endTime}))
 			continue
 		}
 
@@ -973,10 +1872,12 @@ func parseFrequencies(csv *csv.File, tripIDToScheduledTrip map[string]*Scheduled
 			EndTime:    endTimeDuration,
 			Headway:    time.Duration(*headwaySecsOrNil) * time.Second,
 			ExactTimes: parseExactTimes(exactTimesColumn.Read()),
+			Extra:      csv.ExtraColumns(),
 		}
 
 		scheduledTripOrNil.Frequencies = append(scheduledTripOrNil.Frequencies, frequency)
 	}
+	return w
 }
 
 func checkForMissingColumns(csv *csv.File) []warnings.StaticWarning {
@@ -0,0 +1,76 @@
+package gtfs
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseStaticFS(t *testing.T) {
+	fsys := fstest.MapFS{}
+	for name, content := range newZipBuilderWithDefaults().m {
+		fsys[name] = &fstest.MapFile{Data: []byte(content)}
+	}
+	actual, err := ParseStaticFS(fsys, ParseStaticOptions{})
+	if err != nil {
+		t.Fatalf("error when parsing: %s", err)
+	}
+	expected, err := ParseStatic(newZipBuilderWithDefaults().build(), ParseStaticOptions{})
+	if err != nil {
+		t.Fatalf("error when parsing: %s", err)
+	}
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		t.Errorf("not the same: %s", diff)
+	}
+}
+
+func TestParseStatic_TopLevelDirectory(t *testing.T) {
+	expected, err := ParseStatic(newZipBuilderWithDefaults().build(), ParseStaticOptions{})
+	if err != nil {
+		t.Fatalf("error when parsing: %s", err)
+	}
+	for _, tc := range []struct {
+		desc  string
+		extra map[string]string
+	}{
+		{
+			desc: "single directory",
+		},
+		{
+			desc: "directory created by archiving tool",
+			extra: map[string]string{
+				"__MACOSX/feed/._agency.txt": "",
+				"README.txt":                 "not a GTFS file",
+			},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			z := newZipBuilder()
+			z.m = map[string]string{}
+			for name, content := range newZipBuilderWithDefaults().m {
+				z.add("feed/"+name, content)
+			}
+			for name, content := range tc.extra {
+				z.add(name, content)
+			}
+			actual, err := ParseStatic(z.build(), ParseStaticOptions{})
+			if err != nil {
+				t.Fatalf("error when parsing: %s", err)
+			}
+			if diff := cmp.Diff(expected, actual); diff != "" {
+				t.Errorf("not the same: %s", diff)
+			}
+		})
+	}
+}
+
+func TestParseStaticFS_MultipleDirectories(t *testing.T) {
+	fsys := fstest.MapFS{
+		"feed_1/agency.txt": &fstest.MapFile{},
+		"feed_2/agency.txt": &fstest.MapFile{},
+	}
+	if _, err := ParseStaticFS(fsys, ParseStaticOptions{}); err == nil {
+		t.Errorf("expected an error when the feed is ambiguous")
+	}
+}
@@ -3,7 +3,10 @@ package gtfs
 import (
 	"archive/zip"
 	"bytes"
+	"errors"
+	"fmt"
 	"io"
+	"runtime"
 	"strings"
 	"testing"
 	"time"
@@ -15,10 +18,20 @@ import (
 
 var (
 	may4 = time.Date(2022, 5, 4, 0, 0, 0, 0, time.UTC)
+	may5 = time.Date(2022, 5, 5, 0, 0, 0, 0, time.UTC)
+	may6 = time.Date(2022, 5, 6, 0, 0, 0, 0, time.UTC)
 	may7 = time.Date(2022, 5, 7, 0, 0, 0, 0, time.UTC)
 )
 
-func TestParse(t *testing.T) {
+type staticTestCase struct {
+	desc     string
+	content  []byte
+	opts     ParseStaticOptions
+	expected *Static
+}
+
+// staticTestCases returns feeds and the results of parsing them.
+func staticTestCases() []staticTestCase {
 	defaultAgency := Agency{
 		Id:       "a",
 		Name:     "b",
@@ -44,21 +57,18 @@ func TestParse(t *testing.T) {
 		Id: "stop_id",
 	}
 	defaultService := Service{
-		Id:        "service_id",
-		StartDate: may4,
-		EndDate:   may7,
+		Id:                "service_id",
+		StartDate:         may4,
+		EndDate:           may7,
+		CalendarStartDate: may4,
+		CalendarEndDate:   may7,
 	}
 	defaultTrip := ScheduledTrip{
 		ID:      "trip_id",
 		Route:   &defaultRoute,
 		Service: &defaultService,
 	}
-	for _, tc := range []struct {
-		desc     string
-		content  []byte
-		opts     ParseStaticOptions
-		expected *Static
-	}{
+	return []staticTestCase{
 		{
 			desc: "agency with only required fields",
 			content: newZipBuilder().add(
@@ -321,6 +331,12 @@ func TestParse(t *testing.T) {
 			).build(),
 			expected: &Static{
 				Stops: []Stop{{Id: "a"}},
+				Transfers: []Transfer{
+					{
+						From: &Stop{Id: "a"},
+						To:   &Stop{Id: "a"},
+					},
+				},
 			},
 		},
 		{
@@ -334,6 +350,15 @@ func TestParse(t *testing.T) {
 			).build(),
 			expected: &Static{
 				Stops: []Stop{{Id: "a"}},
+				Warnings: []warnings.StaticWarning{
+					{
+						Kind:          warnings.InvalidStopReference{StopID: "b"},
+						File:          "transfers.txt",
+						RowNumber:     1,
+						RowContent:    []string{"a", "b"},
+						HeaderContent: []string{"from_stop_id", "to_stop_id"},
+					},
+				},
 			},
 		},
 		{
@@ -347,6 +372,15 @@ func TestParse(t *testing.T) {
 			).build(),
 			expected: &Static{
 				Stops: []Stop{{Id: "b"}},
+				Warnings: []warnings.StaticWarning{
+					{
+						Kind:          warnings.InvalidStopReference{StopID: "a"},
+						File:          "transfers.txt",
+						RowNumber:     1,
+						RowContent:    []string{"a", "b"},
+						HeaderContent: []string{"from_stop_id", "to_stop_id"},
+					},
+				},
 			},
 		},
 		{
@@ -359,16 +393,18 @@ func TestParse(t *testing.T) {
 			expected: &Static{
 				Services: []Service{
 					{
-						Id:        "a",
-						Monday:    true,
-						Tuesday:   false,
-						Wednesday: true,
-						Thursday:  false,
-						Friday:    true,
-						Saturday:  false,
-						Sunday:    true,
-						StartDate: may4,
-						EndDate:   may7,
+						Id:                "a",
+						Monday:            true,
+						Tuesday:           false,
+						Wednesday:         true,
+						Thursday:          false,
+						Friday:            true,
+						Saturday:          false,
+						Sunday:            true,
+						StartDate:         may4,
+						EndDate:           may7,
+						CalendarStartDate: may4,
+						CalendarEndDate:   may7,
 					},
 				},
 			},
@@ -391,6 +427,35 @@ func TestParse(t *testing.T) {
 				},
 			},
 		},
+		{
+			desc: "calendar_dates.txt outside of calendar.txt range",
+			content: newZipBuilder().add(
+				"calendar.txt",
+				"service_id,monday,tuesday,wednesday,thursday,friday,saturday,sunday,start_date,end_date\n"+
+					"a,1,1,1,1,1,0,0,20220505,20220506",
+			).add(
+				"calendar_dates.txt",
+				"service_id,date,exception_type\na,20220504,1\na,20220507,2",
+			).build(),
+			expected: &Static{
+				Services: []Service{
+					{
+						Id:                "a",
+						Monday:            true,
+						Tuesday:           true,
+						Wednesday:         true,
+						Thursday:          true,
+						Friday:            true,
+						StartDate:         may4,
+						EndDate:           may7,
+						AddedDates:        []time.Time{may4},
+						RemovedDates:      []time.Time{may7},
+						CalendarStartDate: may5,
+						CalendarEndDate:   may6,
+					},
+				},
+			},
+		},
 		{
 			desc: "trip",
 			content: newZipBuilder().add(
@@ -527,6 +592,76 @@ func TestParse(t *testing.T) {
 					},
 				},
 				Shapes: []Shape{},
+				Warnings: []warnings.StaticWarning{
+					{
+						Kind:          warnings.InvalidShapeReference{ShapeID: "shape_id"},
+						File:          constants.TripsFile,
+						RowNumber:     1,
+						RowContent:    []string{"route_id", "service_id", "trip_id", "shape_id"},
+						HeaderContent: []string{"route_id", "service_id", "trip_id", "shape_id"},
+					},
+				},
+			},
+		},
+		{
+			desc: "load only stops and routes",
+			content: newZipBuilderWithDefaults().add(
+				"stop_times.txt",
+				"",
+			).build(),
+			opts: ParseStaticOptions{
+				Tables: []constants.StaticFile{constants.StopsFile, constants.RoutesFile},
+			},
+			expected: &Static{
+				Agencies: []Agency{defaultAgency},
+				Routes:   []Route{defaultRoute},
+				Stops:    []Stop{defaultStop},
+			},
+		},
+		{
+			desc: "load trips without stop times",
+			content: newZipBuilderWithDefaults().add(
+				"stop_times.txt",
+				"",
+			).build(),
+			opts: ParseStaticOptions{
+				Tables: []constants.StaticFile{constants.TripsFile},
+			},
+			expected: &Static{
+				Agencies: []Agency{defaultAgency},
+				Routes:   []Route{defaultRoute},
+				Services: []Service{defaultService},
+				Trips: []ScheduledTrip{
+					{
+						Route:   &defaultRoute,
+						Service: &defaultService,
+						ID:      "trip_id",
+					},
+				},
+			},
+		},
+		{
+			desc: "load only shapes",
+			content: (&zipBuilder{m: map[string]string{}}).add(
+				"shapes.txt",
+				"shape_id,shape_pt_lat,shape_pt_lon,shape_pt_sequence",
+				"shape_1,1.5,2.5,1",
+			).build(),
+			opts: ParseStaticOptions{
+				Tables: []constants.StaticFile{constants.ShapesFile},
+			},
+			expected: &Static{
+				Shapes: []Shape{
+					{
+						ID: "shape_1",
+						Points: []ShapePoint{
+							{
+								Latitude:  1.5,
+								Longitude: 2.5,
+							},
+						},
+					},
+				},
 			},
 		},
 		{
@@ -1024,6 +1159,15 @@ func TestParse(t *testing.T) {
 				Services: []Service{defaultService},
 				Stops:    []Stop{defaultStop},
 				Trips:    []ScheduledTrip{defaultTrip},
+				Warnings: []warnings.StaticWarning{
+					{
+						Kind:          warnings.InvalidTripReference{TripID: "some_trip"},
+						File:          constants.FrequenciesFile,
+						RowNumber:     1,
+						RowContent:    []string{"some_trip", "00:00:00", "01:00:00", "180"},
+						HeaderContent: []string{"trip_id", "start_time", "end_time", "headway_secs"},
+					},
+				},
 			},
 		},
 		{
@@ -1167,12 +1311,17 @@ func TestParse(t *testing.T) {
 				},
 			},
 		},
-	} {
+	}
+}
+
+func TestParse(t *testing.T) {
+	for _, tc := range staticTestCases() {
 		t.Run(tc.desc, func(t *testing.T) {
 			actual, err := ParseStatic(tc.content, tc.opts)
 			if err != nil {
 				t.Errorf("error when parsing: %s", err)
 			}
+			linkStopTimesToTrips(tc.expected)
 			if diff := cmp.Diff(actual, tc.expected); diff != "" {
 				t.Errorf("not the same: \ngot: %+v != \nwant:%+v\ndiff:%s", actual, tc.expected, diff)
 			}
@@ -1180,6 +1329,411 @@ func TestParse(t *testing.T) {
 	}
 }
 
+func TestParseTransfers(t *testing.T) {
+	static, err := ParseStatic(newZipBuilderWithDefaults().add(
+		"stops.txt",
+		"stop_id\na\nb",
+	).add(
+		"trips.txt",
+		"route_id,service_id,trip_id\nroute_id,service_id,trip_1\nroute_id,service_id,trip_2",
+	).add(
+		"transfers.txt",
+		"from_stop_id,to_stop_id,from_route_id,to_route_id,from_trip_id,to_trip_id,transfer_type,min_transfer_time",
+		"a,a,route_id,route_id,,,2,120",
+		"a,b,,,trip_1,trip_2,1,",
+		",,,,trip_1,trip_2,4,",
+		"b,b,,,trip_2,trip_1,5,",
+		",,,,,trip_2,4,",
+		",b,,,,,1,",
+		"a,b,route_2,,,,0,",
+		"a,b,,,trip_3,,0,",
+	).build(), ParseStaticOptions{})
+	if err != nil {
+		t.Fatalf("error when parsing: %s", err)
+	}
+	stopA, stopB := &static.Stops[0], &static.Stops[1]
+	route := &static.Routes[0]
+	trip1, trip2 := &static.Trips[0], &static.Trips[1]
+	wantTransfers := []Transfer{
+		{From: stopA, To: stopA, FromRoute: route, ToRoute: route, Type: TransferType_RequiresTime, MinTransferTime: ptr(int32(120))},
+		{From: stopA, To: stopB, FromTrip: trip1, ToTrip: trip2, Type: TransferType_Timed},
+		{FromTrip: trip1, ToTrip: trip2, Type: TransferType_InSeat},
+		{From: stopB, To: stopB, FromTrip: trip2, ToTrip: trip1, Type: TransferType_InSeatNotAllowed},
+	}
+	if diff := cmp.Diff(wantTransfers, static.Transfers); diff != "" {
+		t.Errorf("transfers not the same: %s", diff)
+	}
+	var gotWarnings []warnings.StaticWarningKind
+	for _, w := range static.Warnings {
+		gotWarnings = append(gotWarnings, w.Kind)
+	}
+	wantWarnings := []warnings.StaticWarningKind{
+		warnings.MissingValues{Columns: []string{"from_trip_id"}},
+		warnings.MissingValues{Columns: []string{"from_stop_id"}},
+		warnings.InvalidRouteReference{RouteID: "route_2"},
+		warnings.InvalidTripReference{TripID: "trip_3"},
+	}
+	if diff := cmp.Diff(wantWarnings, gotWarnings); diff != "" {
+		t.Errorf("warnings not the same: %s", diff)
+	}
+}
+
+func TestParse_StopTimeWithInvalidTripReference(t *testing.T) {
+	static, err := ParseStatic(newZipBuilderWithDefaults().add(
+		"stop_times.txt",
+		"stop_id,trip_id,arrival_time,departure_time,stop_sequence,stop_headsign",
+		"stop_id,a,04:05:06,13:14:15,50,b",
+	).build(), ParseStaticOptions{})
+	if err != nil {
+		t.Fatalf("error when parsing: %s", err)
+	}
+	wantWarnings := []warnings.StaticWarning{
+		{
+			Kind:          warnings.InvalidTripReference{TripID: "a"},
+			File:          constants.StopTimesFile,
+			RowNumber:     1,
+			RowContent:    []string{"stop_id", "a", "04:05:06", "13:14:15", "50", "b"},
+			HeaderContent: []string{"stop_id", "trip_id", "arrival_time", "departure_time", "stop_sequence", "stop_headsign"},
+		},
+	}
+	if diff := cmp.Diff(wantWarnings, static.Warnings); diff != "" {
+		t.Errorf("warnings not the same: %s", diff)
+	}
+	if got := len(static.Trips[0].StopTimes); got != 0 {
+		t.Errorf("got %d stop times, want 0", got)
+	}
+}
+
+func TestParse_Warnings(t *testing.T) {
+	static, err := ParseStatic(newZipBuilderWithDefaults().add(
+		"stops.txt",
+		"stop_id",
+		"stop_1",
+		"stop_1",
+	).add(
+		"calendar.txt",
+		"service_id,monday,tuesday,wednesday,thursday,friday,saturday,sunday,start_date,end_date",
+		"service_id,0,0,0,0,0,0,0,20220504,20220507",
+		"service_2,0,0,0,0,0,0,0,2022-05-04,20220507",
+	).add(
+		"trips.txt",
+		"route_id,service_id,trip_id,shape_id",
+		"route_id,service_id,trip_1,",
+		"route_id,service_id,trip_1,",
+		"route_id,service_2,trip_2,",
+		"route_id,service_id,trip_3,shape_1",
+	).add(
+		"stop_times.txt",
+		"trip_id,stop_id,stop_sequence,arrival_time,departure_time",
+		"trip_1,stop_1,1,08:00:00,08:00:00",
+		"trip_1,stop_1,2,8am,08:10:00",
+		"trip_1,stop_1,two,08:20:00,08:20:00",
+		"trip_1,stop_2,3,08:30:00,08:30:00",
+		"trip_1,stop_1,4,,",
+		"trip_1,stop_1,5,08:50:00,",
+		"trip_2,stop_1,1,08:00:00,08:00:00",
+	).build(), ParseStaticOptions{})
+	if err != nil {
+		t.Fatalf("error when parsing: %s", err)
+	}
+	var gotWarnings []warnings.StaticWarningKind
+	for _, w := range static.Warnings {
+		gotWarnings = append(gotWarnings, w.Kind)
+	}
+	wantWarnings := []warnings.StaticWarningKind{
+		warnings.DuplicateID{Column: "stop_id", ID: "stop_1"},
+		warnings.UnparsableTime{Column: "start_date", Value: "2022-05-04"},
+		warnings.DuplicateID{Column: "trip_id", ID: "trip_1"},
+		warnings.InvalidServiceReference{ServiceID: "service_2"},
+		warnings.InvalidShapeReference{ShapeID: "shape_1"},
+		warnings.UnparsableTime{Column: "arrival_time", Value: "8am"},
+		warnings.InvalidStopSequence{Value: "two"},
+		warnings.InvalidStopReference{StopID: "stop_2"},
+		warnings.UntimedStopTime{TripID: "trip_1", StopSequence: "4"},
+		warnings.InvalidTripReference{TripID: "trip_2"},
+	}
+	if diff := cmp.Diff(wantWarnings, gotWarnings); diff != "" {
+		t.Errorf("warnings not the same: %s", diff)
+	}
+	if len(static.Trips) != 2 {
+		t.Fatalf("got %d trips, want 2", len(static.Trips))
+	}
+	if got := len(static.Trips[0].StopTimes); got != 2 {
+		t.Fatalf("got %d stop times for trip_1, want 2", got)
+	}
+	// A stop time with only an arrival time departs at the arrival time.
+	if stopTime := static.Trips[0].StopTimes[1]; stopTime.ArrivalTime != 8*time.Hour+50*time.Minute || stopTime.DepartureTime != stopTime.ArrivalTime {
+		t.Errorf("got arrival time %s and departure time %s, want 8h50m0s for both", stopTime.ArrivalTime, stopTime.DepartureTime)
+	}
+}
+
+func TestParse_Strict(t *testing.T) {
+	content := newZipBuilderWithDefaults().add(
+		"stop_times.txt",
+		"trip_id,stop_id,stop_sequence,arrival_time,departure_time",
+		"trip_id,stop_id,1,08:00:00,08:00:00",
+		"trip_id,stop_2,2,08:10:00,08:10:00",
+	).build()
+	for _, tc := range []struct {
+		desc           string
+		strict         []warnings.StaticWarningKind
+		strictSeverity warnings.Severity
+		wantErr        bool
+	}{
+		{"not strict", nil, 0, false},
+		{"strict for other kinds", []warnings.StaticWarningKind{warnings.InvalidTripReference{}}, 0, false},
+		{"strict", []warnings.StaticWarningKind{warnings.InvalidTripReference{}, warnings.InvalidStopReference{}}, 0, true},
+		{"strict severity", nil, warnings.SeverityError, true},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			_, err := ParseStatic(content, ParseStaticOptions{Strict: tc.strict, StrictSeverity: tc.strictSeverity})
+			if !tc.wantErr {
+				if err != nil {
+					t.Errorf("error when parsing: %s", err)
+				}
+				return
+			}
+			var gotWarning warnings.StaticWarning
+			if !errors.As(err, &gotWarning) {
+				t.Fatalf("got error %v, want a warning", err)
+			}
+			wantWarning := warnings.StaticWarning{
+				Kind:          warnings.InvalidStopReference{StopID: "stop_2"},
+				File:          constants.StopTimesFile,
+				RowNumber:     2,
+				RowContent:    []string{"trip_id", "stop_2", "2", "08:10:00", "08:10:00"},
+				HeaderContent: []string{"trip_id", "stop_id", "stop_sequence", "arrival_time", "departure_time"},
+			}
+			if diff := cmp.Diff(wantWarning, gotWarning); diff != "" {
+				t.Errorf("warning not the same: %s", diff)
+			}
+			if !errors.Is(err, warnings.InvalidStopReference{StopID: "stop_2"}) {
+				t.Errorf("error %v does not wrap the warning kind", err)
+			}
+		})
+	}
+}
+
+func TestParse_StrictSeverityUntimedStopTime(t *testing.T) {
+	// Stops between timepoints may have no times, so they don't make a valid feed fail.
+	_, err := ParseStatic(newZipBuilderWithDefaults().add(
+		"stop_times.txt",
+		"trip_id,stop_id,stop_sequence,arrival_time,departure_time,timepoint",
+		"trip_id,stop_id,1,08:00:00,08:00:00,1",
+		"trip_id,stop_id,2,,,0",
+		"trip_id,stop_id,3,08:20:00,08:20:00,1",
+	).build(), ParseStaticOptions{StrictSeverity: warnings.SeverityError})
+	if err != nil {
+		t.Errorf("error when parsing: %s", err)
+	}
+}
+
+func TestParse_UnknownTable(t *testing.T) {
+	_, err := ParseStatic(newZipBuilderWithDefaults().build(), ParseStaticOptions{
+		Tables: []constants.StaticFile{"stops"},
+	})
+	if err == nil {
+		t.Errorf("expected an error for an unknown table")
+	}
+}
+
+func TestParse_Concurrency(t *testing.T) {
+	// The stop times span several chunks, and some rows in each chunk result in warnings.
+	stopTimes := []string{"trip_id,stop_id,location_id,stop_sequence,arrival_time,departure_time"}
+	for i := 0; i < 2*stopTimesChunkSize+500; i++ {
+		tripID := fmt.Sprintf("trip_%d", i/100)
+		if i%1000 == 999 {
+			stopTimes = append(stopTimes, fmt.Sprintf("%s,stop_1,location_1,%d,08:00:00,08:00:00", tripID, i))
+			continue
+		}
+		stopTimes = append(stopTimes, fmt.Sprintf("%s,stop_%d,,%d,08:00:00,08:00:00", tripID, i%2+1, 100-i%100))
+	}
+	trips := []string{"route_id,service_id,trip_id"}
+	for i := 0; i < (2*stopTimesChunkSize+500)/100; i++ {
+		trips = append(trips, fmt.Sprintf("route_id,service_id,trip_%d", i))
+	}
+	content := newZipBuilderWithDefaults().add(
+		"stops.txt",
+		"stop_id\nstop_1\nstop_2",
+	).add(
+		"trips.txt", trips...,
+	).add(
+		"stop_times.txt", stopTimes...,
+	).build()
+
+	type stopTime struct {
+		tripID       string
+		stopID       string
+		stopSequence int
+	}
+	parse := func(concurrency int) ([]stopTime, []warnings.StaticWarning) {
+		static, err := ParseStatic(content, ParseStaticOptions{Concurrency: concurrency})
+		if err != nil {
+			t.Fatalf("error when parsing with concurrency %d: %s", concurrency, err)
+		}
+		var stopTimes []stopTime
+		for _, trip := range static.Trips {
+			for _, st := range trip.StopTimes {
+				stopTimes = append(stopTimes, stopTime{trip.ID, st.Stop.Id, st.StopSequence})
+			}
+		}
+		return stopTimes, static.Warnings
+	}
+
+	wantStopTimes, wantWarnings := parse(1)
+	if len(wantStopTimes) != 2*stopTimesChunkSize+480 {
+		t.Errorf("number of stop times: got %d, want %d", len(wantStopTimes), 2*stopTimesChunkSize+480)
+	}
+	if len(wantWarnings) != 20 {
+		t.Errorf("number of warnings: got %d, want 20", len(wantWarnings))
+	}
+	for _, concurrency := range []int{2, 8} {
+		gotStopTimes, gotWarnings := parse(concurrency)
+		if diff := cmp.Diff(gotStopTimes, wantStopTimes, cmp.AllowUnexported(stopTime{})); diff != "" {
+			t.Errorf("stop times with concurrency %d differ from sequential parsing: %s", concurrency, diff)
+		}
+		if diff := cmp.Diff(gotWarnings, wantWarnings); diff != "" {
+			t.Errorf("warnings with concurrency %d differ from sequential parsing: %s", concurrency, diff)
+		}
+	}
+}
+
+func TestParse_KeepExtraColumns(t *testing.T) {
+	content := newZipBuilder().add(
+		"agency.txt",
+		"agency_id,agency_name,agency_url,agency_timezone,agency_color",
+		"a,b,c,d,red",
+	).add(
+		"routes.txt",
+		"route_id,route_type,route_url_2",
+		"route_id,3,https://example.com",
+	).add(
+		"stops.txt",
+		"stop_id,ada,borough",
+		"stop_1,1,Brooklyn",
+		"stop_2,,",
+	).add(
+		"calendar.txt",
+		"service_id,monday,tuesday,wednesday,thursday,friday,saturday,sunday,start_date,end_date,season",
+		"service_id,0,0,0,0,0,0,0,20220504,20220507,summer",
+	).add(
+		"trips.txt",
+		"route_id,service_id,trip_id,train_id",
+		"route_id,service_id,trip_id,A123",
+	).add(
+		"stop_times.txt",
+		"trip_id,stop_id,stop_sequence,arrival_time,departure_time,track",
+		"trip_id,stop_2,2,08:10:00,08:10:00,",
+		"trip_id,stop_1,1,08:00:00,08:00:00,3",
+	).build()
+
+	for _, opts := range []ParseStaticOptions{
+		{KeepExtraColumns: true},
+		{KeepExtraColumns: true, CompactStopTimes: true},
+	} {
+		static, err := ParseStatic(content, opts)
+		if err != nil {
+			t.Fatalf("error when parsing: %s", err)
+		}
+		trip := &static.Trips[0]
+		for _, tc := range []struct {
+			desc string
+			got  map[string]string
+			want map[string]string
+		}{
+			{"agency", static.Agencies[0].Extra, map[string]string{"agency_color": "red"}},
+			{"route", static.Routes[0].Extra, map[string]string{"route_url_2": "https://example.com"}},
+			{"stop with values", static.Stops[0].Extra, map[string]string{"ada": "1", "borough": "Brooklyn"}},
+			{"stop without values", static.Stops[1].Extra, nil},
+			{"service", static.Services[0].Extra, map[string]string{"season": "summer"}},
+			{"trip", trip.Extra, map[string]string{"train_id": "A123"}},
+			{"first stop time", trip.StopTime(0).Extra, map[string]string{"track": "3"}},
+			{"second stop time", trip.StopTime(1).Extra, nil},
+		} {
+			if diff := cmp.Diff(tc.want, tc.got); diff != "" {
+				t.Errorf("%s extra columns with options %+v not the same: %s", tc.desc, opts, diff)
+			}
+		}
+	}
+
+	static, err := ParseStatic(content, ParseStaticOptions{})
+	if err != nil {
+		t.Fatalf("error when parsing: %s", err)
+	}
+	if static.Stops[0].Extra != nil {
+		t.Errorf("extra columns kept without the option: %v", static.Stops[0].Extra)
+	}
+}
+
+func BenchmarkParseStatic_InternStrings(b *testing.B) {
+	content := newLargeZipBuilder(2000, 50).build()
+	for _, intern := range []bool{false, true} {
+		b.Run(fmt.Sprintf("intern=%t", intern), func(b *testing.B) {
+			benchmarkParseStatic(b, content, ParseStaticOptions{InternStrings: intern})
+		})
+	}
+}
+
+// benchmarkParseStatic benchmarks parsing the feed, and reports the memory retained by the
+// parsed feed per stop time in addition to the allocations.
+func benchmarkParseStatic(b *testing.B, content []byte, opts ParseStaticOptions) {
+	b.ReportAllocs()
+	var retained uint64
+	var numStopTimes int
+	for n := 0; n < b.N; n++ {
+		var before, after runtime.MemStats
+		runtime.GC()
+		runtime.ReadMemStats(&before)
+		static, err := ParseStatic(content, opts)
+		if err != nil {
+			b.Fatalf("error when parsing: %s", err)
+		}
+		runtime.GC()
+		runtime.ReadMemStats(&after)
+		retained += after.HeapAlloc - before.HeapAlloc
+		numStopTimes = 0
+		for i := range static.Trips {
+			numStopTimes += static.Trips[i].NumStopTimes()
+		}
+		runtime.KeepAlive(static)
+	}
+	b.ReportMetric(float64(retained)/float64(b.N*numStopTimes), "retained-bytes/stoptime")
+}
+
+// newLargeZipBuilder returns a feed with many trips and stop times, whose string values
+// repeat as in real feeds.
+func newLargeZipBuilder(numTrips, stopTimesPerTrip int) *zipBuilder {
+	stops := []string{"stop_id,stop_name,zone_id,platform_code"}
+	for i := 0; i < stopTimesPerTrip; i++ {
+		stops = append(stops, fmt.Sprintf("stop_%d,Main Street %d,zone_%d,Platform %d", i, i/2, i/10, i%2+1))
+	}
+	trips := []string{"route_id,service_id,trip_id,trip_headsign,block_id"}
+	stopTimes := []string{"trip_id,stop_id,stop_sequence,arrival_time,departure_time,stop_headsign"}
+	for i := 0; i < numTrips; i++ {
+		trips = append(trips, fmt.Sprintf("route_id,service_id,trip_%d,Downtown via Main Street %d,block_%d", i, i%2, i%100))
+		for j := 0; j < stopTimesPerTrip; j++ {
+			stopTimes = append(stopTimes, fmt.Sprintf("trip_%d,stop_%d,%d,08:%02d:00,08:%02d:00,Downtown via Main Street %d", i, j, j, j, j, i%2))
+		}
+	}
+	return newZipBuilderWithDefaults().add(
+		"stops.txt", stops...,
+	).add(
+		"trips.txt", trips...,
+	).add(
+		"stop_times.txt", stopTimes...,
+	)
+}
+
+// linkStopTimesToTrips populates the trip pointer of each stop time in the expected result,
+// as this is cumbersome to do in the test case literals.
+func linkStopTimesToTrips(static *Static) {
+	for i := range static.Trips {
+		for j := range static.Trips[i].StopTimes {
+			static.Trips[i].StopTimes[j].Trip = &static.Trips[i]
+		}
+	}
+}
+
 type zipBuilder struct {
 	m map[string]string
 }
@@ -1215,9 +1769,9 @@ func newZipBuilderWithDefaults() *zipBuilder {
 		"service_id,monday,tuesday,wednesday,thursday,friday,saturday,sunday,start_date,end_date\n"+
 			"service_id,0,0,0,0,0,0,0,20220504,20220507",
 	).add(
+		// Stop times with an invalid trip reference are covered by TestParse_StopTimeWithInvalidTripReference.
 		"stop_times.txt",
-		"stop_id,trip_id,arrival_time,departure_time,stop_sequence,stop_headsign\n"+
-			"stop_id,a,04:05:06,13:14:15,50,b",
+		"stop_id,trip_id,arrival_time,departure_time,stop_sequence,stop_headsign",
 	).add(
 		"trips.txt",
 		"route_id,service_id,trip_id\nroute_id,service_id,trip_id")
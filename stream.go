@@ -0,0 +1,64 @@
+package gtfs
+
+import (
+	"archive/zip"
+	"io"
+)
+
+// StopTimesHandler handles the stop times of a single trip.
+//
+// The stop times are sorted by stop sequence. Returning an error aborts parsing.
+type StopTimesHandler func(trip *ScheduledTrip, stopTimes []ScheduledStopTime) error
+
+// StreamStatic parses the GTFS static feed in the reader, passing stop times to the handler
+// trip by trip instead of storing them.
+//
+// This bounds the memory used by stop times, which is typically most of the memory used to
+// represent a feed, to the stop times of a single trip. The returned feed contains all other
+// entities. Its trips have no stop times, but the trips and stops referenced by the stop times
+// passed to the handler point into it.
+//
+// Stop times are expected to be grouped by trip in stop_times.txt, which is the case for
+// almost all feeds. If the stop times of a trip are not contiguous, the handler is called
+// once for each contiguous group.
+func StreamStatic(r io.ReaderAt, size int64, opts ParseStaticOptions, handler StopTimesHandler) (*Static, error) {
+	reader, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+	return parseStatic(reader, opts, handler)
+}
+
+// stopTimesStream buffers the stop times of the current trip and passes them to the handler
+// when the trip changes.
+type stopTimesStream struct {
+	handler   StopTimesHandler
+	trip      *ScheduledTrip
+	stopTimes []ScheduledStopTime
+}
+
+func newStopTimesStream(handler StopTimesHandler) *stopTimesStream {
+	return &stopTimesStream{handler: handler}
+}
+
+func (stream *stopTimesStream) add(trip *ScheduledTrip, stopTime ScheduledStopTime) error {
+	if trip != stream.trip {
+		if err := stream.flush(); err != nil {
+			return err
+		}
+		stream.trip = trip
+	}
+	stream.stopTimes = append(stream.stopTimes, stopTime)
+	return nil
+}
+
+func (stream *stopTimesStream) flush() error {
+	if len(stream.stopTimes) == 0 {
+		return nil
+	}
+	stopTimes := stream.stopTimes
+	// The handler may retain the slice so a new one is allocated for the next trip.
+	stream.stopTimes = make([]ScheduledStopTime, 0, len(stopTimes))
+	sortStopTimes(stopTimes)
+	return stream.handler(stream.trip, stopTimes)
+}
@@ -0,0 +1,85 @@
+package gtfs
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func newStreamZipBuilder() *zipBuilder {
+	return newZipBuilderWithDefaults().add(
+		"stops.txt",
+		"stop_id\nstop_1\nstop_2",
+	).add(
+		"trips.txt",
+		"route_id,service_id,trip_id\nroute_id,service_id,trip_1\nroute_id,service_id,trip_2",
+	).add(
+		"stop_times.txt",
+		"trip_id,stop_id,stop_sequence,arrival_time,departure_time",
+		"trip_1,stop_2,2,08:10:00,08:10:00",
+		"trip_1,stop_1,1,08:00:00,08:00:00",
+		"trip_2,stop_1,1,09:00:00,09:00:00",
+		"trip_2,stop_2,2,09:10:00,09:10:00",
+		"trip_1,stop_1,3,08:20:00,08:20:00",
+	)
+}
+
+func TestStreamStatic(t *testing.T) {
+	content := newStreamZipBuilder().build()
+	type call struct {
+		trip      *ScheduledTrip
+		sequences []int
+	}
+	var calls []call
+	static, err := StreamStatic(bytes.NewReader(content), int64(len(content)), ParseStaticOptions{}, func(trip *ScheduledTrip, stopTimes []ScheduledStopTime) error {
+		c := call{trip: trip}
+		for _, stopTime := range stopTimes {
+			if stopTime.Trip != trip {
+				t.Errorf("stop time trip: got %+v, want %+v", stopTime.Trip, trip)
+			}
+			if stopTime.Stop == nil {
+				t.Errorf("stop time has no stop")
+			}
+			c.sequences = append(c.sequences, stopTime.StopSequence)
+		}
+		calls = append(calls, c)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("error when streaming: %s", err)
+	}
+	for _, trip := range static.Trips {
+		if len(trip.StopTimes) != 0 {
+			t.Errorf("trip %s has stop times", trip.ID)
+		}
+	}
+	wantCalls := []call{
+		{&static.Trips[0], []int{1, 2}},
+		{&static.Trips[1], []int{1, 2}},
+		{&static.Trips[0], []int{3}},
+	}
+	if diff := cmp.Diff(wantCalls, calls, cmp.AllowUnexported(call{})); diff != "" {
+		t.Errorf("calls not the same: %s", diff)
+	}
+	if calls[0].trip != &static.Trips[0] || calls[1].trip != &static.Trips[1] {
+		t.Errorf("handler trips do not point into the result")
+	}
+}
+
+func TestStreamStatic_HandlerError(t *testing.T) {
+	content := newStreamZipBuilder().build()
+	wantErr := errors.New("handler error")
+	var numCalls int
+	_, err := StreamStatic(bytes.NewReader(content), int64(len(content)), ParseStaticOptions{}, func(trip *ScheduledTrip, stopTimes []ScheduledStopTime) error {
+		numCalls++
+		return wantErr
+	})
+	if !errors.Is(err, wantErr) {
+		t.Errorf("error: got %v, want %v", err, wantErr)
+	}
+	if numCalls != 1 {
+		t.Errorf("number of handler calls: got %d, want 1", numCalls)
+	}
+}
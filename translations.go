@@ -0,0 +1,194 @@
+package gtfs
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/jamespfennell/gtfs/csv"
+	"github.com/jamespfennell/gtfs/warnings"
+)
+
+// Translation corresponds to a single row in the translations.txt file.
+//
+// A translation either matches a specific record using RecordId and RecordSubId,
+// or matches all records whose field has the value FieldValue.
+type Translation struct {
+	TableName   string
+	FieldName   string
+	Language    string
+	Translation string
+	RecordId    string
+	RecordSubId string
+	FieldValue  string
+	Extra       map[string]string
+}
+
+func parseTranslations(csv *csv.File) ([]Translation, []warnings.StaticWarning) {
+	var w []warnings.StaticWarning
+	tableNameColumn := csv.RequiredColumn("table_name").Interned()
+	fieldNameColumn := csv.RequiredColumn("field_name").Interned()
+	languageColumn := csv.RequiredColumn("language").Interned()
+	translationColumn := csv.RequiredColumn("translation")
+	recordIDColumn := csv.OptionalColumn("record_id")
+	recordSubIDColumn := csv.OptionalColumn("record_sub_id")
+	fieldValueColumn := csv.OptionalColumn("field_value")
+
+	if warnings := checkForMissingColumns(csv); len(warnings) > 0 {
+		return nil, warnings
+	}
+
+	var translations []Translation
+	for csv.NextRow() {
+		translation := Translation{
+			TableName:   tableNameColumn.Read(),
+			FieldName:   fieldNameColumn.Read(),
+			Language:    languageColumn.Read(),
+			Translation: translationColumn.Read(),
+			RecordId:    recordIDColumn.Read(),
+			RecordSubId: recordSubIDColumn.Read(),
+			FieldValue:  fieldValueColumn.Read(),
+			Extra:       csv.ExtraColumns(),
+		}
+		if missingKeys := csv.MissingRowKeys(); len(missingKeys) > 0 {
+			w = append(w, warnings.NewStaticWarning(csv, warnings.MissingValues{Columns: missingKeys}))
+			continue
+		}
+		if translation.RecordId != "" && translation.FieldValue != "" {
+			w = append(w, warnings.NewStaticWarning(csv, warnings.MutuallyExclusiveColumns{
+				Columns: []string{"record_id", "field_value"},
+			}))
+			continue
+		}
+		if translation.TableName != "feed_info" && translation.RecordId == "" && translation.FieldValue == "" {
+			w = append(w, warnings.NewStaticWarning(csv, warnings.MissingValues{
+				Columns: []string{"record_id", "field_value"},
+			}))
+			continue
+		}
+		translations = append(translations, translation)
+	}
+	return translations, w
+}
+
+// Translator looks up the translations of a feed in a single language.
+type Translator struct {
+	Language string
+
+	recordKeyToTranslation map[translationRecordKey]string
+	valueKeyToTranslation  map[translationValueKey]string
+}
+
+type translationRecordKey struct {
+	tableName, fieldName, recordID, recordSubID string
+}
+
+type translationValueKey struct {
+	tableName, fieldName, fieldValue string
+}
+
+// Translate returns a translator for the language, which is an IETF BCP 47 language code.
+//
+// If the feed has no translations in the language but has translations in its base
+// language (for example "fr" for "fr-CA"), those translations are used instead.
+func (static *Static) Translate(language string) *Translator {
+	translator := &Translator{
+		Language:               language,
+		recordKeyToTranslation: map[translationRecordKey]string{},
+		valueKeyToTranslation:  map[translationValueKey]string{},
+	}
+	candidates := []string{language}
+	if i := strings.Index(language, "-"); i > 0 {
+		candidates = append(candidates, language[:i])
+	}
+	for _, candidate := range candidates {
+		for _, translation := range static.Translations {
+			if !strings.EqualFold(translation.Language, candidate) {
+				continue
+			}
+			if translation.FieldValue != "" {
+				translator.valueKeyToTranslation[translationValueKey{
+					tableName:  translation.TableName,
+					fieldName:  translation.FieldName,
+					fieldValue: translation.FieldValue,
+				}] = translation.Translation
+				continue
+			}
+			translator.recordKeyToTranslation[translationRecordKey{
+				tableName:   translation.TableName,
+				fieldName:   translation.FieldName,
+				recordID:    translation.RecordId,
+				recordSubID: translation.RecordSubId,
+			}] = translation.Translation
+		}
+		if len(translator.recordKeyToTranslation) > 0 || len(translator.valueKeyToTranslation) > 0 {
+			break
+		}
+	}
+	return translator
+}
+
+// Lookup returns the translation of a field of a record.
+//
+// The table name is the name of the GTFS file without its extension, and the field name is
+// the name of the column. A translation that matches the record ID and sub ID takes precedence
+// over a translation that matches the field value.
+func (translator *Translator) Lookup(tableName, fieldName, recordID, recordSubID, fieldValue string) (string, bool) {
+	if t, ok := translator.recordKeyToTranslation[translationRecordKey{
+		tableName:   tableName,
+		fieldName:   fieldName,
+		recordID:    recordID,
+		recordSubID: recordSubID,
+	}]; ok {
+		return t, true
+	}
+	if fieldValue == "" {
+		return "", false
+	}
+	t, ok := translator.valueKeyToTranslation[translationValueKey{
+		tableName:  tableName,
+		fieldName:  fieldName,
+		fieldValue: fieldValue,
+	}]
+	return t, ok
+}
+
+func (translator *Translator) lookupOr(tableName, fieldName, recordID, recordSubID, fieldValue string) string {
+	if t, ok := translator.Lookup(tableName, fieldName, recordID, recordSubID, fieldValue); ok {
+		return t
+	}
+	return fieldValue
+}
+
+// AgencyName returns the translated name of the agency, or the untranslated name if there is no translation.
+func (translator *Translator) AgencyName(agency *Agency) string {
+	return translator.lookupOr("agency", "agency_name", agency.Id, "", agency.Name)
+}
+
+// StopName returns the translated name of the stop, or the untranslated name if there is no translation.
+func (translator *Translator) StopName(stop *Stop) string {
+	return translator.lookupOr("stops", "stop_name", stop.Id, "", stop.Name)
+}
+
+// RouteShortName returns the translated short name of the route, or the untranslated short name if there is no translation.
+func (translator *Translator) RouteShortName(route *Route) string {
+	return translator.lookupOr("routes", "route_short_name", route.Id, "", route.ShortName)
+}
+
+// RouteLongName returns the translated long name of the route, or the untranslated long name if there is no translation.
+func (translator *Translator) RouteLongName(route *Route) string {
+	return translator.lookupOr("routes", "route_long_name", route.Id, "", route.LongName)
+}
+
+// TripHeadsign returns the translated headsign of the trip, or the untranslated headsign if there is no translation.
+func (translator *Translator) TripHeadsign(trip *ScheduledTrip) string {
+	return translator.lookupOr("trips", "trip_headsign", trip.ID, "", trip.Headsign)
+}
+
+// StopTimeHeadsign returns the translated headsign of the stop time, or the untranslated headsign if there is no translation.
+func (translator *Translator) StopTimeHeadsign(stopTime *ScheduledStopTime) string {
+	var tripID string
+	if stopTime.Trip != nil {
+		tripID = stopTime.Trip.ID
+	}
+	return translator.lookupOr("stop_times", "stop_headsign", tripID, strconv.Itoa(stopTime.StopSequence), stopTime.Headsign)
+}
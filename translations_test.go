@@ -0,0 +1,90 @@
+package gtfs
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jamespfennell/gtfs/warnings"
+)
+
+func TestTranslate(t *testing.T) {
+	static, err := ParseStatic(newZipBuilderWithDefaults().add(
+		"stops.txt",
+		"stop_id,stop_name",
+		"stop_1,Central Station",
+		"stop_2,Central Station",
+		"stop_3,Airport",
+	).add(
+		"routes.txt",
+		"route_id,route_type,route_short_name,route_long_name",
+		"route_id,3,A,Airport Express",
+	).add(
+		"trips.txt",
+		"route_id,service_id,trip_id,trip_headsign",
+		"route_id,service_id,trip_id,Airport",
+	).add(
+		"stop_times.txt",
+		"trip_id,stop_id,stop_sequence,arrival_time,departure_time,stop_headsign",
+		"trip_id,stop_1,1,08:00:00,08:00:00,Airport",
+		"trip_id,stop_3,2,08:30:00,08:30:00,",
+	).add(
+		"feed_info.txt",
+		"feed_publisher_name,feed_publisher_url,feed_lang",
+		"Publisher,https://example.com,en",
+	).add(
+		"translations.txt",
+		"table_name,field_name,language,translation,record_id,record_sub_id,field_value",
+		"stops,stop_name,fr,Gare centrale,,,Central Station",
+		"stops,stop_name,fr,Gare du centre,stop_2,,",
+		"stops,stop_name,fr,Aéroport,,,Airport",
+		"routes,route_long_name,fr,Express aéroport,route_id,,",
+		"trips,trip_headsign,fr,Aéroport,trip_id,,",
+		"stop_times,stop_headsign,fr,Vers l'aéroport,trip_id,1,",
+		"agency,agency_name,fr,B en français,a,,",
+		"stops,stop_name,es,Estación central,,,Central Station",
+		"stops,stop_name,es,Estación,stop_1,,Central Station",
+		"stops,stop_name,es,,stop_1,,",
+	).build(), ParseStaticOptions{})
+	if err != nil {
+		t.Fatalf("error when parsing: %s", err)
+	}
+	if got := len(static.Translations); got != 8 {
+		t.Errorf("number of translations: got %d, want 8", got)
+	}
+	var gotWarnings []warnings.StaticWarningKind
+	for _, w := range static.Warnings {
+		gotWarnings = append(gotWarnings, w.Kind)
+	}
+	wantWarnings := []warnings.StaticWarningKind{
+		warnings.MutuallyExclusiveColumns{Columns: []string{"record_id", "field_value"}},
+		warnings.MissingValues{Columns: []string{"translation"}},
+	}
+	if diff := cmp.Diff(wantWarnings, gotWarnings); diff != "" {
+		t.Errorf("warnings not the same: %s", diff)
+	}
+
+	trip := &static.Trips[0]
+	for _, tc := range []struct {
+		language string
+		got      func(translator *Translator) string
+		want     string
+	}{
+		{"fr", func(tr *Translator) string { return tr.StopName(&static.Stops[0]) }, "Gare centrale"},
+		{"fr", func(tr *Translator) string { return tr.StopName(&static.Stops[1]) }, "Gare du centre"},
+		{"fr", func(tr *Translator) string { return tr.StopName(&static.Stops[2]) }, "Aéroport"},
+		{"fr-CA", func(tr *Translator) string { return tr.StopName(&static.Stops[2]) }, "Aéroport"},
+		{"es", func(tr *Translator) string { return tr.StopName(&static.Stops[0]) }, "Estación central"},
+		{"es", func(tr *Translator) string { return tr.StopName(&static.Stops[2]) }, "Airport"},
+		{"de", func(tr *Translator) string { return tr.StopName(&static.Stops[0]) }, "Central Station"},
+		{"fr", func(tr *Translator) string { return tr.RouteShortName(&static.Routes[0]) }, "A"},
+		{"fr", func(tr *Translator) string { return tr.RouteLongName(&static.Routes[0]) }, "Express aéroport"},
+		{"fr", func(tr *Translator) string { return tr.AgencyName(&static.Agencies[0]) }, "B en français"},
+		{"fr", func(tr *Translator) string { return tr.TripHeadsign(trip) }, "Aéroport"},
+		{"fr", func(tr *Translator) string { return tr.StopTimeHeadsign(&trip.StopTimes[0]) }, "Vers l'aéroport"},
+		{"fr", func(tr *Translator) string { return tr.StopTimeHeadsign(&trip.StopTimes[1]) }, ""},
+	} {
+		if got := tc.got(static.Translate(tc.language)); got != tc.want {
+			t.Errorf("translation in %s: got %q, want %q", tc.language, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package validate
+
+import (
+	"math"
+
+	"github.com/jamespfennell/gtfs"
+	"github.com/jamespfennell/gtfs/internal/geo"
+)
+
+// distanceBetweenStops returns the great-circle distance in meters between the stops, if both have coordinates.
+func distanceBetweenStops(a, b *gtfs.Stop) (float64, bool) {
+	if a.Latitude == nil || a.Longitude == nil || b.Latitude == nil || b.Longitude == nil {
+		return 0, false
+	}
+	return geo.Distance(*a.Latitude, *a.Longitude, *b.Latitude, *b.Longitude), true
+}
+
+// distanceToShape returns the distance in meters between the stop and the closest point of the shape,
+// if the stop has coordinates and the shape has points.
+//
+// Around the stop the Earth is approximated by a plane, which is accurate for the short distances
+// that matter here.
+func distanceToShape(stop *gtfs.Stop, shape *gtfs.Shape) (float64, bool) {
+	if stop.Latitude == nil || stop.Longitude == nil || len(shape.Points) == 0 {
+		return 0, false
+	}
+	lat0, lon0 := *stop.Latitude, *stop.Longitude
+	project := func(point gtfs.ShapePoint) (float64, float64) {
+		x := geo.Radians(point.Longitude-lon0) * math.Cos(geo.Radians(lat0)) * geo.EarthRadius
+		y := geo.Radians(point.Latitude-lat0) * geo.EarthRadius
+		return x, y
+	}
+	x1, y1 := project(shape.Points[0])
+	closest := math.Hypot(x1, y1)
+	for _, point := range shape.Points[1:] {
+		x2, y2 := project(point)
+		if d := distanceToSegment(x1, y1, x2, y2); d < closest {
+			closest = d
+		}
+		x1, y1 = x2, y2
+	}
+	return closest, true
+}
+
+// distanceToSegment returns the distance between the origin and the segment between the two points.
+func distanceToSegment(x1, y1, x2, y2 float64) float64 {
+	dx, dy := x2-x1, y2-y1
+	lengthSquared := dx*dx + dy*dy
+	if lengthSquared == 0 {
+		return math.Hypot(x1, y1)
+	}
+	// Position of the projection of the origin on the line, as a fraction of the segment.
+	t := -(x1*dx + y1*dy) / lengthSquared
+	t = math.Max(0, math.Min(1, t))
+	return math.Hypot(x1+t*dx, y1+t*dy)
+}
@@ -0,0 +1,400 @@
+// Package validate checks GTFS static feeds for problems that are not detected when parsing.
+package validate
+
+import (
+	"sort"
+	"time"
+
+	"github.com/jamespfennell/gtfs"
+	"github.com/jamespfennell/gtfs/constants"
+	"github.com/jamespfennell/gtfs/warnings"
+)
+
+// Maximum distance in meters between a stop and the shape of a trip that stops at it.
+const maxStopDistanceFromShape = 100
+
+// Static validates the feed and returns the problems found.
+//
+// The feed is typically the result of gtfs.ParseStatic, and the warnings raised while parsing it
+// are not repeated. Because the entities of a parsed feed are not associated with rows, the
+// returned warnings only have a kind and a file.
+//
+// Stops that are not used by any stop time are only reported if the feed has stop times, so a
+// feed parsed without stop times can be validated.
+func Static(static *gtfs.Static) []warnings.StaticWarning {
+	v := &validator{static: static}
+	v.checkDuplicateIDs()
+	v.checkReferences()
+	v.checkCoordinates()
+	v.checkServices()
+	v.checkStopTimes()
+	v.checkFrequencies()
+	v.checkUnusedEntities()
+	return v.warnings
+}
+
+type validator struct {
+	static   *gtfs.Static
+	warnings []warnings.StaticWarning
+}
+
+func (v *validator) add(file constants.StaticFile, kind warnings.StaticWarningKind) {
+	v.warnings = append(v.warnings, warnings.StaticWarning{Kind: kind, File: file})
+}
+
+func (v *validator) checkDuplicateIDs() {
+	static := v.static
+	for _, c := range []struct {
+		file   constants.StaticFile
+		column string
+		ids    []string
+	}{
+		{constants.AgencyFile, "agency_id", ids(static.Agencies, func(agency *gtfs.Agency) string { return agency.Id })},
+		{constants.RoutesFile, "route_id", ids(static.Routes, func(route *gtfs.Route) string { return route.Id })},
+		{constants.StopsFile, "stop_id", ids(static.Stops, func(stop *gtfs.Stop) string { return stop.Id })},
+		{constants.CalendarFile, "service_id", ids(static.Services, func(service *gtfs.Service) string { return service.Id })},
+		{constants.TripsFile, "trip_id", ids(static.Trips, func(trip *gtfs.ScheduledTrip) string { return trip.ID })},
+		{constants.ShapesFile, "shape_id", ids(static.Shapes, func(shape *gtfs.Shape) string { return shape.ID })},
+	} {
+		seen := map[string]bool{}
+		for _, id := range c.ids {
+			if seen[id] {
+				v.add(c.file, warnings.DuplicateID{Column: c.column, ID: id})
+			}
+			seen[id] = true
+		}
+	}
+}
+
+func ids[T any](entities []T, id func(*T) string) []string {
+	result := make([]string, 0, len(entities))
+	for i := range entities {
+		result = append(result, id(&entities[i]))
+	}
+	return result
+}
+
+func (v *validator) checkReferences() {
+	static := v.static
+	agencyIDs := map[string]bool{}
+	for i := range static.Agencies {
+		agencyIDs[static.Agencies[i].Id] = true
+	}
+	routeIDs := map[string]bool{}
+	for i := range static.Routes {
+		routeIDs[static.Routes[i].Id] = true
+	}
+	stopIDs := map[string]bool{}
+	for i := range static.Stops {
+		stopIDs[static.Stops[i].Id] = true
+	}
+	levelIDs := map[string]bool{}
+	for i := range static.Levels {
+		levelIDs[static.Levels[i].Id] = true
+	}
+	serviceIDs := map[string]bool{}
+	for i := range static.Services {
+		serviceIDs[static.Services[i].Id] = true
+	}
+	tripIDs := map[string]bool{}
+	for i := range static.Trips {
+		tripIDs[static.Trips[i].ID] = true
+	}
+	shapeIDs := map[string]bool{}
+	for i := range static.Shapes {
+		shapeIDs[static.Shapes[i].ID] = true
+	}
+
+	for i := range static.Routes {
+		route := &static.Routes[i]
+		if route.Agency != nil && !agencyIDs[route.Agency.Id] {
+			v.add(constants.RoutesFile, warnings.InvalidAgencyReference{AgencyID: route.Agency.Id})
+		}
+	}
+	for i := range static.Stops {
+		stop := &static.Stops[i]
+		if stop.Parent != nil && !stopIDs[stop.Parent.Id] {
+			v.add(constants.StopsFile, warnings.InvalidStopReference{StopID: stop.Parent.Id})
+		}
+		if stop.Level != nil && !levelIDs[stop.Level.Id] {
+			v.add(constants.StopsFile, warnings.InvalidLevelReference{LevelID: stop.Level.Id})
+		}
+	}
+	for i := range static.Trips {
+		trip := &static.Trips[i]
+		if trip.Route == nil || !routeIDs[trip.Route.Id] {
+			v.add(constants.TripsFile, warnings.InvalidRouteReference{RouteID: routeID(trip.Route)})
+		}
+		if trip.Service == nil || !serviceIDs[trip.Service.Id] {
+			v.add(constants.TripsFile, warnings.InvalidServiceReference{ServiceID: serviceID(trip.Service)})
+		}
+		if trip.Shape != nil && !shapeIDs[trip.Shape.ID] {
+			v.add(constants.TripsFile, warnings.InvalidShapeReference{ShapeID: trip.Shape.ID})
+		}
+		for j := 0; j < trip.NumStopTimes(); j++ {
+			stopTime := trip.StopTime(j)
+			switch {
+			case stopTime.Stop != nil:
+				if !stopIDs[stopTime.Stop.Id] {
+					v.add(constants.StopTimesFile, warnings.InvalidStopReference{StopID: stopTime.Stop.Id})
+				}
+			case stopTime.Location == nil && stopTime.LocationGroup == nil:
+				v.add(constants.StopTimesFile, warnings.MissingValues{Columns: []string{"stop_id"}})
+			}
+		}
+	}
+	for i := range static.Transfers {
+		transfer := &static.Transfers[i]
+		for _, stop := range []*gtfs.Stop{transfer.From, transfer.To} {
+			if stop != nil && !stopIDs[stop.Id] {
+				v.add(constants.TransfersFile, warnings.InvalidStopReference{StopID: stop.Id})
+			}
+		}
+		for _, route := range []*gtfs.Route{transfer.FromRoute, transfer.ToRoute} {
+			if route != nil && !routeIDs[route.Id] {
+				v.add(constants.TransfersFile, warnings.InvalidRouteReference{RouteID: route.Id})
+			}
+		}
+		for _, trip := range []*gtfs.ScheduledTrip{transfer.FromTrip, transfer.ToTrip} {
+			if trip != nil && !tripIDs[trip.ID] {
+				v.add(constants.TransfersFile, warnings.InvalidTripReference{TripID: trip.ID})
+			}
+		}
+	}
+}
+
+func routeID(route *gtfs.Route) string {
+	if route == nil {
+		return ""
+	}
+	return route.Id
+}
+
+func serviceID(service *gtfs.Service) string {
+	if service == nil {
+		return ""
+	}
+	return service.Id
+}
+
+func (v *validator) checkCoordinates() {
+	static := v.static
+	for i := range static.Stops {
+		stop := &static.Stops[i]
+		if stop.Latitude == nil || stop.Longitude == nil {
+			continue
+		}
+		if !validCoordinates(*stop.Latitude, *stop.Longitude) {
+			v.add(constants.StopsFile, warnings.CoordinatesOutOfRange{
+				ID:        stop.Id,
+				Latitude:  *stop.Latitude,
+				Longitude: *stop.Longitude,
+			})
+		}
+	}
+	for i := range static.Shapes {
+		shape := &static.Shapes[i]
+		for _, point := range shape.Points {
+			if !validCoordinates(point.Latitude, point.Longitude) {
+				v.add(constants.ShapesFile, warnings.CoordinatesOutOfRange{
+					ID:        shape.ID,
+					Latitude:  point.Latitude,
+					Longitude: point.Longitude,
+				})
+			}
+		}
+	}
+}
+
+func validCoordinates(latitude, longitude float64) bool {
+	return -90 <= latitude && latitude <= 90 && -180 <= longitude && longitude <= 180
+}
+
+func (v *validator) checkServices() {
+	for i := range v.static.Services {
+		service := &v.static.Services[i]
+		if service.CalendarEndDate.Before(service.CalendarStartDate) {
+			v.add(constants.CalendarFile, warnings.InvalidCalendarRange{ServiceID: service.Id})
+		}
+	}
+}
+
+// checkStopTimes checks the stop times of each trip: stop sequences and times must increase,
+// vehicles must travel at a reasonable speed between consecutive stops, and stops must be close
+// to the shape of the trip.
+func (v *validator) checkStopTimes() {
+	type stopAndShape struct {
+		stop  *gtfs.Stop
+		shape *gtfs.Shape
+	}
+	checkedStopAndShape := map[stopAndShape]bool{}
+	for i := range v.static.Trips {
+		trip := &v.static.Trips[i]
+		var reportedSpeed bool
+		for j := 0; j < trip.NumStopTimes(); j++ {
+			stopTime := trip.StopTime(j)
+			if stopTime.Stop != nil && trip.Shape != nil && !checkedStopAndShape[stopAndShape{stopTime.Stop, trip.Shape}] {
+				checkedStopAndShape[stopAndShape{stopTime.Stop, trip.Shape}] = true
+				if d, ok := distanceToShape(stopTime.Stop, trip.Shape); ok && d > maxStopDistanceFromShape {
+					v.add(constants.StopTimesFile, warnings.StopTooFarFromShape{
+						StopID:   stopTime.Stop.Id,
+						ShapeID:  trip.Shape.ID,
+						Distance: d,
+					})
+				}
+			}
+			var previous gtfs.ScheduledStopTime
+			if j > 0 {
+				previous = trip.StopTime(j - 1)
+				if stopTime.StopSequence <= previous.StopSequence {
+					v.add(constants.StopTimesFile, warnings.StopSequenceNotIncreasing{TripID: trip.ID, StopSequence: stopTime.StopSequence})
+				}
+			}
+			if !hasTimes(&stopTime) {
+				continue
+			}
+			if stopTime.DepartureTime < stopTime.ArrivalTime {
+				v.add(constants.StopTimesFile, warnings.StopTimesNotIncreasing{TripID: trip.ID, StopSequence: stopTime.StopSequence})
+			}
+			if j == 0 || !hasTimes(&previous) {
+				continue
+			}
+			if stopTime.ArrivalTime < previous.DepartureTime {
+				v.add(constants.StopTimesFile, warnings.StopTimesNotIncreasing{TripID: trip.ID, StopSequence: stopTime.StopSequence})
+				continue
+			}
+			if reportedSpeed || trip.Route == nil {
+				continue
+			}
+			if speed, ok := travelSpeed(&previous, &stopTime); ok && speed > maxSpeed(trip.Route.Type) {
+				v.add(constants.StopTimesFile, warnings.UnreasonableSpeed{
+					TripID:     trip.ID,
+					FromStopID: previous.Stop.Id,
+					ToStopID:   stopTime.Stop.Id,
+					Speed:      speed,
+				})
+				reportedSpeed = true
+			}
+		}
+	}
+}
+
+// hasTimes returns whether the stop time has arrival and departure times, as opposed to a
+// GTFS-Flex pickup and drop off window.
+func hasTimes(stopTime *gtfs.ScheduledStopTime) bool {
+	return stopTime.StartPickupDropOffWindow == nil && stopTime.EndPickupDropOffWindow == nil
+}
+
+// travelSpeed returns the speed in kilometers per hour between the stops of two consecutive stop times.
+//
+// Times in feeds are often rounded to the minute, so the travel time is at least one minute.
+func travelSpeed(from, to *gtfs.ScheduledStopTime) (float64, bool) {
+	if from.Stop == nil || to.Stop == nil {
+		return 0, false
+	}
+	d, ok := distanceBetweenStops(from.Stop, to.Stop)
+	if !ok {
+		return 0, false
+	}
+	travelTime := to.ArrivalTime - from.DepartureTime
+	if travelTime < time.Minute {
+		travelTime = time.Minute
+	}
+	return d / travelTime.Seconds() * 3.6, true
+}
+
+// maxSpeed returns the maximum reasonable speed in kilometers per hour for the route type.
+func maxSpeed(routeType gtfs.RouteType) float64 {
+	switch {
+	case routeType == gtfs.RouteType_Rail || (100 <= routeType && routeType < 200):
+		return 500
+	case routeType == gtfs.RouteType_Ferry || (1000 <= routeType && routeType < 1100) || (1200 <= routeType && routeType < 1300):
+		return 80
+	case routeType == gtfs.RouteType_CableTram:
+		return 30
+	case routeType == gtfs.RouteType_AerialLift || routeType == gtfs.RouteType_Funicular || (1300 <= routeType && routeType < 1500):
+		return 50
+	case 1100 <= routeType && routeType < 1200:
+		// Air services
+		return 1000
+	default:
+		return 150
+	}
+}
+
+func (v *validator) checkFrequencies() {
+	for i := range v.static.Trips {
+		trip := &v.static.Trips[i]
+		frequencies := make([]gtfs.Frequency, len(trip.Frequencies))
+		copy(frequencies, trip.Frequencies)
+		sort.Slice(frequencies, func(i, j int) bool {
+			return frequencies[i].StartTime < frequencies[j].StartTime
+		})
+		for j := 1; j < len(frequencies); j++ {
+			if frequencies[j].StartTime < frequencies[j-1].EndTime {
+				v.add(constants.FrequenciesFile, warnings.OverlappingFrequencies{TripID: trip.ID})
+				break
+			}
+		}
+	}
+}
+
+func (v *validator) checkUnusedEntities() {
+	static := v.static
+	usedRoutes := map[string]bool{}
+	usedServices := map[string]bool{}
+	usedShapes := map[string]bool{}
+	usedStops := map[string]bool{}
+	var hasStopTimes bool
+	for i := range static.Trips {
+		trip := &static.Trips[i]
+		if trip.Route != nil {
+			usedRoutes[trip.Route.Id] = true
+		}
+		if trip.Service != nil {
+			usedServices[trip.Service.Id] = true
+		}
+		if trip.Shape != nil {
+			usedShapes[trip.Shape.ID] = true
+		}
+		for j := 0; j < trip.NumStopTimes(); j++ {
+			hasStopTimes = true
+			if stop := trip.StopTime(j).Stop; stop != nil {
+				usedStops[stop.Id] = true
+			}
+		}
+	}
+	for i := range static.LocationGroups {
+		for _, stop := range static.LocationGroups[i].Stops {
+			usedStops[stop.Id] = true
+		}
+	}
+	for i := range static.Routes {
+		if id := static.Routes[i].Id; !usedRoutes[id] {
+			v.add(constants.RoutesFile, warnings.UnusedEntity{Column: "route_id", ID: id})
+		}
+	}
+	for i := range static.Services {
+		if id := static.Services[i].Id; !usedServices[id] {
+			v.add(constants.CalendarFile, warnings.UnusedEntity{Column: "service_id", ID: id})
+		}
+	}
+	for i := range static.Shapes {
+		if id := static.Shapes[i].ID; !usedShapes[id] {
+			v.add(constants.ShapesFile, warnings.UnusedEntity{Column: "shape_id", ID: id})
+		}
+	}
+	if !hasStopTimes {
+		return
+	}
+	for i := range static.Stops {
+		stop := &static.Stops[i]
+		// Stations, entrances, generic nodes and boarding areas are not referenced by stop times.
+		if stop.Type != gtfs.StopType_Stop && stop.Type != gtfs.StopType_Platform {
+			continue
+		}
+		if !usedStops[stop.Id] {
+			v.add(constants.StopsFile, warnings.UnusedEntity{Column: "stop_id", ID: stop.Id})
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package validate
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/jamespfennell/gtfs"
+	"github.com/jamespfennell/gtfs/warnings"
+)
+
+// newStatic returns a valid feed with a single trip that stops at two stops one kilometer apart.
+func newStatic() *gtfs.Static {
+	static := &gtfs.Static{
+		Agencies: []gtfs.Agency{{Id: "agency"}},
+		Stops: []gtfs.Stop{
+			{Id: "stop_1", Latitude: ptr(40.0), Longitude: ptr(-74.0)},
+			{Id: "stop_2", Latitude: ptr(40.009), Longitude: ptr(-74.0)},
+		},
+		Services: []gtfs.Service{
+			{
+				Id:                "service",
+				Monday:            true,
+				StartDate:         time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+				EndDate:           time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC),
+				CalendarStartDate: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+				CalendarEndDate:   time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		Shapes: []gtfs.Shape{
+			{
+				ID: "shape",
+				Points: []gtfs.ShapePoint{
+					{Latitude: 40.0, Longitude: -74.0001},
+					{Latitude: 40.01, Longitude: -74.0001},
+				},
+			},
+		},
+	}
+	static.Routes = []gtfs.Route{{Id: "route", Agency: &static.Agencies[0], Type: gtfs.RouteType_Bus}}
+	static.Trips = []gtfs.ScheduledTrip{
+		{
+			ID:      "trip",
+			Route:   &static.Routes[0],
+			Service: &static.Services[0],
+			Shape:   &static.Shapes[0],
+		},
+	}
+	trip := &static.Trips[0]
+	trip.StopTimes = []gtfs.ScheduledStopTime{
+		{Trip: trip, Stop: &static.Stops[0], StopSequence: 1, ArrivalTime: 8 * time.Hour, DepartureTime: 8 * time.Hour},
+		{Trip: trip, Stop: &static.Stops[1], StopSequence: 2, ArrivalTime: 8*time.Hour + 2*time.Minute, DepartureTime: 8*time.Hour + 2*time.Minute},
+	}
+	return static
+}
+
+func TestStatic(t *testing.T) {
+	for _, tc := range []struct {
+		desc   string
+		modify func(static *gtfs.Static)
+		want   []warnings.StaticWarningKind
+	}{
+		{
+			desc:   "valid feed",
+			modify: func(static *gtfs.Static) {},
+		},
+		{
+			desc: "duplicate IDs",
+			modify: func(static *gtfs.Static) {
+				static.Routes = append(static.Routes, static.Routes[0])
+			},
+			want: []warnings.StaticWarningKind{
+				warnings.DuplicateID{Column: "route_id", ID: "route"},
+			},
+		},
+		{
+			desc: "dangling references",
+			modify: func(static *gtfs.Static) {
+				static.Trips[0].Route = &gtfs.Route{Id: "other_route"}
+				static.Trips[0].Service = nil
+				static.Trips[0].StopTimes[1].Stop = &gtfs.Stop{Id: "other_stop"}
+			},
+			want: []warnings.StaticWarningKind{
+				warnings.InvalidRouteReference{RouteID: "other_route"},
+				warnings.InvalidServiceReference{},
+				warnings.InvalidStopReference{StopID: "other_stop"},
+				warnings.UnusedEntity{Column: "route_id", ID: "route"},
+				warnings.UnusedEntity{Column: "service_id", ID: "service"},
+				warnings.UnusedEntity{Column: "stop_id", ID: "stop_2"},
+			},
+		},
+		{
+			desc: "coordinates out of range",
+			modify: func(static *gtfs.Static) {
+				static.Stops = append(static.Stops, gtfs.Stop{Id: "stop_3", Type: gtfs.StopType_Station, Latitude: ptr(95.0), Longitude: ptr(0.0)})
+			},
+			want: []warnings.StaticWarningKind{
+				warnings.CoordinatesOutOfRange{ID: "stop_3", Latitude: 95, Longitude: 0},
+			},
+		},
+		{
+			desc: "calendar range",
+			modify: func(static *gtfs.Static) {
+				static.Services[0].CalendarEndDate = time.Date(2023, 12, 31, 0, 0, 0, 0, time.UTC)
+			},
+			want: []warnings.StaticWarningKind{
+				warnings.InvalidCalendarRange{ServiceID: "service"},
+			},
+		},
+		{
+			desc: "stop sequence not increasing",
+			modify: func(static *gtfs.Static) {
+				static.Trips[0].StopTimes[1].StopSequence = 1
+			},
+			want: []warnings.StaticWarningKind{
+				warnings.StopSequenceNotIncreasing{TripID: "trip", StopSequence: 1},
+			},
+		},
+		{
+			desc: "times not increasing",
+			modify: func(static *gtfs.Static) {
+				static.Trips[0].StopTimes[1].ArrivalTime = 7 * time.Hour
+			},
+			want: []warnings.StaticWarningKind{
+				warnings.StopTimesNotIncreasing{TripID: "trip", StopSequence: 2},
+			},
+		},
+		{
+			desc: "unreasonable speed",
+			modify: func(static *gtfs.Static) {
+				static.Stops[1].Latitude = ptr(40.09)
+				static.Shapes[0].Points[1].Latitude = 40.1
+			},
+			want: []warnings.StaticWarningKind{
+				warnings.UnreasonableSpeed{TripID: "trip", FromStopID: "stop_1", ToStopID: "stop_2", Speed: 300},
+			},
+		},
+		{
+			desc: "stop far from shape",
+			modify: func(static *gtfs.Static) {
+				static.Stops[1].Longitude = ptr(-74.01)
+			},
+			want: []warnings.StaticWarningKind{
+				warnings.StopTooFarFromShape{StopID: "stop_2", ShapeID: "shape", Distance: 850},
+			},
+		},
+		{
+			desc: "overlapping frequencies",
+			modify: func(static *gtfs.Static) {
+				static.Trips[0].Frequencies = []gtfs.Frequency{
+					{StartTime: 8 * time.Hour, EndTime: 10 * time.Hour, Headway: 10 * time.Minute},
+					{StartTime: 6 * time.Hour, EndTime: 9 * time.Hour, Headway: 10 * time.Minute},
+				}
+			},
+			want: []warnings.StaticWarningKind{
+				warnings.OverlappingFrequencies{TripID: "trip"},
+			},
+		},
+		{
+			desc: "unused entities",
+			modify: func(static *gtfs.Static) {
+				static.Routes = append(static.Routes, gtfs.Route{Id: "route_2", Agency: &static.Agencies[0]})
+				static.Stops = append(static.Stops,
+					gtfs.Stop{Id: "stop_3"},
+					gtfs.Stop{Id: "station", Type: gtfs.StopType_Station},
+				)
+			},
+			want: []warnings.StaticWarningKind{
+				warnings.UnusedEntity{Column: "route_id", ID: "route_2"},
+				warnings.UnusedEntity{Column: "stop_id", ID: "stop_3"},
+			},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			static := newStatic()
+			tc.modify(static)
+			var got []warnings.StaticWarningKind
+			for _, w := range Static(static) {
+				got = append(got, w.Kind)
+			}
+			// Speeds and distances are compared approximately.
+			if diff := cmp.Diff(tc.want, got, cmpopts.EquateApprox(0.05, 0)); diff != "" {
+				t.Errorf("warnings not the same: %s", diff)
+			}
+		})
+	}
+}
+
+func ptr[T any](t T) *T {
+	return &t
+}
@@ -0,0 +1,60 @@
+package warnings
+
+import (
+	"sort"
+
+	"github.com/jamespfennell/gtfs/constants"
+)
+
+// StaticWarningGroup contains the warnings with the same code in the same file.
+type StaticWarningGroup struct {
+	Code     string               `json:"code"`
+	Severity Severity             `json:"severity"`
+	File     constants.StaticFile `json:"file"`
+	// Number of warnings in the group.
+	Count int `json:"count"`
+	// The first warnings of the group, in the order they were raised.
+	Samples []StaticWarning `json:"samples"`
+}
+
+// Aggregate groups the warnings by code and file, keeping at most maxSamples warnings as samples
+// of each group.
+//
+// The groups are sorted by file and then by code, so that the groups of different versions of a
+// feed can be compared.
+func Aggregate(ws []StaticWarning, maxSamples int) []StaticWarningGroup {
+	type key struct {
+		code string
+		file constants.StaticFile
+	}
+	keyToGroup := map[key]*StaticWarningGroup{}
+	var groups []*StaticWarningGroup
+	for _, w := range ws {
+		k := key{code: w.Kind.Code(), file: w.File}
+		group, ok := keyToGroup[k]
+		if !ok {
+			group = &StaticWarningGroup{
+				Code:     k.code,
+				Severity: w.Kind.Severity(),
+				File:     k.file,
+			}
+			keyToGroup[k] = group
+			groups = append(groups, group)
+		}
+		group.Count++
+		if len(group.Samples) < maxSamples {
+			group.Samples = append(group.Samples, w)
+		}
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].File != groups[j].File {
+			return groups[i].File < groups[j].File
+		}
+		return groups[i].Code < groups[j].Code
+	})
+	result := make([]StaticWarningGroup, 0, len(groups))
+	for _, group := range groups {
+		result = append(result, *group)
+	}
+	return result
+}
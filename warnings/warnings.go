@@ -1,7 +1,10 @@
 package warnings
 
 import (
+	"encoding/json"
 	"fmt"
+	"reflect"
+	"strings"
 
 	"github.com/jamespfennell/gtfs/constants"
 	"github.com/jamespfennell/gtfs/csv"
@@ -25,12 +28,67 @@ type StaticWarning struct {
 	HeaderContent []string
 }
 
+// Error returns the message of the warning, including the location of the problematic row.
+//
+// A StaticWarning satisfies the error interface so that it can be returned when a warning
+// is treated as an error. It unwraps to its kind.
+func (w StaticWarning) Error() string {
+	if w.RowContent == nil {
+		return fmt.Sprintf("%s: %s", w.File, w.Kind)
+	}
+	return fmt.Sprintf("%s row %d [%s]: %s", w.File, w.RowNumber, strings.Join(w.RowContent, ","), w.Kind)
+}
+
+func (w StaticWarning) Unwrap() error {
+	return w.Kind
+}
+
+// MarshalJSON returns the JSON representation of the warning, which contains the code, severity
+// and message of its kind, the fields of its kind, and the location of the problematic row.
+func (w StaticWarning) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Code          string            `json:"code"`
+		Severity      Severity          `json:"severity"`
+		Message       string            `json:"message"`
+		Details       StaticWarningKind `json:"details"`
+		File          string            `json:"file"`
+		RowNumber     int               `json:"row_number"`
+		RowContent    []string          `json:"row_content"`
+		HeaderContent []string          `json:"header_content"`
+	}{
+		Code:          w.Kind.Code(),
+		Severity:      w.Kind.Severity(),
+		Message:       w.Kind.Error(),
+		Details:       w.Kind,
+		File:          string(w.File),
+		RowNumber:     w.RowNumber,
+		RowContent:    w.RowContent,
+		HeaderContent: w.HeaderContent,
+	})
+}
+
+// Matches returns whether the warning has the same kind type as one of the kinds.
+//
+// Only the types of the kinds are compared, so kinds are typically given as zero values,
+// for example warnings.InvalidStopReference{}.
+func (w StaticWarning) Matches(kinds []StaticWarningKind) bool {
+	for _, kind := range kinds {
+		if reflect.TypeOf(kind) == reflect.TypeOf(w.Kind) {
+			return true
+		}
+	}
+	return false
+}
+
 func NewStaticWarning(csvFile *csv.File, kind StaticWarningKind) StaticWarning {
+	// The row is copied because the CSV reader reuses its backing array across rows.
+	rowContent := make([]string, len(csvFile.RowContent()))
+	copy(rowContent, csvFile.RowContent())
 	return StaticWarning{
 		Kind:          kind,
 		File:          csvFile.Name(),
 		RowNumber:     csvFile.RowNumber(),
-		RowContent:    csvFile.RowContent(),
+		RowContent:    rowContent,
 		HeaderContent: csvFile.HeaderContent(),
 	}
 }
@@ -42,7 +100,53 @@ type StaticWarningKind interface {
 	// Text of the warning message.
 	Error() string // TODO: Message()
 
-	// TODO: Fatal() ? And convert all parsing errors into warnings
+	// Code identifying the kind of warning, like "invalid_stop_reference".
+	//
+	// Unlike the message, the code does not depend on the content of the feed and does not
+	// change between versions of this package.
+	Code() string
+
+	// Severity of the warning.
+	Severity() Severity
+}
+
+// Severity describes how much a warning affects the parsed feed.
+type Severity int
+
+const (
+	// The feed does not follow best practices but the parsed data is not affected.
+	SeverityInfo Severity = iota + 1
+	// Part of a row was ignored, or the feed is likely to be incorrect.
+	SeverityWarning
+	// A row or file was ignored, or the feed violates the GTFS specification.
+	SeverityError
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	default:
+		return fmt.Sprintf("Severity(%d)", int(s))
+	}
+}
+
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+func (s *Severity) UnmarshalText(text []byte) error {
+	for _, candidate := range []Severity{SeverityInfo, SeverityWarning, SeverityError} {
+		if string(text) == candidate.String() {
+			*s = candidate
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown severity %q", text)
 }
 
 type MissingColumns struct {
@@ -53,6 +157,14 @@ func (w MissingColumns) Error() string {
 	return fmt.Sprintf("csv file is missing columns %s", w.Columns)
 }
 
+func (w MissingColumns) Code() string {
+	return "missing_columns"
+}
+
+func (w MissingColumns) Severity() Severity {
+	return SeverityError
+}
+
 type AgencyMissingValues struct {
 	AgencyID string
 	Columns  []string
@@ -61,3 +173,656 @@ type AgencyMissingValues struct {
 func (w AgencyMissingValues) Error() string {
 	return fmt.Sprintf("agency %q is missing values %s", w.AgencyID, w.Columns)
 }
+
+func (w AgencyMissingValues) Code() string {
+	return "agency_missing_values"
+}
+
+func (w AgencyMissingValues) Severity() Severity {
+	return SeverityError
+}
+
+type FareAttributeMissingValues struct {
+	FareID  string
+	Columns []string
+}
+
+func (w FareAttributeMissingValues) Error() string {
+	return fmt.Sprintf("fare %q is missing values %s", w.FareID, w.Columns)
+}
+
+func (w FareAttributeMissingValues) Code() string {
+	return "fare_attribute_missing_values"
+}
+
+func (w FareAttributeMissingValues) Severity() Severity {
+	return SeverityError
+}
+
+type InvalidValue struct {
+	Column string
+	Value  string
+}
+
+func (w InvalidValue) Error() string {
+	return fmt.Sprintf("invalid value %q for column %s", w.Value, w.Column)
+}
+
+func (w InvalidValue) Code() string {
+	return "invalid_value"
+}
+
+func (w InvalidValue) Severity() Severity {
+	return SeverityError
+}
+
+type InvalidAgencyReference struct {
+	AgencyID string
+}
+
+func (w InvalidAgencyReference) Error() string {
+	if w.AgencyID == "" {
+		return "no agency ID provided but the feed does not have a unique agency"
+	}
+	return fmt.Sprintf("no agency with ID %q", w.AgencyID)
+}
+
+func (w InvalidAgencyReference) Code() string {
+	return "invalid_agency_reference"
+}
+
+func (w InvalidAgencyReference) Severity() Severity {
+	return SeverityError
+}
+
+type InvalidFareReference struct {
+	FareID string
+}
+
+func (w InvalidFareReference) Error() string {
+	return fmt.Sprintf("no fare with ID %q", w.FareID)
+}
+
+func (w InvalidFareReference) Code() string {
+	return "invalid_fare_reference"
+}
+
+func (w InvalidFareReference) Severity() Severity {
+	return SeverityError
+}
+
+type InvalidRouteReference struct {
+	RouteID string
+}
+
+func (w InvalidRouteReference) Error() string {
+	return fmt.Sprintf("no route with ID %q", w.RouteID)
+}
+
+func (w InvalidRouteReference) Code() string {
+	return "invalid_route_reference"
+}
+
+func (w InvalidRouteReference) Severity() Severity {
+	return SeverityError
+}
+
+type InvalidTripReference struct {
+	TripID string
+}
+
+func (w InvalidTripReference) Error() string {
+	return fmt.Sprintf("no trip with ID %q", w.TripID)
+}
+
+func (w InvalidTripReference) Code() string {
+	return "invalid_trip_reference"
+}
+
+func (w InvalidTripReference) Severity() Severity {
+	return SeverityError
+}
+
+type InvalidZoneReference struct {
+	ZoneID string
+}
+
+func (w InvalidZoneReference) Error() string {
+	return fmt.Sprintf("no stop has zone ID %q", w.ZoneID)
+}
+
+func (w InvalidZoneReference) Code() string {
+	return "invalid_zone_reference"
+}
+
+func (w InvalidZoneReference) Severity() Severity {
+	return SeverityWarning
+}
+
+type MissingValues struct {
+	Columns []string
+}
+
+func (w MissingValues) Error() string {
+	return fmt.Sprintf("row is missing values %s", w.Columns)
+}
+
+func (w MissingValues) Code() string {
+	return "missing_values"
+}
+
+func (w MissingValues) Severity() Severity {
+	return SeverityError
+}
+
+type InvalidAreaReference struct {
+	AreaID string
+}
+
+func (w InvalidAreaReference) Error() string {
+	return fmt.Sprintf("no area with ID %q", w.AreaID)
+}
+
+func (w InvalidAreaReference) Code() string {
+	return "invalid_area_reference"
+}
+
+func (w InvalidAreaReference) Severity() Severity {
+	return SeverityError
+}
+
+type InvalidBookingRuleReference struct {
+	BookingRuleID string
+}
+
+func (w InvalidBookingRuleReference) Error() string {
+	return fmt.Sprintf("no booking rule with ID %q", w.BookingRuleID)
+}
+
+func (w InvalidBookingRuleReference) Code() string {
+	return "invalid_booking_rule_reference"
+}
+
+func (w InvalidBookingRuleReference) Severity() Severity {
+	return SeverityWarning
+}
+
+type InvalidFareMediaReference struct {
+	FareMediaID string
+}
+
+func (w InvalidFareMediaReference) Error() string {
+	return fmt.Sprintf("no fare media with ID %q", w.FareMediaID)
+}
+
+func (w InvalidFareMediaReference) Code() string {
+	return "invalid_fare_media_reference"
+}
+
+func (w InvalidFareMediaReference) Severity() Severity {
+	return SeverityError
+}
+
+type InvalidFareProductReference struct {
+	FareProductID string
+}
+
+func (w InvalidFareProductReference) Error() string {
+	return fmt.Sprintf("no fare product with ID %q", w.FareProductID)
+}
+
+func (w InvalidFareProductReference) Code() string {
+	return "invalid_fare_product_reference"
+}
+
+func (w InvalidFareProductReference) Severity() Severity {
+	return SeverityError
+}
+
+type InvalidLegGroupReference struct {
+	LegGroupID string
+}
+
+func (w InvalidLegGroupReference) Error() string {
+	return fmt.Sprintf("no fare leg rule with leg group ID %q", w.LegGroupID)
+}
+
+func (w InvalidLegGroupReference) Code() string {
+	return "invalid_leg_group_reference"
+}
+
+func (w InvalidLegGroupReference) Severity() Severity {
+	return SeverityError
+}
+
+type InvalidLevelReference struct {
+	LevelID string
+}
+
+func (w InvalidLevelReference) Error() string {
+	return fmt.Sprintf("no level with ID %q", w.LevelID)
+}
+
+func (w InvalidLevelReference) Code() string {
+	return "invalid_level_reference"
+}
+
+func (w InvalidLevelReference) Severity() Severity {
+	return SeverityWarning
+}
+
+type InvalidLocationReference struct {
+	LocationID string
+}
+
+func (w InvalidLocationReference) Error() string {
+	return fmt.Sprintf("no location with ID %q", w.LocationID)
+}
+
+func (w InvalidLocationReference) Code() string {
+	return "invalid_location_reference"
+}
+
+func (w InvalidLocationReference) Severity() Severity {
+	return SeverityError
+}
+
+type InvalidLocationGroupReference struct {
+	LocationGroupID string
+}
+
+func (w InvalidLocationGroupReference) Error() string {
+	return fmt.Sprintf("no location group with ID %q", w.LocationGroupID)
+}
+
+func (w InvalidLocationGroupReference) Code() string {
+	return "invalid_location_group_reference"
+}
+
+func (w InvalidLocationGroupReference) Severity() Severity {
+	return SeverityError
+}
+
+type InvalidNetworkReference struct {
+	NetworkID string
+}
+
+func (w InvalidNetworkReference) Error() string {
+	return fmt.Sprintf("no network with ID %q", w.NetworkID)
+}
+
+func (w InvalidNetworkReference) Code() string {
+	return "invalid_network_reference"
+}
+
+func (w InvalidNetworkReference) Severity() Severity {
+	return SeverityError
+}
+
+type InvalidServiceReference struct {
+	ServiceID string
+}
+
+func (w InvalidServiceReference) Error() string {
+	return fmt.Sprintf("no service with ID %q", w.ServiceID)
+}
+
+func (w InvalidServiceReference) Code() string {
+	return "invalid_service_reference"
+}
+
+func (w InvalidServiceReference) Severity() Severity {
+	return SeverityError
+}
+
+type InvalidShapeReference struct {
+	ShapeID string
+}
+
+func (w InvalidShapeReference) Error() string {
+	return fmt.Sprintf("no shape with ID %q", w.ShapeID)
+}
+
+func (w InvalidShapeReference) Code() string {
+	return "invalid_shape_reference"
+}
+
+func (w InvalidShapeReference) Severity() Severity {
+	return SeverityWarning
+}
+
+type InvalidStopReference struct {
+	StopID string
+}
+
+func (w InvalidStopReference) Error() string {
+	return fmt.Sprintf("no stop with ID %q", w.StopID)
+}
+
+func (w InvalidStopReference) Code() string {
+	return "invalid_stop_reference"
+}
+
+func (w InvalidStopReference) Severity() Severity {
+	return SeverityError
+}
+
+type InvalidTimeframeReference struct {
+	TimeframeGroupID string
+}
+
+func (w InvalidTimeframeReference) Error() string {
+	return fmt.Sprintf("no timeframe with group ID %q", w.TimeframeGroupID)
+}
+
+func (w InvalidTimeframeReference) Code() string {
+	return "invalid_timeframe_reference"
+}
+
+func (w InvalidTimeframeReference) Severity() Severity {
+	return SeverityError
+}
+
+type ConditionallyForbiddenFile struct {
+	Reason string
+}
+
+func (w ConditionallyForbiddenFile) Error() string {
+	return fmt.Sprintf("file is forbidden and was ignored: %s", w.Reason)
+}
+
+func (w ConditionallyForbiddenFile) Code() string {
+	return "conditionally_forbidden_file"
+}
+
+func (w ConditionallyForbiddenFile) Severity() Severity {
+	return SeverityWarning
+}
+
+type RouteInMultipleNetworks struct {
+	RouteID string
+}
+
+func (w RouteInMultipleNetworks) Error() string {
+	return fmt.Sprintf("route %q is already in a network", w.RouteID)
+}
+
+func (w RouteInMultipleNetworks) Code() string {
+	return "route_in_multiple_networks"
+}
+
+func (w RouteInMultipleNetworks) Severity() Severity {
+	return SeverityWarning
+}
+
+type InvalidGeoJSON struct {
+	Reason string
+}
+
+func (w InvalidGeoJSON) Error() string {
+	return fmt.Sprintf("invalid GeoJSON: %s", w.Reason)
+}
+
+func (w InvalidGeoJSON) Code() string {
+	return "invalid_geojson"
+}
+
+func (w InvalidGeoJSON) Severity() Severity {
+	return SeverityError
+}
+
+type MutuallyExclusiveColumns struct {
+	Columns []string
+}
+
+func (w MutuallyExclusiveColumns) Error() string {
+	return fmt.Sprintf("only one of the columns %s can have a value", w.Columns)
+}
+
+func (w MutuallyExclusiveColumns) Code() string {
+	return "mutually_exclusive_columns"
+}
+
+func (w MutuallyExclusiveColumns) Severity() Severity {
+	return SeverityError
+}
+
+type MissingConditionallyRequiredFile struct {
+	Reason string
+}
+
+func (w MissingConditionallyRequiredFile) Error() string {
+	return fmt.Sprintf("file is missing but required: %s", w.Reason)
+}
+
+func (w MissingConditionallyRequiredFile) Code() string {
+	return "missing_conditionally_required_file"
+}
+
+func (w MissingConditionallyRequiredFile) Severity() Severity {
+	return SeverityError
+}
+
+type MultipleFeedInfoRows struct{}
+
+func (w MultipleFeedInfoRows) Error() string {
+	return "feed_info.txt must contain a single row; subsequent rows were ignored"
+}
+
+func (w MultipleFeedInfoRows) Code() string {
+	return "multiple_feed_info_rows"
+}
+
+func (w MultipleFeedInfoRows) Severity() Severity {
+	return SeverityWarning
+}
+
+type DuplicateID struct {
+	Column string
+	ID     string
+}
+
+func (w DuplicateID) Error() string {
+	return fmt.Sprintf("duplicate %s %q", w.Column, w.ID)
+}
+
+func (w DuplicateID) Code() string {
+	return "duplicate_id"
+}
+
+func (w DuplicateID) Severity() Severity {
+	return SeverityError
+}
+
+type UnparsableTime struct {
+	Column string
+	Value  string
+}
+
+func (w UnparsableTime) Error() string {
+	return fmt.Sprintf("unparsable date or time %q for column %s", w.Value, w.Column)
+}
+
+func (w UnparsableTime) Code() string {
+	return "unparsable_time"
+}
+
+func (w UnparsableTime) Severity() Severity {
+	return SeverityError
+}
+
+type InvalidStopSequence struct {
+	Value string
+}
+
+func (w InvalidStopSequence) Error() string {
+	return fmt.Sprintf("invalid stop sequence %q", w.Value)
+}
+
+func (w InvalidStopSequence) Code() string {
+	return "invalid_stop_sequence"
+}
+
+func (w InvalidStopSequence) Severity() Severity {
+	return SeverityError
+}
+
+// UntimedStopTime is a stop time without arrival, departure or pickup/drop-off window times.
+// These are valid for stops between timepoints, so this is not an error in the feed, but the
+// parser does not support them yet and skips the row.
+type UntimedStopTime struct {
+	TripID       string
+	StopSequence string
+}
+
+func (w UntimedStopTime) Error() string {
+	return fmt.Sprintf("stop time %s of trip %q has no times and was skipped", w.StopSequence, w.TripID)
+}
+
+func (w UntimedStopTime) Code() string {
+	return "untimed_stop_time"
+}
+
+func (w UntimedStopTime) Severity() Severity {
+	return SeverityWarning
+}
+
+type StopSequenceNotIncreasing struct {
+	TripID       string
+	StopSequence int
+}
+
+func (w StopSequenceNotIncreasing) Error() string {
+	return fmt.Sprintf("stop sequence %d of trip %q is not greater than the previous stop sequence", w.StopSequence, w.TripID)
+}
+
+func (w StopSequenceNotIncreasing) Code() string {
+	return "stop_sequence_not_increasing"
+}
+
+func (w StopSequenceNotIncreasing) Severity() Severity {
+	return SeverityError
+}
+
+type StopTimesNotIncreasing struct {
+	TripID       string
+	StopSequence int
+}
+
+func (w StopTimesNotIncreasing) Error() string {
+	return fmt.Sprintf("times of trip %q decrease at stop sequence %d", w.TripID, w.StopSequence)
+}
+
+func (w StopTimesNotIncreasing) Code() string {
+	return "stop_times_not_increasing"
+}
+
+func (w StopTimesNotIncreasing) Severity() Severity {
+	return SeverityError
+}
+
+type InvalidCalendarRange struct {
+	ServiceID string
+}
+
+func (w InvalidCalendarRange) Error() string {
+	return fmt.Sprintf("service %q ends before it starts", w.ServiceID)
+}
+
+func (w InvalidCalendarRange) Code() string {
+	return "invalid_calendar_range"
+}
+
+func (w InvalidCalendarRange) Severity() Severity {
+	return SeverityError
+}
+
+type OverlappingFrequencies struct {
+	TripID string
+}
+
+func (w OverlappingFrequencies) Error() string {
+	return fmt.Sprintf("frequencies of trip %q overlap", w.TripID)
+}
+
+func (w OverlappingFrequencies) Code() string {
+	return "overlapping_frequencies"
+}
+
+func (w OverlappingFrequencies) Severity() Severity {
+	return SeverityError
+}
+
+type UnreasonableSpeed struct {
+	TripID     string
+	FromStopID string
+	ToStopID   string
+	// Speed in kilometers per hour.
+	Speed float64
+}
+
+func (w UnreasonableSpeed) Error() string {
+	return fmt.Sprintf("trip %q travels from stop %q to stop %q at %.0f km/h", w.TripID, w.FromStopID, w.ToStopID, w.Speed)
+}
+
+func (w UnreasonableSpeed) Code() string {
+	return "unreasonable_speed"
+}
+
+func (w UnreasonableSpeed) Severity() Severity {
+	return SeverityWarning
+}
+
+type StopTooFarFromShape struct {
+	StopID  string
+	ShapeID string
+	// Distance in meters.
+	Distance float64
+}
+
+func (w StopTooFarFromShape) Error() string {
+	return fmt.Sprintf("stop %q is %.0f m from shape %q", w.StopID, w.Distance, w.ShapeID)
+}
+
+func (w StopTooFarFromShape) Code() string {
+	return "stop_too_far_from_shape"
+}
+
+func (w StopTooFarFromShape) Severity() Severity {
+	return SeverityWarning
+}
+
+type UnusedEntity struct {
+	Column string
+	ID     string
+}
+
+func (w UnusedEntity) Error() string {
+	return fmt.Sprintf("%s %q is not used", w.Column, w.ID)
+}
+
+func (w UnusedEntity) Code() string {
+	return "unused_entity"
+}
+
+func (w UnusedEntity) Severity() Severity {
+	return SeverityInfo
+}
+
+type CoordinatesOutOfRange struct {
+	ID        string
+	Latitude  float64
+	Longitude float64
+}
+
+func (w CoordinatesOutOfRange) Error() string {
+	return fmt.Sprintf("coordinates (%f, %f) of %q are out of range", w.Latitude, w.Longitude, w.ID)
+}
+
+func (w CoordinatesOutOfRange) Code() string {
+	return "coordinates_out_of_range"
+}
+
+func (w CoordinatesOutOfRange) Severity() Severity {
+	return SeverityError
+}
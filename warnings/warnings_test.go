@@ -1,7 +1,157 @@
 package warnings
 
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jamespfennell/gtfs/constants"
+)
+
 // Verify that StaticWarningKind satisfies the error interface.
 var (
 	w StaticWarningKind = nil
 	e error             = w
 )
+
+var allKinds = []StaticWarningKind{
+	MissingColumns{},
+	AgencyMissingValues{},
+	FareAttributeMissingValues{},
+	InvalidValue{},
+	InvalidAgencyReference{},
+	InvalidFareReference{},
+	InvalidRouteReference{},
+	InvalidTripReference{},
+	InvalidZoneReference{},
+	MissingValues{},
+	InvalidAreaReference{},
+	InvalidBookingRuleReference{},
+	InvalidFareMediaReference{},
+	InvalidFareProductReference{},
+	InvalidLegGroupReference{},
+	InvalidLevelReference{},
+	InvalidLocationReference{},
+	InvalidLocationGroupReference{},
+	InvalidNetworkReference{},
+	InvalidServiceReference{},
+	InvalidShapeReference{},
+	InvalidStopReference{},
+	InvalidTimeframeReference{},
+	ConditionallyForbiddenFile{},
+	RouteInMultipleNetworks{},
+	InvalidGeoJSON{},
+	MutuallyExclusiveColumns{},
+	MissingConditionallyRequiredFile{},
+	MultipleFeedInfoRows{},
+	DuplicateID{},
+	UnparsableTime{},
+	InvalidStopSequence{},
+	UntimedStopTime{},
+	StopSequenceNotIncreasing{},
+	StopTimesNotIncreasing{},
+	InvalidCalendarRange{},
+	OverlappingFrequencies{},
+	UnreasonableSpeed{},
+	StopTooFarFromShape{},
+	UnusedEntity{},
+	CoordinatesOutOfRange{},
+}
+
+func TestCodes(t *testing.T) {
+	codeToKind := map[string]StaticWarningKind{}
+	for _, kind := range allKinds {
+		code := kind.Code()
+		if other, ok := codeToKind[code]; ok {
+			t.Errorf("kinds %T and %T have the same code %q", kind, other, code)
+		}
+		codeToKind[code] = kind
+		if kind.Severity() < SeverityInfo || kind.Severity() > SeverityError {
+			t.Errorf("kind %T has invalid severity %s", kind, kind.Severity())
+		}
+	}
+}
+
+func TestSeverityText(t *testing.T) {
+	for _, severity := range []Severity{SeverityInfo, SeverityWarning, SeverityError} {
+		text, err := severity.MarshalText()
+		if err != nil {
+			t.Fatalf("MarshalText(%s) returned error: %s", severity, err)
+		}
+		var got Severity
+		if err := got.UnmarshalText(text); err != nil {
+			t.Fatalf("UnmarshalText(%s) returned error: %s", text, err)
+		}
+		if got != severity {
+			t.Errorf("UnmarshalText(%s) = %s, want %s", text, got, severity)
+		}
+	}
+}
+
+func TestMarshalJSON(t *testing.T) {
+	w := StaticWarning{
+		Kind:          InvalidStopReference{StopID: "stop_2"},
+		File:          constants.StopTimesFile,
+		RowNumber:     2,
+		RowContent:    []string{"trip_1", "stop_2"},
+		HeaderContent: []string{"trip_id", "stop_id"},
+	}
+	got, err := json.Marshal(w)
+	if err != nil {
+		t.Fatalf("json.Marshal() returned error: %s", err)
+	}
+	want := `{"code":"invalid_stop_reference","severity":"error","message":"no stop with ID \"stop_2\"",` +
+		`"details":{"StopID":"stop_2"},"file":"stop_times.txt","row_number":2,` +
+		`"row_content":["trip_1","stop_2"],"header_content":["trip_id","stop_id"]}`
+	if string(got) != want {
+		t.Errorf("json.Marshal() = %s, want %s", got, want)
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	stopWarning := func(stopID string) StaticWarning {
+		return StaticWarning{Kind: InvalidStopReference{StopID: stopID}, File: constants.StopTimesFile}
+	}
+	ws := []StaticWarning{
+		{Kind: DuplicateID{Column: "stop_id", ID: "a"}, File: constants.StopsFile},
+		stopWarning("b"),
+		{Kind: InvalidShapeReference{ShapeID: "c"}, File: constants.TripsFile},
+		stopWarning("d"),
+		stopWarning("e"),
+		{Kind: InvalidStopReference{StopID: "f"}, File: constants.TransfersFile},
+	}
+	got := Aggregate(ws, 2)
+	want := []StaticWarningGroup{
+		{
+			Code:     "invalid_stop_reference",
+			Severity: SeverityError,
+			File:     constants.StopTimesFile,
+			Count:    3,
+			Samples:  []StaticWarning{stopWarning("b"), stopWarning("d")},
+		},
+		{
+			Code:     "duplicate_id",
+			Severity: SeverityError,
+			File:     constants.StopsFile,
+			Count:    1,
+			Samples:  []StaticWarning{ws[0]},
+		},
+		{
+			Code:     "invalid_stop_reference",
+			Severity: SeverityError,
+			File:     constants.TransfersFile,
+			Count:    1,
+			Samples:  []StaticWarning{ws[5]},
+		},
+		{
+			Code:     "invalid_shape_reference",
+			Severity: SeverityWarning,
+			File:     constants.TripsFile,
+			Count:    1,
+			Samples:  []StaticWarning{ws[2]},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Aggregate() not the same: %s", diff)
+	}
+}
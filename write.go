@@ -0,0 +1,1183 @@
+package gtfs
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/jamespfennell/gtfs/constants"
+)
+
+// WriteStatic writes the feed as a GTFS static zip file.
+//
+// A file is written for every table that the parser supports, except for optional tables
+// without rows. Optional columns are only written if they are in use: if some row has a value
+// other than the value the parser assumes when the column is missing. The values in the Extra
+// fields of the entities are written as additional columns, in alphabetical order.
+//
+// Parsing the result with ParseStatic and the same options returns the same feed,
+// apart from the warnings.
+func WriteStatic(w io.Writer, static *Static) error {
+	zipWriter := zip.NewWriter(w)
+	calendar, calendarDates := calendarTables(static.Services)
+	areas, stopAreas := areaTables(static.Areas)
+	networks, routeNetworks := networkTables(static.Networks)
+	locationGroups, locationGroupStops := locationGroupTables(static.LocationGroups)
+	for _, table := range []*csvTable{
+		agencyTable(static.Agencies),
+		routesTable(static.Routes),
+		levelsTable(static.Levels),
+		stopsTable(static.Stops),
+		pathwaysTable(static.Pathways),
+		calendar,
+		calendarDates,
+		shapesTable(static.Shapes),
+		tripsTable(static.Trips),
+		transfersTable(static.Transfers),
+		frequenciesTable(static.Trips),
+		locationGroups,
+		locationGroupStops,
+		bookingRulesTable(static.BookingRules),
+		stopTimesTable(static.Trips),
+		fareAttributesTable(static.FareAttributes),
+		fareRulesTable(static.FareRules),
+		areas,
+		stopAreas,
+		networks,
+		routeNetworks,
+		timeframesTable(static.Timeframes),
+		fareMediaTable(static.FareMedia),
+		fareProductsTable(static.FareProducts),
+		fareLegRulesTable(static.FareLegRules),
+		fareLegJoinRulesTable(static.FareLegJoinRules),
+		fareTransferRulesTable(static.FareTransferRules),
+		translationsTable(static.Translations),
+		feedInfoTable(static.FeedInfo),
+		attributionsTable(static.Attributions),
+	} {
+		if err := table.write(zipWriter); err != nil {
+			return err
+		}
+	}
+	if err := writeLocations(zipWriter, static.Locations); err != nil {
+		return err
+	}
+	return zipWriter.Close()
+}
+
+// csvTable contains the rows of a CSV file to be written.
+type csvTable struct {
+	file constants.StaticFile
+	// If true, the file is written even if it has no rows.
+	required bool
+	columns  []csvColumn
+	rows     [][]string
+	extras   []map[string]string
+}
+
+type csvColumn struct {
+	name string
+	// If true, the column is written even if it is not in use.
+	required bool
+	// Value that the parser assumes if the column is missing. The column is only written if some
+	// row has a different value.
+	missingValue string
+}
+
+func requiredColumn(name string) csvColumn {
+	return csvColumn{name: name, required: true}
+}
+
+func optionalColumn(name string) csvColumn {
+	return csvColumn{name: name}
+}
+
+func optionalColumnOr(name, missingValue string) csvColumn {
+	return csvColumn{name: name, missingValue: missingValue}
+}
+
+// addRow adds a row with the values of the columns, in order, and the values of the extra columns.
+func (t *csvTable) addRow(extra map[string]string, values ...string) {
+	t.rows = append(t.rows, values)
+	t.extras = append(t.extras, extra)
+}
+
+func (t *csvTable) write(zipWriter *zip.Writer) error {
+	if len(t.rows) == 0 && !t.required {
+		return nil
+	}
+	var header []string
+	var indices []int
+	for i, column := range t.columns {
+		if column.required || t.inUse(i) {
+			header = append(header, column.name)
+			indices = append(indices, i)
+		}
+	}
+	extraColumnSet := map[string]bool{}
+	for _, extra := range t.extras {
+		for column := range extra {
+			extraColumnSet[column] = true
+		}
+	}
+	var extraColumns []string
+	for column := range extraColumnSet {
+		extraColumns = append(extraColumns, column)
+	}
+	sort.Strings(extraColumns)
+	header = append(header, extraColumns...)
+
+	file, err := zipWriter.Create(string(t.file))
+	if err != nil {
+		return fmt.Errorf("failed to write %q: %w", t.file, err)
+	}
+	writer := csv.NewWriter(file)
+	if err := writer.Write(header); err != nil {
+		return fmt.Errorf("failed to write %q: %w", t.file, err)
+	}
+	record := make([]string, len(header))
+	for r, row := range t.rows {
+		for j, i := range indices {
+			record[j] = row[i]
+		}
+		for j, column := range extraColumns {
+			record[len(indices)+j] = t.extras[r][column]
+		}
+		if err := writer.Write(record); err != nil {
+			return fmt.Errorf("failed to write %q: %w", t.file, err)
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("failed to write %q: %w", t.file, err)
+	}
+	return nil
+}
+
+// inUse returns whether some row has a value in the i-th column other than its missing value.
+func (t *csvTable) inUse(i int) bool {
+	for _, row := range t.rows {
+		if row[i] != t.columns[i].missingValue {
+			return true
+		}
+	}
+	return false
+}
+
+func agencyTable(agencies []Agency) *csvTable {
+	t := &csvTable{
+		file:     constants.AgencyFile,
+		required: true,
+		columns: []csvColumn{
+			optionalColumn("agency_id"),
+			requiredColumn("agency_name"),
+			requiredColumn("agency_url"),
+			requiredColumn("agency_timezone"),
+			optionalColumn("agency_lang"),
+			optionalColumn("agency_phone"),
+			optionalColumn("agency_fare_url"),
+			optionalColumn("agency_email"),
+		},
+	}
+	for i := range agencies {
+		agency := &agencies[i]
+		t.addRow(agency.Extra,
+			agency.Id,
+			agency.Name,
+			agency.Url,
+			agency.Timezone,
+			agency.Language,
+			agency.Phone,
+			agency.FareUrl,
+			agency.Email,
+		)
+	}
+	return t
+}
+
+func routesTable(routes []Route) *csvTable {
+	t := &csvTable{
+		file:     constants.RoutesFile,
+		required: true,
+		columns: []csvColumn{
+			requiredColumn("route_id"),
+			optionalColumn("agency_id"),
+			optionalColumn("route_short_name"),
+			optionalColumn("route_long_name"),
+			optionalColumn("route_desc"),
+			requiredColumn("route_type"),
+			optionalColumn("route_url"),
+			optionalColumnOr("route_color", "FFFFFF"),
+			optionalColumnOr("route_text_color", "000000"),
+			optionalColumn("route_sort_order"),
+			optionalColumnOr("continuous_pickup", formatEnum(PickupDropOffPolicy_No)),
+			optionalColumnOr("continuous_drop_off", formatEnum(PickupDropOffPolicy_No)),
+		},
+	}
+	for i := range routes {
+		route := &routes[i]
+		t.addRow(route.Extra,
+			route.Id,
+			idOf(route.Agency),
+			route.ShortName,
+			route.LongName,
+			route.Description,
+			formatEnum(route.Type),
+			route.Url,
+			route.Color,
+			route.TextColor,
+			formatOptionalInt32(route.SortOrder),
+			formatEnum(route.ContinuousPickup),
+			formatEnum(route.ContinuousDropOff),
+		)
+	}
+	return t
+}
+
+func levelsTable(levels []Level) *csvTable {
+	t := &csvTable{
+		file: constants.LevelsFile,
+		columns: []csvColumn{
+			requiredColumn("level_id"),
+			requiredColumn("level_index"),
+			optionalColumn("level_name"),
+		},
+	}
+	for i := range levels {
+		level := &levels[i]
+		t.addRow(level.Extra, level.Id, formatFloat64(level.Index), level.Name)
+	}
+	return t
+}
+
+func stopsTable(stops []Stop) *csvTable {
+	t := &csvTable{
+		file:     constants.StopsFile,
+		required: true,
+		columns: []csvColumn{
+			requiredColumn("stop_id"),
+			optionalColumn("stop_code"),
+			optionalColumn("stop_name"),
+			optionalColumn("stop_desc"),
+			optionalColumn("stop_lat"),
+			optionalColumn("stop_lon"),
+			optionalColumn("zone_id"),
+			optionalColumn("stop_url"),
+			optionalColumn("location_type"),
+			optionalColumn("parent_station"),
+			optionalColumn("stop_timezone"),
+			optionalColumnOr("wheelchair_boarding", formatEnum(WheelchairBoarding_NotSpecified)),
+			optionalColumn("level_id"),
+			optionalColumn("platform_code"),
+		},
+	}
+	for i := range stops {
+		stop := &stops[i]
+		t.addRow(stop.Extra,
+			stop.Id,
+			stop.Code,
+			stop.Name,
+			stop.Description,
+			formatOptionalFloat64(stop.Latitude),
+			formatOptionalFloat64(stop.Longitude),
+			stop.ZoneId,
+			stop.Url,
+			formatStopType(stop.Type),
+			idOf(stop.Parent),
+			stop.Timezone,
+			formatEnum(stop.WheelchairBoarding),
+			idOf(stop.Level),
+			stop.PlatformCode,
+		)
+	}
+	return t
+}
+
+func pathwaysTable(pathways []Pathway) *csvTable {
+	t := &csvTable{
+		file: constants.PathwaysFile,
+		columns: []csvColumn{
+			requiredColumn("pathway_id"),
+			requiredColumn("from_stop_id"),
+			requiredColumn("to_stop_id"),
+			requiredColumn("pathway_mode"),
+			requiredColumn("is_bidirectional"),
+			optionalColumn("length"),
+			optionalColumn("traversal_time"),
+			optionalColumn("stair_count"),
+			optionalColumn("max_slope"),
+			optionalColumn("min_width"),
+			optionalColumn("signposted_as"),
+			optionalColumn("reversed_signposted_as"),
+		},
+	}
+	for i := range pathways {
+		pathway := &pathways[i]
+		t.addRow(pathway.Extra,
+			pathway.Id,
+			idOf(pathway.From),
+			idOf(pathway.To),
+			formatEnum(pathway.Mode),
+			formatBool(pathway.IsBidirectional),
+			formatOptionalFloat64(pathway.Length),
+			formatOptionalDuration(pathway.TraversalTime, time.Second),
+			formatOptionalInt32(pathway.StairCount),
+			formatOptionalFloat64(pathway.MaxSlope),
+			formatOptionalFloat64(pathway.MinWidth),
+			pathway.SignpostedAs,
+			pathway.ReversedSignpostedAs,
+		)
+	}
+	return t
+}
+
+// calendarTables returns the calendar.txt and calendar_dates.txt files.
+//
+// Services without a calendar range have no row in the calendar.txt file, unless they are built
+// by hand with days of the week or extra columns, in which case StartDate and EndDate are written.
+func calendarTables(services []Service) (*csvTable, *csvTable) {
+	calendar := &csvTable{
+		file: constants.CalendarFile,
+		columns: []csvColumn{
+			requiredColumn("service_id"),
+			requiredColumn("monday"),
+			requiredColumn("tuesday"),
+			requiredColumn("wednesday"),
+			requiredColumn("thursday"),
+			requiredColumn("friday"),
+			requiredColumn("saturday"),
+			requiredColumn("sunday"),
+			requiredColumn("start_date"),
+			requiredColumn("end_date"),
+		},
+	}
+	calendarDates := &csvTable{
+		file: constants.CalendarDatesFile,
+		columns: []csvColumn{
+			requiredColumn("service_id"),
+			requiredColumn("date"),
+			requiredColumn("exception_type"),
+		},
+	}
+	for i := range services {
+		service := &services[i]
+		var first, last time.Time
+		for _, date := range append(append([]time.Time{}, service.AddedDates...), service.RemovedDates...) {
+			if first.IsZero() || date.Before(first) {
+				first = date
+			}
+			if last.IsZero() || last.Before(date) {
+				last = date
+			}
+		}
+		datesOnly := !first.IsZero() && service.Extra == nil && service.CalendarStartDate.IsZero() &&
+			service.StartDate.Equal(first) && service.EndDate.Equal(last) &&
+			!service.Monday && !service.Tuesday && !service.Wednesday && !service.Thursday &&
+			!service.Friday && !service.Saturday && !service.Sunday
+		if !datesOnly {
+			startDate, endDate := service.CalendarStartDate, service.CalendarEndDate
+			if startDate.IsZero() {
+				startDate, endDate = service.StartDate, service.EndDate
+			}
+			calendar.addRow(service.Extra,
+				service.Id,
+				formatBool(service.Monday),
+				formatBool(service.Tuesday),
+				formatBool(service.Wednesday),
+				formatBool(service.Thursday),
+				formatBool(service.Friday),
+				formatBool(service.Saturday),
+				formatBool(service.Sunday),
+				formatDate(startDate),
+				formatDate(endDate),
+			)
+		}
+		for _, date := range service.AddedDates {
+			calendarDates.addRow(nil, service.Id, formatDate(date), "1")
+		}
+		for _, date := range service.RemovedDates {
+			calendarDates.addRow(nil, service.Id, formatDate(date), "2")
+		}
+	}
+	return calendar, calendarDates
+}
+
+func shapesTable(shapes []Shape) *csvTable {
+	t := &csvTable{
+		file: constants.ShapesFile,
+		columns: []csvColumn{
+			requiredColumn("shape_id"),
+			requiredColumn("shape_pt_lat"),
+			requiredColumn("shape_pt_lon"),
+			requiredColumn("shape_pt_sequence"),
+			optionalColumn("shape_dist_traveled"),
+		},
+	}
+	for i := range shapes {
+		shape := &shapes[i]
+		for j := range shape.Points {
+			point := &shape.Points[j]
+			t.addRow(point.Extra,
+				shape.ID,
+				formatFloat64(point.Latitude),
+				formatFloat64(point.Longitude),
+				strconv.Itoa(j),
+				formatOptionalFloat64(point.Distance),
+			)
+		}
+	}
+	return t
+}
+
+func tripsTable(trips []ScheduledTrip) *csvTable {
+	t := &csvTable{
+		file:     constants.TripsFile,
+		required: true,
+		columns: []csvColumn{
+			requiredColumn("route_id"),
+			requiredColumn("service_id"),
+			requiredColumn("trip_id"),
+			optionalColumn("trip_headsign"),
+			optionalColumn("trip_short_name"),
+			optionalColumn("direction_id"),
+			optionalColumn("block_id"),
+			optionalColumn("shape_id"),
+			optionalColumnOr("wheelchair_accessible", formatEnum(WheelchairBoarding_NotSpecified)),
+			optionalColumnOr("bikes_allowed", formatEnum(BikesAllowed_NotSpecified)),
+		},
+	}
+	for i := range trips {
+		trip := &trips[i]
+		t.addRow(trip.Extra,
+			idOf(trip.Route),
+			idOf(trip.Service),
+			trip.ID,
+			trip.Headsign,
+			trip.ShortName,
+			formatDirectionID_GTFSStatic(trip.DirectionId),
+			trip.BlockID,
+			idOf(trip.Shape),
+			formatEnum(trip.WheelchairAccessible),
+			formatEnum(trip.BikesAllowed),
+		)
+	}
+	return t
+}
+
+func transfersTable(transfers []Transfer) *csvTable {
+	t := &csvTable{
+		file: constants.TransfersFile,
+		columns: []csvColumn{
+			optionalColumn("from_stop_id"),
+			optionalColumn("to_stop_id"),
+			optionalColumn("from_route_id"),
+			optionalColumn("to_route_id"),
+			optionalColumn("from_trip_id"),
+			optionalColumn("to_trip_id"),
+			requiredColumn("transfer_type"),
+			optionalColumn("min_transfer_time"),
+		},
+	}
+	for i := range transfers {
+		transfer := &transfers[i]
+		t.addRow(transfer.Extra,
+			idOf(transfer.From),
+			idOf(transfer.To),
+			idOf(transfer.FromRoute),
+			idOf(transfer.ToRoute),
+			idOf(transfer.FromTrip),
+			idOf(transfer.ToTrip),
+			formatEnum(transfer.Type),
+			formatOptionalInt32(transfer.MinTransferTime),
+		)
+	}
+	return t
+}
+
+func frequenciesTable(trips []ScheduledTrip) *csvTable {
+	t := &csvTable{
+		file: constants.FrequenciesFile,
+		columns: []csvColumn{
+			requiredColumn("trip_id"),
+			requiredColumn("start_time"),
+			requiredColumn("end_time"),
+			requiredColumn("headway_secs"),
+			optionalColumnOr("exact_times", formatEnum(FrequencyBased)),
+		},
+	}
+	for i := range trips {
+		trip := &trips[i]
+		for j := range trip.Frequencies {
+			frequency := &trip.Frequencies[j]
+			t.addRow(frequency.Extra,
+				trip.ID,
+				formatGtfsTime(frequency.StartTime),
+				formatGtfsTime(frequency.EndTime),
+				strconv.FormatInt(int64(frequency.Headway/time.Second), 10),
+				formatEnum(frequency.ExactTimes),
+			)
+		}
+	}
+	return t
+}
+
+// locationGroupTables returns the location_groups.txt and location_group_stops.txt files.
+func locationGroupTables(groups []LocationGroup) (*csvTable, *csvTable) {
+	locationGroups := &csvTable{
+		file: constants.LocationGroupsFile,
+		columns: []csvColumn{
+			requiredColumn("location_group_id"),
+			optionalColumn("location_group_name"),
+		},
+	}
+	locationGroupStops := &csvTable{
+		file: constants.LocationGroupStopsFile,
+		columns: []csvColumn{
+			requiredColumn("location_group_id"),
+			requiredColumn("stop_id"),
+		},
+	}
+	for i := range groups {
+		group := &groups[i]
+		locationGroups.addRow(group.Extra, group.Id, group.Name)
+		for _, stop := range group.Stops {
+			locationGroupStops.addRow(nil, group.Id, stop.Id)
+		}
+	}
+	return locationGroups, locationGroupStops
+}
+
+func bookingRulesTable(rules []BookingRule) *csvTable {
+	t := &csvTable{
+		file: constants.BookingRulesFile,
+		columns: []csvColumn{
+			requiredColumn("booking_rule_id"),
+			requiredColumn("booking_type"),
+			optionalColumn("prior_notice_duration_min"),
+			optionalColumn("prior_notice_duration_max"),
+			optionalColumn("prior_notice_last_day"),
+			optionalColumn("prior_notice_last_time"),
+			optionalColumn("prior_notice_start_day"),
+			optionalColumn("prior_notice_start_time"),
+			optionalColumn("prior_notice_service_id"),
+			optionalColumn("message"),
+			optionalColumn("pickup_message"),
+			optionalColumn("drop_off_message"),
+			optionalColumn("phone_number"),
+			optionalColumn("info_url"),
+			optionalColumn("booking_url"),
+		},
+	}
+	for i := range rules {
+		rule := &rules[i]
+		t.addRow(rule.Extra,
+			rule.Id,
+			formatEnum(rule.Type),
+			formatOptionalDuration(rule.PriorNoticeDurationMin, time.Minute),
+			formatOptionalDuration(rule.PriorNoticeDurationMax, time.Minute),
+			formatOptionalInt32(rule.PriorNoticeLastDay),
+			formatOptionalGtfsTime(rule.PriorNoticeLastTime),
+			formatOptionalInt32(rule.PriorNoticeStartDay),
+			formatOptionalGtfsTime(rule.PriorNoticeStartTime),
+			idOf(rule.PriorNoticeService),
+			rule.Message,
+			rule.PickupMessage,
+			rule.DropOffMessage,
+			rule.PhoneNumber,
+			rule.InfoUrl,
+			rule.BookingUrl,
+		)
+	}
+	return t
+}
+
+func stopTimesTable(trips []ScheduledTrip) *csvTable {
+	t := &csvTable{
+		file:     constants.StopTimesFile,
+		required: true,
+		columns: []csvColumn{
+			requiredColumn("trip_id"),
+			optionalColumn("arrival_time"),
+			optionalColumn("departure_time"),
+			requiredColumn("stop_id"),
+			optionalColumn("location_group_id"),
+			optionalColumn("location_id"),
+			requiredColumn("stop_sequence"),
+			optionalColumn("stop_headsign"),
+			optionalColumn("start_pickup_drop_off_window"),
+			optionalColumn("end_pickup_drop_off_window"),
+			optionalColumnOr("pickup_type", formatEnum(PickupDropOffPolicy_Yes)),
+			optionalColumnOr("drop_off_type", formatEnum(PickupDropOffPolicy_Yes)),
+			optionalColumnOr("continuous_pickup", formatEnum(PickupDropOffPolicy_No)),
+			optionalColumnOr("continuous_drop_off", formatEnum(PickupDropOffPolicy_No)),
+			optionalColumn("shape_dist_traveled"),
+			optionalColumnOr("timepoint", "1"),
+			optionalColumn("pickup_booking_rule_id"),
+			optionalColumn("drop_off_booking_rule_id"),
+		},
+	}
+	for i := range trips {
+		trip := &trips[i]
+		for j := 0; j < trip.NumStopTimes(); j++ {
+			stopTime := trip.StopTime(j)
+			arrivalTime := formatGtfsTime(stopTime.ArrivalTime)
+			departureTime := formatGtfsTime(stopTime.DepartureTime)
+			// Stop times with a pickup/drop-off window are parsed with zero arrival and departure times.
+			hasWindow := stopTime.StartPickupDropOffWindow != nil || stopTime.EndPickupDropOffWindow != nil
+			if hasWindow && stopTime.ArrivalTime == 0 && stopTime.DepartureTime == 0 {
+				arrivalTime, departureTime = "", ""
+			}
+			t.addRow(stopTime.Extra,
+				trip.ID,
+				arrivalTime,
+				departureTime,
+				idOf(stopTime.Stop),
+				idOf(stopTime.LocationGroup),
+				idOf(stopTime.Location),
+				strconv.Itoa(stopTime.StopSequence),
+				stopTime.Headsign,
+				formatOptionalGtfsTime(stopTime.StartPickupDropOffWindow),
+				formatOptionalGtfsTime(stopTime.EndPickupDropOffWindow),
+				formatEnum(stopTime.PickupType),
+				formatEnum(stopTime.DropOffType),
+				formatEnum(stopTime.ContinuousPickup),
+				formatEnum(stopTime.ContinuousDropOff),
+				formatOptionalFloat64(stopTime.ShapeDistanceTraveled),
+				formatBool(stopTime.ExactTimes),
+				idOf(stopTime.PickupBookingRule),
+				idOf(stopTime.DropOffBookingRule),
+			)
+		}
+	}
+	return t
+}
+
+func fareAttributesTable(fares []FareAttribute) *csvTable {
+	t := &csvTable{
+		file: constants.FareAttributesFile,
+		columns: []csvColumn{
+			requiredColumn("fare_id"),
+			requiredColumn("price"),
+			requiredColumn("currency_type"),
+			requiredColumn("payment_method"),
+			// An empty value means unlimited transfers.
+			requiredColumn("transfers"),
+			optionalColumn("agency_id"),
+			optionalColumn("transfer_duration"),
+		},
+	}
+	for i := range fares {
+		fare := &fares[i]
+		t.addRow(fare.Extra,
+			fare.Id,
+			formatFloat64(fare.Price),
+			fare.CurrencyType,
+			formatEnum(fare.PaymentMethod),
+			formatOptionalInt32(fare.Transfers),
+			idOf(fare.Agency),
+			formatOptionalDuration(fare.TransferDuration, time.Second),
+		)
+	}
+	return t
+}
+
+func fareRulesTable(rules []FareRule) *csvTable {
+	t := &csvTable{
+		file: constants.FareRulesFile,
+		columns: []csvColumn{
+			requiredColumn("fare_id"),
+			optionalColumn("route_id"),
+			optionalColumn("origin_id"),
+			optionalColumn("destination_id"),
+			optionalColumn("contains_id"),
+		},
+	}
+	for i := range rules {
+		rule := &rules[i]
+		t.addRow(rule.Extra,
+			idOf(rule.Fare),
+			idOf(rule.Route),
+			rule.OriginZoneId,
+			rule.DestinationZoneId,
+			rule.ContainsZoneId,
+		)
+	}
+	return t
+}
+
+// areaTables returns the areas.txt and stop_areas.txt files.
+//
+// The parser appends the rows of the stop_areas.txt file to both the stops of the area and the
+// areas of the stop, so the rows are ordered such that both orders are preserved.
+func areaTables(areas []Area) (*csvTable, *csvTable) {
+	areasTable := &csvTable{
+		file: constants.AreasFile,
+		columns: []csvColumn{
+			requiredColumn("area_id"),
+			optionalColumn("area_name"),
+		},
+	}
+	stopAreas := &csvTable{
+		file: constants.StopAreasFile,
+		columns: []csvColumn{
+			requiredColumn("area_id"),
+			requiredColumn("stop_id"),
+		},
+	}
+	for i := range areas {
+		area := &areas[i]
+		areasTable.addRow(area.Extra, area.Id, area.Name)
+	}
+	// Index of the next stop of each area, and of the next area of each stop, to be written.
+	areaToNext := map[*Area]int{}
+	stopToNext := map[*Stop]int{}
+	// A row can be written once it is next for both its area and its stop.
+	for progress := true; progress; {
+		progress = false
+		for i := range areas {
+			area := &areas[i]
+			for areaToNext[area] < len(area.Stops) {
+				stop := area.Stops[areaToNext[area]]
+				if j := stopToNext[stop]; j < len(stop.Areas) && stop.Areas[j] != area {
+					break
+				}
+				stopAreas.addRow(nil, area.Id, stop.Id)
+				areaToNext[area]++
+				stopToNext[stop]++
+				progress = true
+			}
+		}
+	}
+	// If the stops of the areas and the areas of the stops are inconsistent, the remaining rows
+	// are written in the order of the stops of the areas.
+	for i := range areas {
+		area := &areas[i]
+		for _, stop := range area.Stops[areaToNext[area]:] {
+			stopAreas.addRow(nil, area.Id, stop.Id)
+		}
+	}
+	return areasTable, stopAreas
+}
+
+// networkTables returns the networks.txt and route_networks.txt files.
+//
+// Networks defined by the network_id column of the routes.txt file are also written to these
+// files, which the parser reads into the same networks.
+func networkTables(networks []Network) (*csvTable, *csvTable) {
+	networksTable := &csvTable{
+		file: constants.NetworksFile,
+		columns: []csvColumn{
+			requiredColumn("network_id"),
+			optionalColumn("network_name"),
+		},
+	}
+	routeNetworks := &csvTable{
+		file: constants.RouteNetworksFile,
+		columns: []csvColumn{
+			requiredColumn("network_id"),
+			requiredColumn("route_id"),
+		},
+	}
+	for i := range networks {
+		network := &networks[i]
+		networksTable.addRow(network.Extra, network.Id, network.Name)
+		for _, route := range network.Routes {
+			routeNetworks.addRow(nil, network.Id, route.Id)
+		}
+	}
+	return networksTable, routeNetworks
+}
+
+func timeframesTable(timeframes []Timeframe) *csvTable {
+	t := &csvTable{
+		file: constants.TimeframesFile,
+		columns: []csvColumn{
+			requiredColumn("timeframe_group_id"),
+			optionalColumnOr("start_time", formatGtfsTime(0)),
+			optionalColumnOr("end_time", formatGtfsTime(24*time.Hour)),
+			requiredColumn("service_id"),
+		},
+	}
+	for i := range timeframes {
+		timeframe := &timeframes[i]
+		t.addRow(timeframe.Extra,
+			timeframe.GroupId,
+			formatGtfsTime(timeframe.StartTime),
+			formatGtfsTime(timeframe.EndTime),
+			idOf(timeframe.Service),
+		)
+	}
+	return t
+}
+
+func fareMediaTable(media []FareMedia) *csvTable {
+	t := &csvTable{
+		file: constants.FareMediaFile,
+		columns: []csvColumn{
+			requiredColumn("fare_media_id"),
+			optionalColumn("fare_media_name"),
+			requiredColumn("fare_media_type"),
+		},
+	}
+	for i := range media {
+		m := &media[i]
+		t.addRow(m.Extra, m.Id, m.Name, formatEnum(m.Type))
+	}
+	return t
+}
+
+func fareProductsTable(products []FareProduct) *csvTable {
+	t := &csvTable{
+		file: constants.FareProductsFile,
+		columns: []csvColumn{
+			requiredColumn("fare_product_id"),
+			optionalColumn("fare_product_name"),
+			optionalColumn("fare_media_id"),
+			requiredColumn("amount"),
+			requiredColumn("currency"),
+		},
+	}
+	for i := range products {
+		product := &products[i]
+		t.addRow(product.Extra,
+			product.Id,
+			product.Name,
+			idOf(product.Media),
+			formatFloat64(product.Amount),
+			product.Currency,
+		)
+	}
+	return t
+}
+
+func fareLegRulesTable(rules []FareLegRule) *csvTable {
+	t := &csvTable{
+		file: constants.FareLegRulesFile,
+		columns: []csvColumn{
+			optionalColumn("leg_group_id"),
+			optionalColumn("network_id"),
+			optionalColumn("from_area_id"),
+			optionalColumn("to_area_id"),
+			optionalColumn("from_timeframe_group_id"),
+			optionalColumn("to_timeframe_group_id"),
+			requiredColumn("fare_product_id"),
+			optionalColumn("rule_priority"),
+		},
+	}
+	for i := range rules {
+		rule := &rules[i]
+		t.addRow(rule.Extra,
+			idOf(rule.LegGroup),
+			idOf(rule.Network),
+			idOf(rule.FromArea),
+			idOf(rule.ToArea),
+			timeframeGroupID(rule.FromTimeframes),
+			timeframeGroupID(rule.ToTimeframes),
+			fareProductID(rule.FareProducts),
+			formatOptionalInt32(rule.RulePriority),
+		)
+	}
+	return t
+}
+
+func fareLegJoinRulesTable(rules []FareLegJoinRule) *csvTable {
+	t := &csvTable{
+		file: constants.FareLegJoinRulesFile,
+		columns: []csvColumn{
+			requiredColumn("from_network_id"),
+			requiredColumn("to_network_id"),
+			optionalColumn("from_stop_id"),
+			optionalColumn("to_stop_id"),
+		},
+	}
+	for i := range rules {
+		rule := &rules[i]
+		t.addRow(rule.Extra,
+			idOf(rule.FromNetwork),
+			idOf(rule.ToNetwork),
+			idOf(rule.FromStop),
+			idOf(rule.ToStop),
+		)
+	}
+	return t
+}
+
+func fareTransferRulesTable(rules []FareTransferRule) *csvTable {
+	t := &csvTable{
+		file: constants.FareTransferRulesFile,
+		columns: []csvColumn{
+			optionalColumn("from_leg_group_id"),
+			optionalColumn("to_leg_group_id"),
+			optionalColumn("transfer_count"),
+			optionalColumn("duration_limit"),
+			optionalColumnOr("duration_limit_type", formatEnum(DurationLimitType_DepartureToArrival)),
+			requiredColumn("fare_transfer_type"),
+			optionalColumn("fare_product_id"),
+		},
+	}
+	for i := range rules {
+		rule := &rules[i]
+		t.addRow(rule.Extra,
+			idOf(rule.FromLegGroup),
+			idOf(rule.ToLegGroup),
+			formatOptionalInt32(rule.TransferCount),
+			formatOptionalDuration(rule.DurationLimit, time.Second),
+			formatEnum(rule.DurationLimitType),
+			formatEnum(rule.FareTransferType),
+			fareProductID(rule.FareProducts),
+		)
+	}
+	return t
+}
+
+// timeframeGroupID returns the group ID of the timeframes, which all have the same group ID.
+func timeframeGroupID(timeframes []*Timeframe) string {
+	if len(timeframes) == 0 {
+		return ""
+	}
+	return timeframes[0].GroupId
+}
+
+// fareProductID returns the ID of the fare products, which all have the same ID.
+func fareProductID(products []*FareProduct) string {
+	if len(products) == 0 {
+		return ""
+	}
+	return products[0].Id
+}
+
+func translationsTable(translations []Translation) *csvTable {
+	t := &csvTable{
+		file: constants.TranslationsFile,
+		columns: []csvColumn{
+			requiredColumn("table_name"),
+			requiredColumn("field_name"),
+			requiredColumn("language"),
+			requiredColumn("translation"),
+			optionalColumn("record_id"),
+			optionalColumn("record_sub_id"),
+			optionalColumn("field_value"),
+		},
+	}
+	for i := range translations {
+		translation := &translations[i]
+		t.addRow(translation.Extra,
+			translation.TableName,
+			translation.FieldName,
+			translation.Language,
+			translation.Translation,
+			translation.RecordId,
+			translation.RecordSubId,
+			translation.FieldValue,
+		)
+	}
+	return t
+}
+
+func feedInfoTable(feedInfo *FeedInfo) *csvTable {
+	t := &csvTable{
+		file: constants.FeedInfoFile,
+		columns: []csvColumn{
+			requiredColumn("feed_publisher_name"),
+			requiredColumn("feed_publisher_url"),
+			requiredColumn("feed_lang"),
+			optionalColumn("default_lang"),
+			optionalColumn("feed_start_date"),
+			optionalColumn("feed_end_date"),
+			optionalColumn("feed_version"),
+			optionalColumn("feed_contact_email"),
+			optionalColumn("feed_contact_url"),
+		},
+	}
+	if feedInfo != nil {
+		t.addRow(feedInfo.Extra,
+			feedInfo.PublisherName,
+			feedInfo.PublisherUrl,
+			feedInfo.Language,
+			feedInfo.DefaultLanguage,
+			formatDate(feedInfo.StartDate),
+			formatDate(feedInfo.EndDate),
+			feedInfo.Version,
+			feedInfo.ContactEmail,
+			feedInfo.ContactUrl,
+		)
+	}
+	return t
+}
+
+func attributionsTable(attributions []Attribution) *csvTable {
+	t := &csvTable{
+		file: constants.AttributionsFile,
+		columns: []csvColumn{
+			optionalColumn("attribution_id"),
+			optionalColumn("agency_id"),
+			optionalColumn("route_id"),
+			optionalColumn("trip_id"),
+			requiredColumn("organization_name"),
+			optionalColumnOr("is_producer", formatBool(false)),
+			optionalColumnOr("is_operator", formatBool(false)),
+			optionalColumnOr("is_authority", formatBool(false)),
+			optionalColumn("attribution_url"),
+			optionalColumn("attribution_email"),
+			optionalColumn("attribution_phone"),
+		},
+	}
+	for i := range attributions {
+		attribution := &attributions[i]
+		t.addRow(attribution.Extra,
+			attribution.Id,
+			idOf(attribution.Agency),
+			idOf(attribution.Route),
+			idOf(attribution.Trip),
+			attribution.OrganizationName,
+			formatBool(attribution.IsProducer),
+			formatBool(attribution.IsOperator),
+			formatBool(attribution.IsAuthority),
+			attribution.Url,
+			attribution.Email,
+			attribution.Phone,
+		)
+	}
+	return t
+}
+
+// writeLocations writes the locations.geojson file, if there are locations.
+//
+// Locations with a single polygon are written as GeoJSON Polygons and other locations
+// as GeoJSON MultiPolygons.
+func writeLocations(zipWriter *zip.Writer, locations []Location) error {
+	if len(locations) == 0 {
+		return nil
+	}
+	collection := geoJSONFeatureCollection{Type: "FeatureCollection"}
+	for _, location := range locations {
+		feature := geoJSONFeature{Id: location.Id}
+		feature.Properties.StopName = location.Name
+		feature.Properties.StopDesc = location.Description
+		var polygons [][][][2]float64
+		for _, polygon := range location.Polygons {
+			var rawPolygon [][][2]float64
+			for _, ring := range polygon {
+				rawRing := make([][2]float64, 0, len(ring))
+				for _, point := range ring {
+					// GeoJSON positions are longitude first.
+					rawRing = append(rawRing, [2]float64{point.Longitude, point.Latitude})
+				}
+				rawPolygon = append(rawPolygon, rawRing)
+			}
+			polygons = append(polygons, rawPolygon)
+		}
+		var coordinates any = polygons
+		feature.Geometry.Type = "MultiPolygon"
+		if len(polygons) == 1 {
+			coordinates = polygons[0]
+			feature.Geometry.Type = "Polygon"
+		}
+		var err error
+		if feature.Geometry.Coordinates, err = json.Marshal(coordinates); err != nil {
+			return fmt.Errorf("failed to write %q: %w", constants.LocationsFile, err)
+		}
+		collection.Features = append(collection.Features, feature)
+	}
+	file, err := zipWriter.Create(string(constants.LocationsFile))
+	if err != nil {
+		return fmt.Errorf("failed to write %q: %w", constants.LocationsFile, err)
+	}
+	if err := json.NewEncoder(file).Encode(collection); err != nil {
+		return fmt.Errorf("failed to write %q: %w", constants.LocationsFile, err)
+	}
+	return nil
+}
+
+// idOf returns the ID of the referenced entity, or the empty string if the reference is nil.
+func idOf[T any, P interface {
+	*T
+	id() string
+}](entity P) string {
+	if entity == nil {
+		return ""
+	}
+	return entity.id()
+}
+
+// The id methods return the IDs used for references to the entities in the written files.
+func (agency *Agency) id() string       { return agency.Id }
+func (route *Route) id() string         { return route.Id }
+func (stop *Stop) id() string           { return stop.Id }
+func (level *Level) id() string         { return level.Id }
+func (service *Service) id() string     { return service.Id }
+func (trip *ScheduledTrip) id() string  { return trip.ID }
+func (shape *Shape) id() string         { return shape.ID }
+func (location *Location) id() string   { return location.Id }
+func (group *LocationGroup) id() string { return group.Id }
+func (rule *BookingRule) id() string    { return rule.Id }
+func (fare *FareAttribute) id() string  { return fare.Id }
+func (area *Area) id() string           { return area.Id }
+func (network *Network) id() string     { return network.Id }
+func (media *FareMedia) id() string     { return media.Id }
+func (group *FareLegGroup) id() string  { return group.Id }
+
+// formatEnum formats an enum whose values are the values of the GTFS static field.
+func formatEnum[T ~int32](value T) string {
+	return strconv.FormatInt(int64(value), 10)
+}
+
+func formatBool(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
+
+func formatFloat64(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func formatOptionalFloat64(f *float64) string {
+	if f == nil {
+		return ""
+	}
+	return formatFloat64(*f)
+}
+
+func formatOptionalInt32(i *int32) string {
+	if i == nil {
+		return ""
+	}
+	return strconv.FormatInt(int64(*i), 10)
+}
+
+// formatOptionalDuration formats the duration as an integer number of the unit.
+func formatOptionalDuration(d *time.Duration, unit time.Duration) string {
+	if d == nil {
+		return ""
+	}
+	return strconv.FormatInt(int64(*d/unit), 10)
+}
+
+// formatGtfsTime formats the duration since the start of the service day as HH:MM:SS.
+// The number of hours can exceed 24 for trips that run after midnight.
+func formatGtfsTime(d time.Duration) string {
+	seconds := int64(d / time.Second)
+	return fmt.Sprintf("%02d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60)
+}
+
+func formatOptionalGtfsTime(d *time.Duration) string {
+	if d == nil {
+		return ""
+	}
+	return formatGtfsTime(*d)
+}
+
+// formatDate formats the date as YYYYMMDD in its location, or returns the empty string if the date is zero.
+func formatDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format("20060102")
+}
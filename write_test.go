@@ -0,0 +1,167 @@
+package gtfs
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/jamespfennell/gtfs/constants"
+)
+
+func TestWriteStatic_RoundTrip(t *testing.T) {
+	testCases := staticTestCases()
+	for _, tc := range []staticTestCase{
+		{desc: "pathways", content: newPathwaysZipBuilder().build()},
+		{desc: "flex", content: newFlexZipBuilder().build()},
+		{desc: "fares v2", content: newFaresV2ZipBuilder().build()},
+		{
+			desc: "areas, networks, fares and feed info",
+			content: newZipBuilderWithDefaults().add(
+				"agency.txt",
+				"agency_id,agency_name,agency_url,agency_timezone",
+				"a,b,c,America/New_York",
+			).add(
+				"routes.txt",
+				"route_id,route_type,route_color,network_id",
+				"route_id,3,FF0000,network_1",
+				"route_2,1,,network_1",
+				"route_3,3,FFFFFF,",
+			).add(
+				"stops.txt",
+				"stop_id,zone_id,stop_lat,stop_lon",
+				"stop_1,zone_1,40.1,-73.25",
+				"stop_2,zone_2,40.2,-73.125",
+			).add(
+				"calendar_dates.txt",
+				"service_id,date,exception_type",
+				"service_id,20220510,1",
+				"other_service,20220602,1",
+				"other_service,20220601,1",
+				"other_service,20220603,2",
+			).add(
+				"stop_times.txt",
+				"trip_id,stop_id,stop_sequence,arrival_time,departure_time,shape_dist_traveled",
+				"trip_id,stop_1,1,08:00:00,08:00:00,",
+				"trip_id,stop_2,2,08:10:00,08:11:00,1.5",
+			).add(
+				"areas.txt",
+				"area_id,area_name",
+				"area_1,Area 1",
+				"area_2,Area 2",
+			).add(
+				"stop_areas.txt",
+				"area_id,stop_id",
+				"area_2,stop_2",
+				"area_1,stop_1",
+				"area_1,stop_2",
+				"area_2,stop_1",
+			).add(
+				"fare_attributes.txt",
+				"fare_id,price,currency_type,payment_method,transfers,transfer_duration",
+				"fare_1,2.75,USD,1,,3600",
+			).add(
+				"fare_rules.txt",
+				"fare_id,route_id,origin_id",
+				"fare_1,route_id,zone_1",
+			).add(
+				"translations.txt",
+				"table_name,field_name,language,translation,record_id",
+				"stops,stop_name,fr,Arrêt,stop_1",
+			).add(
+				"feed_info.txt",
+				"feed_publisher_name,feed_publisher_url,feed_lang,feed_start_date",
+				"Publisher,https://example.com,en,20220501",
+			).build(),
+		},
+		{
+			desc: "extra columns",
+			content: newZipBuilderWithDefaults().add(
+				"stops.txt",
+				"stop_id,stop_name,x_b,x_a",
+				"stop_1,Stop 1,b1,",
+				"stop_2,Stop 2,,a2",
+			).build(),
+			opts: ParseStaticOptions{KeepExtraColumns: true},
+		},
+	} {
+		testCases = append(testCases, tc)
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			want, err := ParseStatic(tc.content, tc.opts)
+			if err != nil {
+				t.Fatalf("error when parsing: %s", err)
+			}
+			var b bytes.Buffer
+			if err := WriteStatic(&b, want); err != nil {
+				t.Fatalf("error when writing: %s", err)
+			}
+			got, err := ParseStatic(b.Bytes(), tc.opts)
+			if err != nil {
+				t.Fatalf("error when parsing written feed: %s", err)
+			}
+			// Empty optional files are not written, so empty slices in the original feed are nil.
+			opts := []cmp.Option{cmpopts.IgnoreFields(Static{}, "Warnings"), cmpopts.EquateEmpty()}
+			if diff := cmp.Diff(want, got, opts...); diff != "" {
+				t.Errorf("written feed not the same: %s", diff)
+			}
+		})
+	}
+}
+
+func TestWriteStatic_Columns(t *testing.T) {
+	static, err := ParseStatic(newZipBuilderWithDefaults().add(
+		"routes.txt",
+		"route_id,route_type,route_short_name,route_long_name,route_color,continuous_pickup",
+		"route_id,3,1,,FFFFFF,",
+		"route_2,3,2,,FF0000,1",
+	).add(
+		"stop_times.txt",
+		"trip_id,stop_id,stop_sequence,arrival_time,departure_time,pickup_type,drop_off_type,timepoint",
+		"trip_id,stop_id,1,08:00:00,08:00:00,,0,",
+		"trip_id,stop_id,2,25:00:00,25:01:00,1,,1",
+	).build(), ParseStaticOptions{})
+	if err != nil {
+		t.Fatalf("error when parsing: %s", err)
+	}
+	var b bytes.Buffer
+	if err := WriteStatic(&b, static); err != nil {
+		t.Fatalf("error when writing: %s", err)
+	}
+	reader, err := zip.NewReader(bytes.NewReader(b.Bytes()), int64(b.Len()))
+	if err != nil {
+		t.Fatalf("error when reading zip: %s", err)
+	}
+	for _, tc := range []struct {
+		file constants.StaticFile
+		want string
+	}{
+		{
+			file: constants.RoutesFile,
+			want: "route_id,agency_id,route_short_name,route_type,route_color\n" +
+				"route_id,a,1,3,FFFFFF\n" +
+				"route_2,a,2,3,FF0000\n",
+		},
+		{
+			file: constants.StopTimesFile,
+			want: "trip_id,arrival_time,departure_time,stop_id,stop_sequence,pickup_type,timepoint\n" +
+				"trip_id,08:00:00,08:00:00,stop_id,1,0,0\n" +
+				"trip_id,25:00:00,25:01:00,stop_id,2,1,1\n",
+		},
+	} {
+		file, err := reader.Open(string(tc.file))
+		if err != nil {
+			t.Fatalf("error when opening %s: %s", tc.file, err)
+		}
+		got, err := io.ReadAll(file)
+		if err != nil {
+			t.Fatalf("error when reading %s: %s", tc.file, err)
+		}
+		if diff := cmp.Diff(tc.want, string(got)); diff != "" {
+			t.Errorf("%s not the same: %s", tc.file, diff)
+		}
+	}
+}